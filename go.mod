@@ -11,5 +11,6 @@ require (
 	github.com/klauspost/compress v1.15.7 // indirect
 	github.com/lib/pq v1.10.6
 	github.com/valyala/fasthttp v1.38.0
-	golang.org/x/net v0.0.0-20220708220712-1185a9018129 // indirect
+	golang.org/x/net v0.17.0
+	golang.org/x/text v0.13.0
 )
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+
+	"github.com/valyala/fasthttp"
+)
+
+// DomainCertificatesGET returns the route handler for GET /domains/:name/certificates
+func (app *APP) DomainCertificatesGET(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	ctx.Response.Header.SetBytesV("Access-Control-Allow-Origin", ctx.Request.Header.Peek("Origin"))
+
+	domainName, _ := ctx.UserValue("name").(string)
+
+	certificates, customErr := app.GetCertificates(domainName)
+	if customErr != nil {
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.Response.Header.SetContentType("application/json")
+	ctx.Response.SetStatusCode(fasthttp.StatusOK)
+
+	err := json.NewEncoder(ctx).Encode(certificates)
+	if err != nil {
+		errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+		customErr := wrappedErr.New(fasthttp.StatusInternalServerError, "DomainCertificatesGET", errMessage)
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+}
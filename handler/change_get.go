@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+
+	"github.com/valyala/fasthttp"
+)
+
+// DomainChangesGET returns the route handler for GET /domains/:name/changes
+func (app *APP) DomainChangesGET(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	ctx.Response.Header.SetBytesV("Access-Control-Allow-Origin", ctx.Request.Header.Peek("Origin"))
+
+	domainName, _ := ctx.UserValue("name").(string)
+
+	events, customErr := app.GetChangeEvents(domainName)
+	if customErr != nil {
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.Response.Header.SetContentType("application/json")
+	ctx.Response.SetStatusCode(fasthttp.StatusOK)
+
+	err := json.NewEncoder(ctx).Encode(events)
+	if err != nil {
+		errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+		customErr := wrappedErr.New(fasthttp.StatusInternalServerError, "DomainChangesGET", errMessage)
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+}
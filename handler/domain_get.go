@@ -6,6 +6,7 @@ import (
 	"log"
 
 	wrappedErr "domain-info-api/platform/errorhandling"
+	hostinfo "domain-info-api/platform/hostinfo"
 
 	"github.com/valyala/fasthttp"
 )
@@ -16,7 +17,23 @@ func (app *APP) DomainGET(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
 	ctx.Response.Header.SetBytesV("Access-Control-Allow-Origin", ctx.Request.Header.Peek("Origin"))
 
-	domains, customErr := app.GetAllDomains()
+	var domains *hostinfo.Items
+	var customErr *wrappedErr.Error
+
+	vulnerableTo := string(ctx.URI().QueryArgs().Peek("vulnerable_to"))
+
+	if vulnerableTo == "" {
+		domains, customErr = app.GetAllDomains()
+	} else if hostinfo.Vulnerabilities[vulnerableTo] {
+		domains, customErr = app.GetDomainsVulnerableTo(vulnerableTo)
+	} else {
+		customErr = wrappedErr.New(fasthttp.StatusBadRequest, "DomainGET", "Unknown vulnerability")
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusBadRequest)
+		fmt.Fprintln(ctx, customErr.Message.Error())
+		return
+	}
+
 	if customErr != nil {
 		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
 		return
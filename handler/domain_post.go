@@ -47,6 +47,9 @@ func (app *APP) DomainPOST(ctx *fasthttp.RequestCtx) {
 		case fasthttp.StatusNotImplemented:
 			ctx.Response.SetStatusCode(fasthttp.StatusNotImplemented)
 			fmt.Fprintln(ctx, customErr.Message)
+		case fasthttp.StatusServiceUnavailable:
+			ctx.Response.SetStatusCode(fasthttp.StatusServiceUnavailable)
+			fmt.Fprintln(ctx, customErr.Message)
 		}
 
 		return
@@ -65,6 +68,9 @@ func (app *APP) DomainPOST(ctx *fasthttp.RequestCtx) {
 			case fasthttp.StatusNotImplemented:
 				ctx.Response.SetStatusCode(fasthttp.StatusNotImplemented)
 				fmt.Fprintln(ctx, customErr.Message)
+			case fasthttp.StatusServiceUnavailable:
+				ctx.Response.SetStatusCode(fasthttp.StatusServiceUnavailable)
+				fmt.Fprintln(ctx, customErr.Message)
 			}
 
 			return
@@ -77,6 +83,25 @@ func (app *APP) DomainPOST(ctx *fasthttp.RequestCtx) {
 		}
 	}
 
+	if ctx.URI().QueryArgs().GetBool("subdomains") {
+
+		found, customErr := app.DiscoverSubdomains(domain)
+
+		switch {
+		case customErr != nil:
+			domain.HostInfo.SubdomainAnalysis = hostinfo.SubdomainDiscoveryFailed
+		case len(found) == 0:
+			domain.HostInfo.SubdomainAnalysis = hostinfo.SubdomainsNoneFound
+		case app.QueueSubdomains(domain.Name, found):
+			domain.HostInfo.SubdomainAnalysis = hostinfo.SubdomainsQueued
+		default:
+			domain.HostInfo.SubdomainAnalysis = hostinfo.SubdomainsQueueFull
+		}
+
+		domain.HostInfo.Subdomains = found
+
+	}
+
 	ctx.Response.SetStatusCode(fasthttp.StatusCreated)
 	ctx.Response.Header.SetContentType("application/json")
 
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+
+	"github.com/valyala/fasthttp"
+)
+
+// DomainIncidentsGET returns the route handler for GET /domains/:name/incidents
+func (app *APP) DomainIncidentsGET(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	ctx.Response.Header.SetBytesV("Access-Control-Allow-Origin", ctx.Request.Header.Peek("Origin"))
+
+	domainName, _ := ctx.UserValue("name").(string)
+
+	report, exists, customErr := app.GetIncidentReport(domainName)
+	if customErr != nil {
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if !exists {
+		customErr = wrappedErr.New(fasthttp.StatusNotFound, "DomainIncidentsGET", "Domain is not tracked")
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusNotFound)
+		fmt.Fprintln(ctx, customErr.Message.Error())
+		return
+	}
+
+	ctx.Response.Header.SetContentType("application/json")
+	ctx.Response.SetStatusCode(fasthttp.StatusOK)
+
+	err := json.NewEncoder(ctx).Encode(report)
+	if err != nil {
+		errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+		customErr := wrappedErr.New(fasthttp.StatusInternalServerError, "DomainIncidentsGET", errMessage)
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+
+	"github.com/valyala/fasthttp"
+)
+
+// DomainLogoGET returns the route handler for GET /domains/:name/logo
+func (app *APP) DomainLogoGET(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	ctx.Response.Header.SetBytesV("Access-Control-Allow-Origin", ctx.Request.Header.Peek("Origin"))
+
+	domainName, _ := ctx.UserValue("name").(string)
+
+	logo, customErr := app.GetLogo(domainName)
+	if customErr != nil {
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if logo == nil {
+		customErr = wrappedErr.New(fasthttp.StatusNotFound, "DomainLogoGET", "Logo not found")
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusNotFound)
+		fmt.Fprintln(ctx, customErr.Message.Error())
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s"`, logo.Hash)
+
+	ctx.Response.Header.Set("ETag", etag)
+	ctx.Response.Header.Set("Cache-Control", "public, max-age=3600")
+
+	if string(ctx.Request.Header.Peek("If-None-Match")) == etag {
+		ctx.Response.SetStatusCode(fasthttp.StatusNotModified)
+		return
+	}
+
+	ctx.Response.Header.SetContentType(logo.ContentType)
+	ctx.Response.Header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	ctx.Response.SetStatusCode(fasthttp.StatusOK)
+	ctx.Response.SetBody(logo.Data)
+
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+	hostinfo "domain-info-api/platform/hostinfo"
+
+	"github.com/valyala/fasthttp"
+)
+
+const defaultReportWindow = 7 * 24 * time.Hour
+
+// ReportGradesGET returns the route handler for GET /reports/grades
+func (app *APP) ReportGradesGET(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	ctx.Response.Header.SetBytesV("Access-Control-Allow-Origin", ctx.Request.Header.Peek("Origin"))
+
+	window, err := parseWindow(string(ctx.URI().QueryArgs().Peek("window")))
+	if err != nil {
+		customErr := wrappedErr.New(fasthttp.StatusBadRequest, "ReportGradesGET", "Invalid report window")
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusBadRequest)
+		fmt.Fprintln(ctx, customErr.Message.Error())
+		return
+	}
+
+	format := string(ctx.URI().QueryArgs().Peek("format"))
+	if format != "" && format != "json" && format != "csv" {
+		customErr := wrappedErr.New(fasthttp.StatusBadRequest, "ReportGradesGET", "Invalid report format")
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusBadRequest)
+		fmt.Fprintln(ctx, customErr.Message.Error())
+		return
+	}
+
+	report, customErr := app.GetGradeReport(time.Now().Add(-window))
+	if customErr != nil {
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.Response.SetStatusCode(fasthttp.StatusOK)
+
+	if format == "csv" {
+		ctx.Response.Header.SetContentType("text/csv")
+		err = writeGradeReportCSV(ctx, report)
+	} else {
+		ctx.Response.Header.SetContentType("application/json")
+		err = json.NewEncoder(ctx).Encode(report)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf("Report encoding failed: %s", err.Error())
+		customErr := wrappedErr.New(fasthttp.StatusInternalServerError, "ReportGradesGET", errMessage)
+		log.Println(customErr)
+		ctx.Response.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+}
+
+// parseWindow accepts either a number of days such as "30d" or any Go duration such as "24h"
+func parseWindow(value string) (time.Duration, error) {
+
+	if value == "" {
+		return defaultReportWindow, nil
+	}
+
+	var window time.Duration
+	var err error
+
+	if strings.HasSuffix(value, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(value, "d"))
+		window = time.Duration(days) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(value)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if window <= 0 {
+		return 0, fmt.Errorf("window must be positive")
+	}
+
+	return window, nil
+
+}
+
+func writeGradeReportCSV(ctx *fasthttp.RequestCtx, report *hostinfo.GradeReport) error {
+
+	writer := csv.NewWriter(ctx)
+
+	records := [][]string{
+		{"type", "domain", "ssl_grade", "previous_ssl_grade", "count", "changed_at"},
+	}
+
+	var distributionGrades []hostinfo.Grade
+
+	for grade := range report.Distribution {
+		distributionGrades = append(distributionGrades, grade)
+	}
+
+	sort.Slice(distributionGrades, func(i, j int) bool {
+		return distributionGrades[j].Less(distributionGrades[i])
+	})
+
+	for _, grade := range distributionGrades {
+		records = append(records, []string{"distribution", "", string(grade), "", strconv.Itoa(report.Distribution[grade]), ""})
+	}
+
+	records = append(records, []string{"upgrades", "", "", "", strconv.Itoa(report.Upgrades), ""})
+	records = append(records, []string{"downgrades", "", "", "", strconv.Itoa(report.Downgrades), ""})
+
+	for _, drop := range report.Dropped {
+		records = append(records, []string{"dropped", drop.Domain, string(drop.Grade), string(drop.PreviousGrade), "", drop.DroppedAt.Format(time.RFC3339)})
+	}
+
+	return writer.WriteAll(records)
+
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	handler "domain-info-api/handler"
+	"domain-info-api/platform/geoip"
 	hostinfo "domain-info-api/platform/hostinfo"
 
 	"github.com/buaazp/fasthttprouter"
@@ -37,16 +40,59 @@ func main() {
 		log.Fatal(customErr)
 	}
 
+	monitor := hostinfo.NewMonitor(host)
+	stopMonitor := make(chan struct{})
+	monitorStopped := make(chan struct{})
+
+	go func() {
+		monitor.Start(stopMonitor)
+		close(monitorStopped)
+	}()
+
+	reloadGeoIP := make(chan os.Signal, 1)
+	signal.Notify(reloadGeoIP, syscall.SIGHUP)
+
+	go func() {
+		for range reloadGeoIP {
+			if err := geoip.Reload(); err != nil {
+				log.Printf("Failed to reload the geolocation databases: %s", err.Error())
+			}
+		}
+	}()
+
 	router := fasthttprouter.New()
-	app := handler.APP{host}
+	app := handler.APP{Connection: host}
 
 	router.POST("/domains", app.DomainPOST)
 	router.GET("/domains", app.DomainGET)
+	router.GET("/domains/:name/certificates", app.DomainCertificatesGET)
+	router.GET("/domains/:name/changes", app.DomainChangesGET)
+	router.GET("/domains/:name/incidents", app.DomainIncidentsGET)
+	router.GET("/domains/:name/logo", app.DomainLogoGET)
+	router.GET("/domains/:name/subdomains", app.DomainSubdomainsGET)
+	router.GET("/reports/grades", app.ReportGradesGET)
+
+	server := &fasthttp.Server{Handler: router.Handler}
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-shutdown
+		if err := server.Shutdown(); err != nil {
+			log.Printf("Failed to shut down the server: %s", err.Error())
+		}
+	}()
 
 	fmt.Println("Listening on port 3000")
 
-	err = fasthttp.ListenAndServe(":3000", router.Handler)
+	err = server.ListenAndServe(":3000")
+
+	close(stopMonitor)
+	<-monitorStopped
+
 	if err != nil {
+		db.Close()
 		log.Fatalf("Failed to listen to port 3000: %s", err.Error())
 	}
 
@@ -0,0 +1,36 @@
+package audit
+
+// Statuses of a finding
+const (
+	Pass = "pass"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+// Finding represents the result of a single check of an audit, Check naming what was checked,
+// such as a header or a record, and Value holding what was found when it is worth showing
+type Finding struct {
+	Check   string `json:"check,omitempty"`
+	Status  string `json:"status"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// Score returns the score out of 100 of the given findings, each check contributing its weight
+// when it passes and half of it when it only raises a warning
+func Score(findings []Finding, weights map[string]int) int {
+
+	var score float64
+
+	for _, finding := range findings {
+		switch finding.Status {
+		case Pass:
+			score += float64(weights[finding.Check])
+		case Warn:
+			score += float64(weights[finding.Check]) / 2
+		}
+	}
+
+	return int(score)
+
+}
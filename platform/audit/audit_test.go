@@ -0,0 +1,21 @@
+package audit
+
+import "testing"
+
+func TestScore(t *testing.T) {
+
+	weights := map[string]int{"first": 50, "second": 30, "third": 15, "fourth": 5}
+
+	findings := []Finding{
+		{Check: "first", Status: Pass},
+		{Check: "second", Status: Warn},
+		{Check: "third", Status: Fail},
+		{Check: "fourth", Status: Warn},
+		{Check: "unweighted", Status: Pass},
+	}
+
+	if score := Score(findings, weights); score != 67 {
+		t.Errorf("got %d, want 67", score)
+	}
+
+}
@@ -0,0 +1,233 @@
+package availability
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Check represents the result of probing the availability of a domain
+type Check struct {
+	DNSResolved    bool      `json:"dns_resolved"`
+	Addresses      []string  `json:"addresses"`
+	Port80Open     bool      `json:"port_80_open"`
+	Port443Open    bool      `json:"port_443_open"`
+	URL            string    `json:"url"`
+	StatusCode     int       `json:"status_code"`
+	LatencyMS      int64     `json:"latency_ms"`
+	TLSHandshakeMS int64     `json:"tls_handshake_ms"`
+	Error          string    `json:"error,omitempty"`
+	IsDown         bool      `json:"is_down"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// Config represents how a domain is probed and what makes it count as down
+type Config struct {
+	Timeout        time.Duration
+	MaxLatency     time.Duration
+	DownStatusCode int
+	RequireHTTPS   bool
+}
+
+// Prober represents a client able to check the availability of a domain
+type Prober struct {
+	Config    Config
+	Resolver  *net.Resolver
+	HTTPPort  string
+	HTTPSPort string
+	TLSConfig *tls.Config
+}
+
+// DefaultConfig returns the configuration used when no environment variable is set:
+// a domain is down when it cannot be resolved, reached, or answers with a 5xx status
+func DefaultConfig() Config {
+
+	return Config{
+		Timeout:        10 * time.Second,
+		DownStatusCode: http.StatusInternalServerError,
+	}
+
+}
+
+// ConfigFromEnv returns the default configuration overridden by the AVAILABILITY_TIMEOUT,
+// AVAILABILITY_MAX_LATENCY, AVAILABILITY_DOWN_STATUS and AVAILABILITY_REQUIRE_HTTPS variables
+func ConfigFromEnv() Config {
+
+	config := DefaultConfig()
+
+	if timeout, err := time.ParseDuration(os.Getenv("AVAILABILITY_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+
+	if maxLatency, err := time.ParseDuration(os.Getenv("AVAILABILITY_MAX_LATENCY")); err == nil {
+		config.MaxLatency = maxLatency
+	}
+
+	if statusCode, err := strconv.Atoi(os.Getenv("AVAILABILITY_DOWN_STATUS")); err == nil {
+		config.DownStatusCode = statusCode
+	}
+
+	if requireHTTPS, err := strconv.ParseBool(os.Getenv("AVAILABILITY_REQUIRE_HTTPS")); err == nil {
+		config.RequireHTTPS = requireHTTPS
+	}
+
+	return config
+
+}
+
+// NewProber returns a Prober using the system resolver and the standard HTTP ports
+func NewProber(config Config) *Prober {
+
+	return &Prober{
+		Config:    config,
+		Resolver:  net.DefaultResolver,
+		HTTPPort:  "80",
+		HTTPSPort: "443",
+	}
+
+}
+
+// Probe checks the availability of the given domain with the configuration found in the environment
+func Probe(domain string) Check {
+	return NewProber(ConfigFromEnv()).Probe(domain)
+}
+
+// Probe resolves the domain, connects to its HTTP and HTTPS ports and performs a GET
+// request, preferring HTTPS, to decide whether the domain is down
+func (p *Prober) Probe(domain string) Check {
+
+	check := Check{CheckedAt: time.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config.Timeout)
+	defer cancel()
+
+	addresses, err := p.Resolver.LookupHost(ctx, domain)
+	if err != nil {
+		check.Error = fmt.Sprintf("DNS resolution failed: %s", err.Error())
+		check.IsDown = true
+		return check
+	}
+
+	check.DNSResolved = true
+	check.Addresses = addresses
+
+	check.Port80Open = p.canConnect(ctx, addresses[0], p.HTTPPort)
+	check.Port443Open = p.canConnect(ctx, addresses[0], p.HTTPSPort)
+
+	switch {
+	case check.Port443Open:
+		check.URL = buildURL("https", domain, p.HTTPSPort, "443")
+	case check.Port80Open:
+		check.URL = buildURL("http", domain, p.HTTPPort, "80")
+	default:
+		check.Error = "TCP connection failed on both HTTP and HTTPS ports"
+		check.IsDown = true
+		return check
+	}
+
+	err = p.get(ctx, &check)
+	if err != nil {
+		check.Error = fmt.Sprintf("HTTP request failed: %s", err.Error())
+	}
+
+	check.IsDown = p.isDown(check)
+
+	return check
+
+}
+
+func (p *Prober) canConnect(ctx context.Context, address, port string) bool {
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, port))
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+
+	return true
+
+}
+
+// get performs the GET request on the check URL recording status code, latency and TLS handshake time
+func (p *Prober) get(ctx context.Context, check *Check) error {
+
+	var handshakeStart time.Time
+
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart: func() {
+			handshakeStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			check.TLSHandshakeMS = time.Since(handshakeStart).Milliseconds()
+		},
+	}
+
+	request, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, check.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   p.TLSConfig,
+			DisableKeepAlives: true,
+		},
+	}
+
+	start := time.Now()
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1<<20))
+
+	check.LatencyMS = time.Since(start).Milliseconds()
+	check.StatusCode = response.StatusCode
+
+	return nil
+
+}
+
+// isDown applies the configured definition of "down" to a check that reached a server
+func (p *Prober) isDown(check Check) bool {
+
+	if check.StatusCode == 0 {
+		return true
+	}
+
+	if p.Config.DownStatusCode > 0 && check.StatusCode >= p.Config.DownStatusCode {
+		return true
+	}
+
+	if p.Config.MaxLatency > 0 && time.Duration(check.LatencyMS)*time.Millisecond > p.Config.MaxLatency {
+		return true
+	}
+
+	return p.Config.RequireHTTPS && !check.Port443Open
+
+}
+
+func buildURL(scheme, domain, port, defaultPort string) string {
+
+	if port == defaultPort {
+		return fmt.Sprintf("%s://%s/", scheme, domain)
+	}
+
+	return fmt.Sprintf("%s://%s/", scheme, net.JoinHostPort(domain, port))
+
+}
@@ -0,0 +1,106 @@
+package availability
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func newTestProber(t *testing.T, server *httptest.Server) *Prober {
+	t.Helper()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	closed.Close()
+
+	prober := NewProber(DefaultConfig())
+	prober.HTTPPort = closedPort
+	prober.HTTPSPort = serverURL.Port()
+	prober.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return prober
+
+}
+
+func TestProbe(t *testing.T) {
+
+	var tests = []struct {
+		name       string
+		statusCode int
+		wantDown   bool
+	}{
+		{name: "healthy site", statusCode: http.StatusOK, wantDown: false},
+		{name: "missing page", statusCode: http.StatusNotFound, wantDown: false},
+		{name: "failing site", statusCode: http.StatusServiceUnavailable, wantDown: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+			}))
+
+			defer server.Close()
+
+			check := newTestProber(t, server).Probe("127.0.0.1")
+
+			if !check.DNSResolved || !check.Port443Open || check.Port80Open {
+				t.Errorf("got %+v, want a resolved domain reachable on HTTPS only", check)
+			}
+
+			if check.StatusCode != test.statusCode {
+				t.Errorf("got status code %d, want %d", check.StatusCode, test.statusCode)
+			}
+
+			if check.IsDown != test.wantDown {
+				t.Errorf("got is_down %t, want %t", check.IsDown, test.wantDown)
+			}
+
+		})
+	}
+
+}
+
+func TestProbeUnreachable(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	prober := newTestProber(t, server)
+	server.Close()
+
+	check := prober.Probe("127.0.0.1")
+
+	if !check.IsDown || check.Error == "" {
+		t.Errorf("got %+v, want an unreachable domain to be down", check)
+	}
+
+}
+
+func TestConfigFromEnvIgnoresNonPositiveTimeout(t *testing.T) {
+
+	defer os.Unsetenv("AVAILABILITY_TIMEOUT")
+
+	for _, timeout := range []string{"0s", "-5s"} {
+
+		os.Setenv("AVAILABILITY_TIMEOUT", timeout)
+
+		if config := ConfigFromEnv(); config.Timeout != DefaultConfig().Timeout {
+			t.Errorf("got timeout %s for %s, want the default", config.Timeout, timeout)
+		}
+
+	}
+
+}
@@ -0,0 +1,194 @@
+package caa
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/dnsrecords"
+)
+
+// authorities maps a name found in the subject of an issuing certificate to the CAA issuer
+// domains the authority behind it recognizes
+var authorities = []struct {
+	name    string
+	domains []string
+}{
+	{"Let's Encrypt", []string{"letsencrypt.org"}},
+	{"DigiCert", []string{"digicert.com", "symantec.com", "geotrust.com", "rapidssl.com", "thawte.com", "digitalcertvalidation.com"}},
+	{"Cloudflare", []string{"digicert.com"}},
+	{"Sectigo", []string{"sectigo.com", "comodoca.com", "comodo.com", "usertrust.com", "trust-provider.com"}},
+	{"COMODO", []string{"sectigo.com", "comodoca.com", "comodo.com", "usertrust.com", "trust-provider.com"}},
+	{"ZeroSSL", []string{"sectigo.com", "zerossl.com"}},
+	{"GlobalSign", []string{"globalsign.com"}},
+	{"Google Trust Services", []string{"pki.goog", "google.com"}},
+	{"Amazon", []string{"amazon.com", "amazontrust.com", "awstrust.com", "amazonaws.com"}},
+	{"GoDaddy", []string{"godaddy.com", "starfieldtech.com"}},
+	{"Starfield", []string{"godaddy.com", "starfieldtech.com"}},
+	{"Entrust", []string{"entrust.net", "affirmtrust.com"}},
+	{"Microsoft", []string{"microsoft.com"}},
+	{"Buypass", []string{"buypass.com", "buypass.no"}},
+	{"SSL.com", []string{"ssl.com"}},
+	{"Certum", []string{"certum.pl", "certum.eu"}},
+	{"Actalis", []string{"actalis.it"}},
+	{"IdenTrust", []string{"identrust.com"}},
+	{"HARICA", []string{"harica.gr"}},
+}
+
+// Certificate represents the certificate a server presents, as far as the check is concerned
+type Certificate struct {
+	Server string
+	Issuer string
+	Names  []string
+}
+
+// Report represents the CAA policy of a domain and whether the certificates served comply with it
+type Report struct {
+	Policy   Policy    `json:"policy"`
+	Findings []Finding `json:"findings"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Finding represents the result of the check for the whole policy or for a single server
+type Finding struct {
+	Server string `json:"server,omitempty"`
+	Issuer string `json:"issuer,omitempty"`
+	audit.Finding
+}
+
+// Check looks up the CAA policy of the given domain with the resolver found in the environment
+// and checks the given certificates against it
+func Check(domain string, certificates []Certificate) Report {
+	return CheckWith(dnsrecords.NewResolver(dnsrecords.ConfigFromEnv()), domain, certificates)
+}
+
+// CheckWith looks up the CAA policy of the given domain with the given resolver and reports,
+// for each certificate, whether its issuer is authorized. A domain without any CAA record is
+// reported as a warning since any authority may then issue for it
+func CheckWith(resolver Resolver, domain string, certificates []Certificate) Report {
+
+	report := Report{Findings: []Finding{}}
+
+	policy, err := lookupPolicy(resolver, domain)
+	report.Policy = policy
+
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	if len(policy.Records) == 0 {
+		report.Findings = append(report.Findings, Finding{Finding: audit.Finding{Status: audit.Warn, Message: "No CAA record found, any certificate authority may issue certificates for the domain"}})
+	}
+
+	if tags := policy.unknownCritical(); len(tags) > 0 {
+		report.Findings = append(report.Findings, Finding{Finding: audit.Finding{Status: audit.Warn, Message: fmt.Sprintf("Critical CAA properties %s are unknown to most authorities, which then refuse to issue", strings.Join(tags, ", "))}})
+	}
+
+	for _, certificate := range certificates {
+		if certificate.Issuer != "" {
+			report.Findings = append(report.Findings, policy.check(certificate))
+		}
+	}
+
+	return report
+
+}
+
+// check returns the finding of a single certificate against the policy
+func (p Policy) check(certificate Certificate) Finding {
+
+	finding := Finding{Server: certificate.Server, Issuer: certificate.Issuer}
+
+	authorized, restricted := p.authorizedIssuers(isWildcard(certificate.Names))
+
+	if !restricted {
+		finding.Status = audit.Pass
+		finding.Message = "The CAA policy does not restrict which authority may issue the certificate"
+		return finding
+	}
+
+	issuerDomains := issuerDomains(certificate.Issuer)
+
+	if len(issuerDomains) == 0 {
+		finding.Status = audit.Warn
+		finding.Message = fmt.Sprintf("The issuer is not a known certificate authority, it cannot be matched against %s", strings.Join(sorted(authorized), ", "))
+		return finding
+	}
+
+	for _, domain := range issuerDomains {
+		for _, allowed := range authorized {
+			if domain == allowed {
+				finding.Status = audit.Pass
+				finding.Message = fmt.Sprintf("The CAA policy of %s authorizes %s", p.Domain, allowed)
+				return finding
+			}
+		}
+	}
+
+	finding.Status = audit.Fail
+
+	if len(authorized) == 0 {
+		finding.Message = fmt.Sprintf("The CAA policy of %s forbids any issuance", p.Domain)
+	} else {
+		finding.Message = fmt.Sprintf("The CAA policy of %s only authorizes %s", p.Domain, strings.Join(sorted(authorized), ", "))
+	}
+
+	return finding
+
+}
+
+// Authorizes reports whether the policy explicitly names the authority behind the issuer among
+// the ones allowed to issue certificates
+func (p Policy) Authorizes(issuer string) bool {
+
+	authorized, _ := p.authorizedIssuers(false)
+
+	for _, domain := range issuerDomains(issuer) {
+		for _, allowed := range authorized {
+			if domain == allowed {
+				return true
+			}
+		}
+	}
+
+	return false
+
+}
+
+// issuerDomains returns the CAA issuer domains of the authority named in an issuer subject
+func issuerDomains(issuer string) []string {
+
+	lower := strings.ToLower(issuer)
+
+	for _, authority := range authorities {
+		if strings.Contains(lower, strings.ToLower(authority.name)) {
+			return authority.domains
+		}
+	}
+
+	return nil
+
+}
+
+func isWildcard(names []string) bool {
+
+	for _, name := range names {
+		if strings.HasPrefix(name, "*.") {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func sorted(values []string) []string {
+
+	copied := append([]string{}, values...)
+	sort.Strings(copied)
+
+	return copied
+
+}
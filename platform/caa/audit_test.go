@@ -0,0 +1,76 @@
+package caa
+
+import (
+	"strings"
+	"testing"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/dnsrecords"
+	"domain-info-api/platform/dnsrecords/dnstest"
+)
+
+const (
+	letsEncrypt = "CN=R3, O=Let's Encrypt, C=US"
+	digiCert    = "CN=DigiCert TLS RSA SHA256 2020 CA1, O=DigiCert Inc, C=US"
+)
+
+func TestCheckWith(t *testing.T) {
+
+	resolver := dnstest.Resolver{dnsrecords.TypeCAA: {
+		"example.com":         {`0 issue "letsencrypt.org"`, `0 issuewild ";"`, `0 iodef "mailto:security@example.com"`},
+		"shop.example.org":    {`0 issue "digicert.com; cansignhttpexchanges=yes"`},
+		"example.net":         {`0 iodef "mailto:security@example.net"`},
+		"critical.example.io": {`0 issue "letsencrypt.org"`, `128 tbs "unknown"`},
+	}}
+
+	tests := []struct {
+		name         string
+		domain       string
+		certificate  Certificate
+		wantPolicy   string
+		wantStatuses []string
+	}{
+		{"authorized issuer", "www.example.com", Certificate{Server: "192.0.2.1", Issuer: letsEncrypt, Names: []string{"www.example.com"}}, "example.com", []string{audit.Pass}},
+		{"unauthorized issuer", "example.com", Certificate{Server: "192.0.2.1", Issuer: digiCert, Names: []string{"example.com"}}, "example.com", []string{audit.Fail}},
+		{"wildcard forbidden", "example.com", Certificate{Server: "192.0.2.1", Issuer: letsEncrypt, Names: []string{"*.example.com"}}, "example.com", []string{audit.Fail}},
+		{"parameters ignored", "shop.example.org", Certificate{Server: "192.0.2.1", Issuer: digiCert}, "shop.example.org", []string{audit.Pass}},
+		{"unknown authority", "example.com", Certificate{Server: "192.0.2.1", Issuer: "CN=Internal CA, O=Example"}, "example.com", []string{audit.Warn}},
+		{"no issue property", "example.net", Certificate{Server: "192.0.2.1", Issuer: digiCert}, "example.net", []string{audit.Pass}},
+		{"no policy", "example.edu", Certificate{Server: "192.0.2.1", Issuer: digiCert}, "", []string{audit.Warn, audit.Pass}},
+		{"unknown critical property", "critical.example.io", Certificate{Server: "192.0.2.1", Issuer: letsEncrypt}, "critical.example.io", []string{audit.Warn, audit.Pass}},
+		{"no certificate data", "example.com", Certificate{Server: "192.0.2.1"}, "example.com", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			report := CheckWith(resolver, tt.domain, []Certificate{tt.certificate})
+
+			if report.Policy.Domain != tt.wantPolicy {
+				t.Errorf("got policy of %q, want %q", report.Policy.Domain, tt.wantPolicy)
+			}
+
+			var statuses []string
+
+			for _, finding := range report.Findings {
+				statuses = append(statuses, finding.Status)
+			}
+
+			if strings.Join(statuses, ",") != strings.Join(tt.wantStatuses, ",") {
+				t.Errorf("got %v (%+v), want %v", statuses, report.Findings, tt.wantStatuses)
+			}
+
+		})
+	}
+
+}
+
+func TestCheckWithFailingResolver(t *testing.T) {
+
+	report := CheckWith(dnstest.Resolver{dnsrecords.TypeCAA: {"com": nil}}, "example.com", []Certificate{{Server: "192.0.2.1", Issuer: letsEncrypt}})
+
+	if report.Error == "" || len(report.Findings) != 0 {
+		t.Errorf("got %+v, want an error and no finding", report)
+	}
+
+}
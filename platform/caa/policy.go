@@ -0,0 +1,153 @@
+package caa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// criticalFlag marks a property a certificate authority must understand before issuing
+const criticalFlag = 128
+
+// Record represents a single CAA record
+type Record struct {
+	Flags int    `json:"flags"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// Policy represents the CAA records that apply to a domain and the name they were found at,
+// which is the domain itself or its closest ancestor publishing any
+type Policy struct {
+	Domain  string   `json:"domain"`
+	Records []Record `json:"records"`
+}
+
+// Resolver represents what the check needs to query DNS records
+type Resolver interface {
+	Query(name string, recordType dnsmessage.Type) ([]dnsrecords.Record, error)
+}
+
+// lookupPolicy walks up from the domain to its top level domain and returns the first set of
+// CAA records found, or an empty policy when none of the names publishes any
+func lookupPolicy(resolver Resolver, domain string) (Policy, error) {
+
+	labels := strings.Split(strings.Trim(strings.ToLower(domain), "."), ".")
+
+	for i := range labels {
+
+		name := strings.Join(labels[i:], ".")
+
+		records, err := resolver.Query(name, dnsrecords.TypeCAA)
+		if err != nil {
+			return Policy{Records: []Record{}}, fmt.Errorf("querying CAA records of %s: %v", name, err)
+		}
+
+		policy := Policy{Domain: name, Records: []Record{}}
+
+		for _, record := range records {
+			if parsed, ok := parseRecord(record.Value); ok {
+				policy.Records = append(policy.Records, parsed)
+			}
+		}
+
+		if len(policy.Records) > 0 {
+			return policy, nil
+		}
+
+	}
+
+	return Policy{Records: []Record{}}, nil
+
+}
+
+// parseRecord parses a CAA record in presentation format, such as 0 issue "letsencrypt.org"
+func parseRecord(value string) (Record, bool) {
+
+	fields := strings.SplitN(value, " ", 3)
+	if len(fields) != 3 {
+		return Record{}, false
+	}
+
+	flags, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Record{}, false
+	}
+
+	propertyValue, err := strconv.Unquote(fields[2])
+	if err != nil {
+		propertyValue = strings.Trim(fields[2], `"`)
+	}
+
+	return Record{Flags: flags, Tag: strings.ToLower(fields[1]), Value: propertyValue}, true
+
+}
+
+// issuers returns the issuer domains the given tag authorizes. An empty value, such as in
+// 0 issue ";", authorizes no one and adds nothing
+func (p Policy) issuers(tag string) ([]string, bool) {
+
+	var domains []string
+
+	found := false
+
+	for _, record := range p.Records {
+
+		if record.Tag != tag {
+			continue
+		}
+
+		found = true
+
+		issuer := strings.ToLower(strings.TrimSpace(strings.SplitN(record.Value, ";", 2)[0]))
+
+		if issuer != "" {
+			domains = append(domains, issuer)
+		}
+
+	}
+
+	return domains, found
+
+}
+
+// authorizedIssuers returns the issuer domains allowed to issue the certificate and whether the
+// policy restricts issuance at all. Wildcard certificates follow issuewild when it is present
+func (p Policy) authorizedIssuers(wildcard bool) ([]string, bool) {
+
+	if wildcard {
+		if domains, found := p.issuers("issuewild"); found {
+			return domains, true
+		}
+	}
+
+	return p.issuers("issue")
+
+}
+
+// unknownCritical returns the tags marked critical that the check does not understand, which
+// forbid any issuance by a compliant authority
+func (p Policy) unknownCritical() []string {
+
+	var tags []string
+
+	for _, record := range p.Records {
+
+		switch record.Tag {
+		case "issue", "issuewild", "iodef", "issuemail", "issuevmc":
+			continue
+		}
+
+		if record.Flags&criticalFlag != 0 {
+			tags = append(tags, record.Tag)
+		}
+
+	}
+
+	return tags
+
+}
@@ -0,0 +1,145 @@
+package ctlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// crtShTimeLayout is how crt.sh writes times, in UTC and with optional fractional seconds
+const crtShTimeLayout = "2006-01-02T15:04:05.999999999"
+
+// maxAnswerSize bounds how much of an answer is read, domains issuing many certificates
+// having answers of several megabytes even without the expired ones. The certificates read
+// before the limit, or before maxEntries of them, are kept
+const maxAnswerSize = 16 << 20
+
+// CrtSh represents a source querying the JSON output of crt.sh or of a server answering like it
+type CrtSh struct {
+	URL    string
+	Client *http.Client
+}
+
+// crtShEntry represents a certificate as listed by crt.sh
+type crtShEntry struct {
+	ID             int64  `json:"id"`
+	IssuerName     string `json:"issuer_name"`
+	CommonName     string `json:"common_name"`
+	NameValue      string `json:"name_value"`
+	SerialNumber   string `json:"serial_number"`
+	NotBefore      string `json:"not_before"`
+	NotAfter       string `json:"not_after"`
+	EntryTimestamp string `json:"entry_timestamp"`
+}
+
+// Certificates returns the certificates logged for the domain and for any name under it that
+// have not expired, up to maxEntries of them and newest first
+func (s *CrtSh) Certificates(domain string) ([]Entry, error) {
+
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+
+	var entries []Entry
+
+	for _, query := range []string{domain, "%." + domain} {
+
+		found, err := s.query(query)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, found...)
+
+	}
+
+	return current(dedupe(entries), time.Now()), nil
+
+}
+
+func (s *CrtSh) query(identity string) ([]Entry, error) {
+
+	response, err := s.Client.Get(fmt.Sprintf("%s/?q=%s&output=json&exclude=expired", s.URL, url.QueryEscape(identity)))
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("certificate log answered with status %d", response.StatusCode)
+	}
+
+	body := &io.LimitedReader{R: response.Body, N: maxAnswerSize}
+	decoder := json.NewDecoder(body)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate log answer: %v", err)
+	}
+
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("invalid certificate log answer, not a list")
+	}
+
+	var entries []Entry
+
+	for len(entries) < maxEntries && decoder.More() {
+
+		var certificate crtShEntry
+
+		if err := decoder.Decode(&certificate); err != nil {
+
+			// An answer cut by the size limit keeps the certificates read until then
+			if body.N == 0 {
+				break
+			}
+
+			return nil, fmt.Errorf("invalid certificate log answer: %v", err)
+
+		}
+
+		entries = append(entries, Entry{
+			LogID:        certificate.ID,
+			SerialNumber: certificate.SerialNumber,
+			Issuer:       certificate.IssuerName,
+			CommonName:   certificate.CommonName,
+			Names:        splitNames(certificate.NameValue),
+			NotBefore:    parseTime(certificate.NotBefore),
+			NotAfter:     parseTime(certificate.NotAfter),
+			LoggedAt:     parseTime(certificate.EntryTimestamp),
+		})
+
+	}
+
+	return entries, nil
+
+}
+
+// splitNames returns the names of a certificate, which crt.sh separates with new lines
+func splitNames(value string) []string {
+
+	names := []string{}
+
+	for _, name := range strings.Split(value, "\n") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+
+}
+
+func parseTime(value string) time.Time {
+
+	parsed, err := time.Parse(crtShTimeLayout, value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+
+}
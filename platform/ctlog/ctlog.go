@@ -0,0 +1,191 @@
+package ctlog
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultURL is the crt.sh compatible aggregator queried when CT_LOG_URL is not set
+const defaultURL = "https://crt.sh"
+
+// maxEntries is how many certificates are kept for a domain, the most recently logged ones
+const maxEntries = 500
+
+// Entry represents a certificate found in the Certificate Transparency logs
+type Entry struct {
+	LogID        int64     `json:"log_id"`
+	SerialNumber string    `json:"serial_number"`
+	Issuer       string    `json:"issuer"`
+	CommonName   string    `json:"common_name"`
+	Names        []string  `json:"names"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+	LoggedAt     time.Time `json:"logged_at"`
+}
+
+// Source represents where the certificates logged for a domain come from
+type Source interface {
+	Certificates(domain string) ([]Entry, error)
+}
+
+// Config represents which aggregator answers the queries and how long to wait for it
+type Config struct {
+	URL     string
+	Timeout time.Duration
+}
+
+// DefaultConfig returns the configuration used when no environment variable is set
+func DefaultConfig() Config {
+
+	return Config{
+		URL:     defaultURL,
+		Timeout: 30 * time.Second,
+	}
+
+}
+
+// ConfigFromEnv returns the default configuration overridden by the CT_LOG_URL and
+// CT_LOG_TIMEOUT variables. CT_LOG_URL may point to any server answering like crt.sh,
+// such as a local stand-in serving fixtures
+func ConfigFromEnv() Config {
+
+	config := DefaultConfig()
+
+	if url := os.Getenv("CT_LOG_URL"); url != "" {
+		config.URL = strings.TrimSuffix(url, "/")
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("CT_LOG_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+
+	return config
+
+}
+
+// SourceFromEnv returns the source configured through the environment
+func SourceFromEnv() Source {
+
+	config := ConfigFromEnv()
+
+	return &CrtSh{
+		URL:    config.URL,
+		Client: &http.Client{Timeout: config.Timeout},
+	}
+
+}
+
+// Fetch returns the certificates logged for the given domain and its subdomains from the
+// source configured through the environment
+func Fetch(domain string) ([]Entry, error) {
+	return SourceFromEnv().Certificates(domain)
+}
+
+// Organization returns the organization named in an issuer distinguished name, such as
+// Let's Encrypt in "C=US, O=Let's Encrypt, CN=R3", or the whole name when it has none
+func Organization(issuer string) string {
+
+	for _, attribute := range splitDN(issuer) {
+
+		parts := strings.SplitN(attribute, "=", 2)
+
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "O") {
+			return strings.Trim(strings.TrimSpace(parts[1]), `"`)
+		}
+
+	}
+
+	return strings.TrimSpace(issuer)
+
+}
+
+// splitDN splits a distinguished name on the commas that are not quoted or escaped
+func splitDN(name string) []string {
+
+	var attributes []string
+	var current strings.Builder
+
+	quoted, escaped := false, false
+
+	for _, r := range name {
+
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			attributes = append(attributes, current.String())
+			current.Reset()
+			continue
+		}
+
+		current.WriteRune(r)
+
+	}
+
+	return append(attributes, current.String())
+
+}
+
+// dedupe keeps a single entry per issuer and serial number, since a precertificate and the
+// final certificate are logged as two entries, and sorts them newest first
+func dedupe(entries []Entry) []Entry {
+
+	seen := make(map[string]bool)
+
+	var unique []Entry
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LogID < entries[j].LogID
+	})
+
+	for _, entry := range entries {
+
+		key := entry.Issuer + " " + strings.ToLower(entry.SerialNumber)
+
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		unique = append(unique, entry)
+
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].LoggedAt.After(unique[j].LoggedAt)
+	})
+
+	return unique
+
+}
+
+// current drops the entries that expired before now, which an aggregator ignoring the request
+// to exclude them still returns, and keeps the first maxEntries of the rest
+func current(entries []Entry, now time.Time) []Entry {
+
+	var valid []Entry
+
+	for _, entry := range entries {
+
+		if !entry.NotAfter.IsZero() && entry.NotAfter.Before(now) {
+			continue
+		}
+
+		valid = append(valid, entry)
+
+		if len(valid) == maxEntries {
+			break
+		}
+
+	}
+
+	return valid
+
+}
@@ -0,0 +1,170 @@
+package ctlog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtures holds the answers of the stand-in log by query
+var fixtures = map[string]string{
+	"example.com": `[
+		{"id": 100, "issuer_name": "C=US, O=Let's Encrypt, CN=R3", "common_name": "example.com", "name_value": "example.com\nwww.example.com", "serial_number": "03a1", "not_before": "2023-01-01T00:00:00", "not_after": "2099-04-01T00:00:00", "entry_timestamp": "2023-01-01T01:02:03.456"}
+	]`,
+	"%.example.com": `[
+		{"id": 101, "issuer_name": "C=US, O=Let's Encrypt, CN=R3", "common_name": "example.com", "name_value": "example.com\nwww.example.com", "serial_number": "03A1", "not_before": "2023-01-01T00:00:00", "not_after": "2099-04-01T00:00:00", "entry_timestamp": "2023-01-01T01:02:04"},
+		{"id": 200, "issuer_name": "C=US, O=\"DigiCert, Inc.\", CN=DigiCert TLS RSA SHA256 2020 CA1", "common_name": "api.example.com", "name_value": "API.example.com", "serial_number": "0b2f", "not_before": "2023-02-01T00:00:00", "not_after": "2099-02-01T00:00:00", "entry_timestamp": "2023-02-01T10:00:00"},
+		{"id": 150, "issuer_name": "C=US, O=Let's Encrypt, CN=R3", "common_name": "old.example.com", "name_value": "old.example.com", "serial_number": "01c4", "not_before": "2020-01-01T00:00:00", "not_after": "2020-04-01T00:00:00", "entry_timestamp": "2020-01-01T00:00:00"}
+	]`,
+}
+
+func standIn(t *testing.T) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		fixture, exists := fixtures[r.URL.Query().Get("q")]
+		if !exists || r.URL.Query().Get("output") != "json" {
+			http.Error(w, "unknown query", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, fixture)
+
+	}))
+
+}
+
+func TestFetch(t *testing.T) {
+
+	server := standIn(t)
+	defer server.Close()
+
+	os.Setenv("CT_LOG_URL", server.URL+"/")
+	defer os.Unsetenv("CT_LOG_URL")
+
+	entries, err := Fetch("Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2 once the duplicated precertificate and the expired certificate are dropped", len(entries))
+	}
+
+	if entries[0].LogID != 200 || entries[0].Names[0] != "api.example.com" || !entries[0].LoggedAt.Equal(time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v, want the DigiCert certificate first", entries[0])
+	}
+
+	if entries[1].LogID != 100 || len(entries[1].Names) != 2 {
+		t.Errorf("got %+v, want the first logged Let's Encrypt entry", entries[1])
+	}
+
+}
+
+func TestCurrentCapsEntries(t *testing.T) {
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var entries []Entry
+
+	for i := 0; i < maxEntries+10; i++ {
+		entries = append(entries, Entry{LogID: int64(i), NotAfter: now.AddDate(0, 0, 1)})
+	}
+
+	entries[0].NotAfter = now.AddDate(0, 0, -1)
+
+	kept := current(entries, now)
+
+	if len(kept) != maxEntries || kept[0].LogID != 1 {
+		t.Errorf("got %d entries starting with %d, want %d starting with 1", len(kept), kept[0].LogID, maxEntries)
+	}
+
+}
+
+func TestCertificatesTruncatesOversizedAnswers(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		count   int
+		padding int
+		want    func(int) bool
+	}{
+		{"more entries than kept", maxEntries + 50, 0, func(n int) bool { return n == maxEntries }},
+		{"more bytes than read", 200, 100 << 10, func(n int) bool { return n > 0 && n < 200 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+				padding := strings.Repeat("a", tt.padding)
+
+				fmt.Fprint(w, "[")
+
+				for i := 0; i < tt.count; i++ {
+
+					if i > 0 {
+						fmt.Fprint(w, ",")
+					}
+
+					fmt.Fprintf(w, `{"id": %d, "issuer_name": "CN=R3", "common_name": "example.com", "name_value": "%s.example.com", "serial_number": "%x", "not_after": "2099-01-01T00:00:00"}`, i, padding, i)
+
+				}
+
+				fmt.Fprint(w, "]")
+
+			}))
+			defer server.Close()
+
+			entries, err := (&CrtSh{URL: server.URL, Client: server.Client()}).query("example.com")
+			if err != nil {
+				t.Fatalf("got %v, want the answer truncated rather than an error", err)
+			}
+
+			if !tt.want(len(entries)) {
+				t.Errorf("got %d entries out of %d", len(entries), tt.count)
+			}
+
+		})
+	}
+
+}
+
+func TestFetchWithFailingLog(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	source := &CrtSh{URL: server.URL, Client: server.Client()}
+
+	if _, err := source.Certificates("example.com"); err == nil {
+		t.Error("got no error, want one")
+	}
+
+}
+
+func TestOrganization(t *testing.T) {
+
+	tests := []struct {
+		issuer string
+		want   string
+	}{
+		{"C=US, O=Let's Encrypt, CN=R3", "Let's Encrypt"},
+		{"CN=DigiCert TLS RSA SHA256 2020 CA1, O=\"DigiCert, Inc.\", C=US", "DigiCert, Inc."},
+		{"CN=Internal CA", "CN=Internal CA"},
+	}
+
+	for _, tt := range tests {
+		if got := Organization(tt.issuer); got != tt.want {
+			t.Errorf("got %q for %q, want %q", got, tt.issuer, tt.want)
+		}
+	}
+
+}
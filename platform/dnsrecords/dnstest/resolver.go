@@ -0,0 +1,49 @@
+package dnstest
+
+import (
+	"errors"
+	"strings"
+
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Resolver answers queries from fixed values in presentation format, by record type and name.
+// A name without values falls back to the wildcard entry of its parent, such as "*.example.com",
+// and a name mapped to nil fails as if the query had timed out
+type Resolver map[dnsmessage.Type]map[string][]string
+
+// Query returns the records of the given type held for the given name
+func (r Resolver) Query(name string, recordType dnsmessage.Type) ([]dnsrecords.Record, error) {
+
+	name = strings.TrimSuffix(name, ".")
+
+	values, exists := r[recordType][name]
+	if !exists && strings.Contains(name, ".") {
+		values, exists = r[recordType]["*"+name[strings.Index(name, "."):]]
+	}
+
+	if exists && values == nil {
+		return nil, errors.New("i/o timeout")
+	}
+
+	records := []dnsrecords.Record{}
+
+	for _, value := range values {
+		records = append(records, dnsrecords.Record{Type: typeName(recordType), Name: name + ".", Value: value})
+	}
+
+	return records, nil
+
+}
+
+func typeName(recordType dnsmessage.Type) string {
+
+	if recordType == dnsrecords.TypeCAA {
+		return dnsrecords.CAA
+	}
+
+	return strings.TrimPrefix(recordType.String(), "Type")
+
+}
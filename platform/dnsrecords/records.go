@@ -0,0 +1,214 @@
+package dnsrecords
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TypeCAA is the record type of Certification Authority Authorization records, which the
+// dnsmessage package does not name
+const TypeCAA = dnsmessage.Type(257)
+
+// Names of the record types of an inventory
+const (
+	A     = "A"
+	AAAA  = "AAAA"
+	CNAME = "CNAME"
+	MX    = "MX"
+	NS    = "NS"
+	TXT   = "TXT"
+	CAA   = "CAA"
+	SOA   = "SOA"
+	SRV   = "SRV"
+)
+
+// Types holds the record types queried on the domain itself, SRV records being queried
+// on the names of well known services instead
+var Types = map[string]dnsmessage.Type{
+	A:     dnsmessage.TypeA,
+	AAAA:  dnsmessage.TypeAAAA,
+	CNAME: dnsmessage.TypeCNAME,
+	MX:    dnsmessage.TypeMX,
+	NS:    dnsmessage.TypeNS,
+	TXT:   dnsmessage.TypeTXT,
+	CAA:   TypeCAA,
+	SOA:   dnsmessage.TypeSOA,
+}
+
+// srvServices holds the service labels SRV records are looked up under
+var srvServices = []string{
+	"_sip._tcp",
+	"_sip._udp",
+	"_sips._tcp",
+	"_xmpp-client._tcp",
+	"_xmpp-server._tcp",
+	"_caldavs._tcp",
+	"_carddavs._tcp",
+	"_imaps._tcp",
+	"_submission._tcp",
+	"_autodiscover._tcp",
+}
+
+// Record represents a single DNS record, its data written in presentation format
+type Record struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	TTL   uint32 `json:"ttl"`
+	Value string `json:"value"`
+}
+
+// Inventory represents every record found for a domain. Errors holds why a record type
+// could not be queried, by type
+type Inventory struct {
+	Resolver  string            `json:"resolver"`
+	Records   []Record          `json:"records"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// Lookup returns the inventory of the given domain with the configuration found in the environment
+func Lookup(domain string) Inventory {
+	return NewResolver(ConfigFromEnv()).Lookup(domain)
+}
+
+// Lookup queries every record type of the inventory concurrently
+func (r *Resolver) Lookup(domain string) Inventory {
+
+	inventory := Inventory{
+		Resolver:  r.Config.Server,
+		Records:   []Record{},
+		CheckedAt: time.Now(),
+	}
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+
+	query := func(typeName, name string, recordType dnsmessage.Type) {
+
+		defer wait.Done()
+
+		records, err := r.Query(name, recordType)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if err != nil {
+			if inventory.Errors == nil {
+				inventory.Errors = make(map[string]string)
+			}
+			inventory.Errors[typeName] = err.Error()
+			return
+		}
+
+		inventory.Records = append(inventory.Records, records...)
+
+	}
+
+	for typeName, recordType := range Types {
+		wait.Add(1)
+		go query(typeName, domain, recordType)
+	}
+
+	for _, service := range srvServices {
+		wait.Add(1)
+		go query(SRV, service+"."+domain, dnsmessage.TypeSRV)
+	}
+
+	wait.Wait()
+
+	SortRecords(inventory.Records)
+
+	return inventory
+
+}
+
+// SortRecords orders records by type, name and value
+func SortRecords(records []Record) {
+
+	sort.Slice(records, func(i, j int) bool {
+
+		if records[i].Type != records[j].Type {
+			return records[i].Type < records[j].Type
+		}
+
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+
+		return records[i].Value < records[j].Value
+
+	})
+
+}
+
+// newRecord returns the given resource in presentation format
+func newRecord(resource dnsmessage.Resource) Record {
+
+	record := Record{
+		Type: typeName(resource.Header.Type),
+		Name: strings.ToLower(resource.Header.Name.String()),
+		TTL:  resource.Header.TTL,
+	}
+
+	switch body := resource.Body.(type) {
+	case *dnsmessage.AResource:
+		record.Value = net.IP(body.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		record.Value = net.IP(body.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		record.Value = strings.ToLower(body.CNAME.String())
+	case *dnsmessage.MXResource:
+		record.Value = fmt.Sprintf("%d %s", body.Pref, strings.ToLower(body.MX.String()))
+	case *dnsmessage.NSResource:
+		record.Value = strings.ToLower(body.NS.String())
+	case *dnsmessage.PTRResource:
+		record.Value = strings.ToLower(body.PTR.String())
+	case *dnsmessage.TXTResource:
+		record.Value = strings.Join(body.TXT, "")
+	case *dnsmessage.SOAResource:
+		record.Value = fmt.Sprintf("%s %s %d %d %d %d %d", strings.ToLower(body.NS.String()), strings.ToLower(body.MBox.String()),
+			body.Serial, body.Refresh, body.Retry, body.Expire, body.MinTTL)
+	case *dnsmessage.SRVResource:
+		record.Value = fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, strings.ToLower(body.Target.String()))
+	case *dnsmessage.UnknownResource:
+		record.Value = formatUnknown(resource.Header.Type, body.Data)
+	}
+
+	return record
+
+}
+
+// formatUnknown writes the data of a type dnsmessage does not parse, CAA records being
+// written as `flags tag "value"`
+func formatUnknown(recordType dnsmessage.Type, data []byte) string {
+
+	if recordType == TypeCAA && len(data) >= 2 && len(data) >= 2+int(data[1]) {
+		tag := string(data[2 : 2+int(data[1])])
+		return fmt.Sprintf("%d %s %q", data[0], strings.ToLower(tag), string(data[2+int(data[1]):]))
+	}
+
+	return fmt.Sprintf("\\# %d %x", len(data), data)
+
+}
+
+func typeName(recordType dnsmessage.Type) string {
+
+	for name, known := range Types {
+		if known == recordType {
+			return name
+		}
+	}
+
+	if recordType == dnsmessage.TypeSRV {
+		return SRV
+	}
+
+	return strings.TrimPrefix(recordType.String(), "Type")
+
+}
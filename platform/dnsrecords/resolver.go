@@ -0,0 +1,308 @@
+package dnsrecords
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fallbackServer is used when neither DNS_RESOLVER nor /etc/resolv.conf name a server
+const fallbackServer = "8.8.8.8:53"
+
+// maxUDPSize is the payload size advertised through EDNS0 so that large TXT sets fit in UDP
+const maxUDPSize = 4096
+
+// Config represents which server answers the queries and how long to wait for it
+type Config struct {
+	Server  string
+	Timeout time.Duration
+}
+
+// Resolver represents a client sending queries straight to a single DNS server
+type Resolver struct {
+	Config Config
+}
+
+// QueryOptions represents the DNSSEC flags of a query. DNSSECOK asks for the DNSSEC records
+// and CheckingDisabled asks a validating server to answer even when validation fails
+type QueryOptions struct {
+	DNSSECOK         bool
+	CheckingDisabled bool
+}
+
+// DefaultConfig returns the configuration used when no environment variable is set, which
+// queries the first nameserver of /etc/resolv.conf
+func DefaultConfig() Config {
+
+	return Config{
+		Server:  systemServer("/etc/resolv.conf"),
+		Timeout: 5 * time.Second,
+	}
+
+}
+
+// ConfigFromEnv returns the default configuration overridden by the DNS_RESOLVER and
+// DNS_TIMEOUT variables. DNS_RESOLVER takes an address, the port defaulting to 53
+func ConfigFromEnv() Config {
+
+	config := DefaultConfig()
+
+	if server := os.Getenv("DNS_RESOLVER"); server != "" {
+		config.Server = withPort(server)
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("DNS_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+
+	return config
+
+}
+
+// NewResolver returns a Resolver with the given configuration
+func NewResolver(config Config) *Resolver {
+	return &Resolver{Config: config}
+}
+
+// Query returns the records of the given type found for a name. A name that does not
+// exist has no records rather than an error
+func (r *Resolver) Query(name string, recordType dnsmessage.Type) ([]Record, error) {
+
+	response, err := r.Exchange(name, recordType, QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+
+	for _, answer := range response.Answers {
+		if answer.Header.Type == recordType {
+			records = append(records, newRecord(answer))
+		}
+	}
+
+	return records, nil
+
+}
+
+// Exchange sends a single query over UDP, retrying over TCP when the answer is truncated,
+// and returns the whole response
+func (r *Resolver) Exchange(name string, recordType dnsmessage.Type, options QueryOptions) (*dnsmessage.Message, error) {
+
+	query, id, err := buildQuery(name, recordType, options)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.exchangeUDP(query, id)
+	if err == nil && response.Header.Truncated {
+		response, err = r.exchangeTCP(query, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.Header.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+		return response, nil
+	}
+
+	return nil, fmt.Errorf("server answered %s", strings.TrimPrefix(response.Header.RCode.String(), "RCode"))
+
+}
+
+func (r *Resolver) exchangeUDP(query []byte, id uint16) (*dnsmessage.Message, error) {
+
+	conn, err := net.DialTimeout("udp", r.Config.Server, r.Config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(r.Config.Timeout))
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, maxUDPSize)
+
+	for {
+
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := parseResponse(buffer[:n], id)
+		if err == errUnexpectedID {
+			continue
+		}
+
+		return response, err
+
+	}
+
+}
+
+func (r *Resolver) exchangeTCP(query []byte, id uint16) (*dnsmessage.Message, error) {
+
+	conn, err := net.DialTimeout("tcp", r.Config.Server, r.Config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(r.Config.Timeout))
+
+	framed := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(framed, uint16(len(query)))
+	copy(framed[2:], query)
+
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, binary.BigEndian.Uint16(length[:]))
+
+	if _, err := io.ReadFull(conn, buffer); err != nil {
+		return nil, err
+	}
+
+	return parseResponse(buffer, id)
+
+}
+
+var errUnexpectedID = errors.New("response does not match the query")
+
+func buildQuery(name string, recordType dnsmessage.Type, options QueryOptions) ([]byte, uint16, error) {
+
+	var idBytes [2]byte
+
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, 0, err
+	}
+
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	questionName, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := dnsmessage.Header{
+		ID:               id,
+		RecursionDesired: true,
+		AuthenticData:    options.DNSSECOK,
+		CheckingDisabled: options.CheckingDisabled,
+	}
+
+	builder := dnsmessage.NewBuilder(nil, header)
+	builder.EnableCompression()
+
+	if err := builder.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := builder.Question(dnsmessage.Question{Name: questionName, Type: recordType, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, err
+	}
+
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, 0, err
+	}
+
+	var opt dnsmessage.ResourceHeader
+
+	if err := opt.SetEDNS0(maxUDPSize, dnsmessage.RCodeSuccess, options.DNSSECOK); err != nil {
+		return nil, 0, err
+	}
+
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, 0, err
+	}
+
+	query, err := builder.Finish()
+
+	return query, id, err
+
+}
+
+func parseResponse(packet []byte, id uint16) (*dnsmessage.Message, error) {
+
+	var response dnsmessage.Message
+
+	if err := response.Unpack(packet); err != nil {
+		return nil, err
+	}
+
+	if response.Header.ID != id || !response.Header.Response {
+		return nil, errUnexpectedID
+	}
+
+	return &response, nil
+
+}
+
+// systemServer returns the first nameserver listed in a resolv.conf file
+func systemServer(path string) string {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fallbackServer
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return withPort(fields[1])
+		}
+
+	}
+
+	return fallbackServer
+
+}
+
+func withPort(server string) string {
+
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+
+}
+
+func fqdn(name string) string {
+
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+
+}
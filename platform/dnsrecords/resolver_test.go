@@ -0,0 +1,231 @@
+package dnsrecords
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testZone holds the answers of the test server by "name type", such as "example.com. A"
+type testZone map[string][]dnsmessage.Resource
+
+// newTestServer starts a DNS server answering from the zone over UDP and TCP on the same
+// port. When truncateUDP is set, UDP answers are empty and truncated
+func newTestServer(t *testing.T, zone testZone, truncateUDP bool) (string, func()) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packetConn, err := net.ListenPacket("udp", listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		t.Skipf("cannot listen on UDP: %s", err)
+	}
+
+	go func() {
+
+		buffer := make([]byte, 512)
+
+		for {
+
+			n, address, err := packetConn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+
+			packetConn.WriteTo(answer(zone, buffer[:n], truncateUDP), address)
+
+		}
+
+	}()
+
+	go func() {
+
+		for {
+
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			var length [2]byte
+
+			io.ReadFull(conn, length[:])
+
+			query := make([]byte, binary.BigEndian.Uint16(length[:]))
+
+			io.ReadFull(conn, query)
+
+			response := answer(zone, query, false)
+
+			binary.BigEndian.PutUint16(length[:], uint16(len(response)))
+
+			conn.Write(append(length[:], response...))
+			conn.Close()
+
+		}
+
+	}()
+
+	return listener.Addr().String(), func() {
+		listener.Close()
+		packetConn.Close()
+	}
+
+}
+
+func answer(zone testZone, query []byte, truncate bool) []byte {
+
+	var request dnsmessage.Message
+
+	if err := request.Unpack(query); err != nil || len(request.Questions) == 0 {
+		return nil
+	}
+
+	question := request.Questions[0]
+
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: request.Header.ID, Response: true, Authoritative: true},
+		Questions: request.Questions,
+	}
+
+	answers, exists := zone[question.Name.String()+" "+typeName(question.Type)]
+
+	switch {
+	case truncate:
+		response.Header.Truncated = true
+	case !exists && !zoneHasName(zone, question.Name.String()):
+		response.Header.RCode = dnsmessage.RCodeNameError
+	default:
+		response.Answers = answers
+	}
+
+	packed, _ := response.Pack()
+
+	return packed
+
+}
+
+func zoneHasName(zone testZone, name string) bool {
+
+	for key := range zone {
+		if strings.HasPrefix(key, name+" ") {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func TestLookup(t *testing.T) {
+
+	name := dnsmessage.MustNewName
+
+	zone := testZone{
+		"example.com. A": {
+			{Header: dnsmessage.ResourceHeader{Name: name("example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 300},
+				Body: &dnsmessage.AResource{A: [4]byte{93, 184, 216, 34}}},
+		},
+		"example.com. MX": {
+			{Header: dnsmessage.ResourceHeader{Name: name("example.com."), Type: dnsmessage.TypeMX, Class: dnsmessage.ClassINET, TTL: 300},
+				Body: &dnsmessage.MXResource{Pref: 10, MX: name("Mail.Example.com.")}},
+		},
+		"example.com. TXT": {
+			{Header: dnsmessage.ResourceHeader{Name: name("example.com."), Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 300},
+				Body: &dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}}},
+		},
+		"example.com. CAA": {
+			{Header: dnsmessage.ResourceHeader{Name: name("example.com."), Type: TypeCAA, Class: dnsmessage.ClassINET, TTL: 300},
+				Body: &dnsmessage.UnknownResource{Type: TypeCAA, Data: append([]byte{0, 5}, "issueletsencrypt.org"...)}},
+		},
+		"_sip._tcp.example.com. SRV": {
+			{Header: dnsmessage.ResourceHeader{Name: name("_sip._tcp.example.com."), Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: 300},
+				Body: &dnsmessage.SRVResource{Priority: 10, Weight: 5, Port: 5060, Target: name("sip.example.com.")}},
+		},
+	}
+
+	server, closeServer := newTestServer(t, zone, false)
+	defer closeServer()
+
+	inventory := NewResolver(Config{Server: server, Timeout: time.Second}).Lookup("example.com")
+
+	if len(inventory.Errors) != 0 {
+		t.Fatalf("got errors %v, but didn't want any", inventory.Errors)
+	}
+
+	want := []Record{
+		{Type: A, Name: "example.com.", TTL: 300, Value: "93.184.216.34"},
+		{Type: CAA, Name: "example.com.", TTL: 300, Value: `0 issue "letsencrypt.org"`},
+		{Type: MX, Name: "example.com.", TTL: 300, Value: "10 mail.example.com."},
+		{Type: SRV, Name: "_sip._tcp.example.com.", TTL: 300, Value: "10 5 5060 sip.example.com."},
+		{Type: TXT, Name: "example.com.", TTL: 300, Value: "v=spf1 -all"},
+	}
+
+	if !reflect.DeepEqual(inventory.Records, want) {
+		t.Errorf("got %+v, want %+v", inventory.Records, want)
+	}
+
+	if inventory.Resolver != server {
+		t.Errorf("got resolver %q, want %q", inventory.Resolver, server)
+	}
+
+}
+
+func TestQueryRetriesTruncatedAnswersOverTCP(t *testing.T) {
+
+	zone := testZone{
+		"example.com. NS": {
+			{Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("example.com."), Type: dnsmessage.TypeNS, Class: dnsmessage.ClassINET, TTL: 60},
+				Body: &dnsmessage.NSResource{NS: dnsmessage.MustNewName("ns1.example.com.")}},
+		},
+	}
+
+	server, closeServer := newTestServer(t, zone, true)
+	defer closeServer()
+
+	records, err := NewResolver(Config{Server: server, Timeout: time.Second}).Query("example.com", dnsmessage.TypeNS)
+	if err != nil {
+		t.Fatalf("got an error, but didn't want one: %s", err)
+	}
+
+	if len(records) != 1 || records[0].Value != "ns1.example.com." {
+		t.Errorf("got %+v, want the NS record served over TCP", records)
+	}
+
+}
+
+func TestQueryUnreachableServer(t *testing.T) {
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = NewResolver(Config{Server: conn.LocalAddr().String(), Timeout: 50 * time.Millisecond}).Query("example.com", dnsmessage.TypeA)
+	if err == nil {
+		t.Error("wanted an error, but didn't get one")
+	}
+
+}
+
+func TestConfigFromEnv(t *testing.T) {
+
+	os.Setenv("DNS_RESOLVER", "1.1.1.1")
+	defer os.Unsetenv("DNS_RESOLVER")
+
+	if got := ConfigFromEnv().Server; got != "1.1.1.1:53" {
+		t.Errorf("got server %q, want 1.1.1.1:53", got)
+	}
+
+}
@@ -0,0 +1,264 @@
+package dnssec
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Record types of DNSSEC, which the dnsmessage package does not name
+const (
+	TypeDS     = dnsmessage.Type(43)
+	TypeRRSIG  = dnsmessage.Type(46)
+	TypeDNSKEY = dnsmessage.Type(48)
+)
+
+// Flags of a DNSKEY record
+const (
+	zoneKeyFlag      = 0x0100
+	secureEntryPoint = 0x0001
+)
+
+// Lengths of the fixed parts of the records
+const (
+	rrsigFixedLength   = 18
+	dnskeyFixedLength  = 4
+	dsFixedLength      = 4
+	maxNameLabelLength = 63
+)
+
+var algorithmNames = map[uint8]string{
+	5:  "RSASHA1",
+	7:  "RSASHA1-NSEC3-SHA1",
+	8:  "RSASHA256",
+	10: "RSASHA512",
+	13: "ECDSAP256SHA256",
+	14: "ECDSAP384SHA384",
+	15: "ED25519",
+	16: "ED448",
+}
+
+var digestNames = map[uint8]string{
+	1: "SHA-1",
+	2: "SHA-256",
+	4: "SHA-384",
+}
+
+var typeNames = map[dnsmessage.Type]string{
+	TypeDS:             "DS",
+	TypeDNSKEY:         "DNSKEY",
+	dnsmessage.TypeSOA: "SOA",
+}
+
+var errTruncatedRecord = errors.New("record data is truncated")
+
+// dnskey represents a parsed DNSKEY record
+type dnskey struct {
+	flags     uint16
+	protocol  uint8
+	algorithm uint8
+	publicKey []byte
+	rdata     []byte
+}
+
+// ds represents a parsed DS record
+type ds struct {
+	keyTag     uint16
+	algorithm  uint8
+	digestType uint8
+	digest     []byte
+}
+
+// rrsig represents a parsed RRSIG record
+type rrsig struct {
+	typeCovered dnsmessage.Type
+	algorithm   uint8
+	labels      uint8
+	originalTTL uint32
+	expiration  uint32
+	inception   uint32
+	keyTag      uint16
+	signerName  string
+	signature   []byte
+}
+
+func parseDNSKEY(rdata []byte) (dnskey, error) {
+
+	if len(rdata) < dnskeyFixedLength {
+		return dnskey{}, errTruncatedRecord
+	}
+
+	return dnskey{
+		flags:     binary.BigEndian.Uint16(rdata),
+		protocol:  rdata[2],
+		algorithm: rdata[3],
+		publicKey: rdata[4:],
+		rdata:     rdata,
+	}, nil
+
+}
+
+// keyTag returns the tag DS and RRSIG records use to refer to the key, as computed in RFC 4034
+func (k dnskey) keyTag() uint16 {
+
+	var accumulator uint32
+
+	for i, b := range k.rdata {
+		if i&1 == 0 {
+			accumulator += uint32(b) << 8
+		} else {
+			accumulator += uint32(b)
+		}
+	}
+
+	accumulator += accumulator >> 16 & 0xFFFF
+
+	return uint16(accumulator & 0xFFFF)
+
+}
+
+func (k dnskey) isZoneKey() bool {
+	return k.flags&zoneKeyFlag != 0
+}
+
+func (k dnskey) isSecureEntryPoint() bool {
+	return k.flags&secureEntryPoint != 0
+}
+
+func parseDS(rdata []byte) (ds, error) {
+
+	if len(rdata) < dsFixedLength {
+		return ds{}, errTruncatedRecord
+	}
+
+	return ds{
+		keyTag:     binary.BigEndian.Uint16(rdata),
+		algorithm:  rdata[2],
+		digestType: rdata[3],
+		digest:     rdata[4:],
+	}, nil
+
+}
+
+func parseRRSIG(rdata []byte) (rrsig, error) {
+
+	if len(rdata) < rrsigFixedLength {
+		return rrsig{}, errTruncatedRecord
+	}
+
+	signerName, length, err := readName(rdata[rrsigFixedLength:])
+	if err != nil {
+		return rrsig{}, err
+	}
+
+	return rrsig{
+		typeCovered: dnsmessage.Type(binary.BigEndian.Uint16(rdata)),
+		algorithm:   rdata[2],
+		labels:      rdata[3],
+		originalTTL: binary.BigEndian.Uint32(rdata[4:]),
+		expiration:  binary.BigEndian.Uint32(rdata[8:]),
+		inception:   binary.BigEndian.Uint32(rdata[12:]),
+		keyTag:      binary.BigEndian.Uint16(rdata[16:]),
+		signerName:  signerName,
+		signature:   rdata[rrsigFixedLength+length:],
+	}, nil
+
+}
+
+// header returns the RRSIG data that precedes the signature, with the signer name in canonical form
+func (s rrsig) header() []byte {
+
+	data := make([]byte, rrsigFixedLength)
+
+	binary.BigEndian.PutUint16(data, uint16(s.typeCovered))
+	data[2] = s.algorithm
+	data[3] = s.labels
+	binary.BigEndian.PutUint32(data[4:], s.originalTTL)
+	binary.BigEndian.PutUint32(data[8:], s.expiration)
+	binary.BigEndian.PutUint32(data[12:], s.inception)
+	binary.BigEndian.PutUint16(data[16:], s.keyTag)
+
+	return append(data, canonicalName(s.signerName)...)
+
+}
+
+// readName reads an uncompressed name in wire format, returning it lowercase along with its length
+func readName(data []byte) (string, int, error) {
+
+	var labels []string
+
+	offset := 0
+
+	for {
+
+		if offset >= len(data) {
+			return "", 0, errTruncatedRecord
+		}
+
+		length := int(data[offset])
+		offset++
+
+		if length == 0 {
+			break
+		}
+
+		if length > maxNameLabelLength || offset+length > len(data) {
+			return "", 0, errTruncatedRecord
+		}
+
+		labels = append(labels, strings.ToLower(string(data[offset:offset+length])))
+		offset += length
+
+	}
+
+	return strings.Join(labels, ".") + ".", offset, nil
+
+}
+
+// canonicalName returns a name in the lowercase, uncompressed wire format signatures are computed over
+func canonicalName(name string) []byte {
+
+	var wire []byte
+
+	for _, label := range strings.Split(strings.ToLower(name), ".") {
+		if label != "" {
+			wire = append(wire, byte(len(label)))
+			wire = append(wire, label...)
+		}
+	}
+
+	return append(wire, 0)
+
+}
+
+func algorithmName(algorithm uint8) string {
+
+	if name, exists := algorithmNames[algorithm]; exists {
+		return name
+	}
+
+	return "UNKNOWN"
+
+}
+
+func digestName(digestType uint8) string {
+
+	if name, exists := digestNames[digestType]; exists {
+		return name
+	}
+
+	return "UNKNOWN"
+
+}
+
+func typeName(recordType dnsmessage.Type) string {
+
+	if name, exists := typeNames[recordType]; exists {
+		return name
+	}
+
+	return strings.TrimPrefix(recordType.String(), "Type")
+
+}
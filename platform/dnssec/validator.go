@@ -0,0 +1,521 @@
+package dnssec
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Statuses of a report
+const (
+	Secure        = "secure"
+	Insecure      = "insecure"
+	Bogus         = "bogus"
+	Indeterminate = "indeterminate"
+)
+
+// expiryWarning is how close to its expiration a signature is reported as expiring soon
+const expiryWarning = 7 * 24 * time.Hour
+
+// Roles of a DNSKEY record
+const (
+	KSK = "KSK"
+	ZSK = "ZSK"
+)
+
+// TrustAnchor represents the DS record of a root zone key the chain of trust starts from
+type TrustAnchor struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     string
+}
+
+// RootTrustAnchors holds the root zone keys published by IANA, KSK-2017 and KSK-2024
+var RootTrustAnchors = []TrustAnchor{
+	{KeyTag: 20326, Algorithm: 8, DigestType: 2, Digest: "E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"},
+	{KeyTag: 38696, Algorithm: 8, DigestType: 2, Digest: "683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16"},
+}
+
+// Report represents the DNSSEC state of the zone a domain belongs to
+type Report struct {
+	Zone              string      `json:"zone"`
+	Status            string      `json:"status"`
+	Signed            bool        `json:"signed"`
+	HasDS             bool        `json:"has_ds"`
+	AuthenticatedData bool        `json:"authenticated_data"`
+	InsecureFrom      string      `json:"insecure_from,omitempty"`
+	DNSKEYAlgorithms  []string    `json:"dnskey_algorithms"`
+	DSAlgorithms      []string    `json:"ds_algorithms"`
+	Keys              []Key       `json:"keys"`
+	DS                []DS        `json:"ds"`
+	Signatures        []Signature `json:"signatures"`
+	NextExpiration    *time.Time  `json:"next_expiration"`
+	ExpiringSoon      bool        `json:"expiring_soon"`
+	Error             string      `json:"error,omitempty"`
+	CheckedAt         time.Time   `json:"checked_at"`
+}
+
+// Key represents a DNSKEY record of the zone
+type Key struct {
+	KeyTag    uint16 `json:"key_tag"`
+	Role      string `json:"role"`
+	Algorithm string `json:"algorithm"`
+}
+
+// DS represents a DS record the parent zone publishes for the zone
+type DS struct {
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  string `json:"algorithm"`
+	DigestType string `json:"digest_type"`
+	MatchesKey bool   `json:"matches_key"`
+}
+
+// Signature represents an RRSIG record over the DNSKEY, DS or SOA records of the zone
+type Signature struct {
+	TypeCovered string    `json:"type_covered"`
+	KeyTag      uint16    `json:"key_tag"`
+	Algorithm   string    `json:"algorithm"`
+	Inception   time.Time `json:"inception"`
+	Expiration  time.Time `json:"expiration"`
+}
+
+// Exchanger represents what the validator needs to send queries
+type Exchanger interface {
+	Exchange(name string, recordType dnsmessage.Type, options dnsrecords.QueryOptions) (*dnsmessage.Message, error)
+}
+
+// Validator represents a client walking the chain of trust from the root down to a zone
+type Validator struct {
+	Exchanger    Exchanger
+	TrustAnchors []TrustAnchor
+	Now          func() time.Time
+}
+
+// rrset represents the records of a type found at a name along with the signatures covering them
+type rrset struct {
+	rdatas     [][]byte
+	signatures []rrsig
+}
+
+// queryError represents a failure to get an answer, which leaves the status indeterminate
+type queryError struct {
+	err error
+}
+
+func (e queryError) Error() string {
+	return e.err.Error()
+}
+
+// NewValidator returns a Validator sending its queries to the given exchanger
+func NewValidator(exchanger Exchanger) *Validator {
+
+	return &Validator{
+		Exchanger:    exchanger,
+		TrustAnchors: RootTrustAnchors,
+		Now:          time.Now,
+	}
+
+}
+
+// Check reports the DNSSEC state of the given domain with the resolver found in the environment
+func Check(domain string) Report {
+	return NewValidator(dnsrecords.NewResolver(dnsrecords.ConfigFromEnv())).Check(domain)
+}
+
+// Check finds the zone of the domain, describes its keys and signatures and validates the chain
+// of trust leading to it. The records are asked with checking disabled so that a validating
+// resolver hands over what it would otherwise refuse, and validation happens here
+func (v *Validator) Check(domain string) Report {
+
+	now := v.Now()
+	cache := make(map[string]rrset)
+
+	report := Report{
+		DNSKEYAlgorithms: []string{},
+		DSAlgorithms:     []string{},
+		Keys:             []Key{},
+		DS:               []DS{},
+		Signatures:       []Signature{},
+		CheckedAt:        now,
+	}
+
+	zones, err := v.zoneCuts(domain, cache)
+	if err != nil {
+		report.Status = Indeterminate
+		report.Error = err.Error()
+		return report
+	}
+
+	report.Zone = zones[len(zones)-1]
+
+	err = v.describe(&report, cache, now)
+	if err == nil {
+		err = v.validate(&report, zones, cache, now)
+	}
+
+	switch err.(type) {
+
+	case nil:
+		if report.InsecureFrom != "" {
+			report.Status = Insecure
+		} else {
+			report.Status = Secure
+		}
+
+	case queryError:
+		report.Status = Indeterminate
+		report.Error = err.Error()
+
+	default:
+		report.Status = Bogus
+		report.Error = err.Error()
+
+	}
+
+	if response, err := v.Exchanger.Exchange(report.Zone, dnsmessage.TypeSOA, dnsrecords.QueryOptions{DNSSECOK: true}); err == nil {
+		report.AuthenticatedData = response.Header.AuthenticData
+	}
+
+	return report
+
+}
+
+// describe fills the keys, DS records and signatures of the zone
+func (v *Validator) describe(report *Report, cache map[string]rrset, now time.Time) error {
+
+	keys, err := v.fetch(report.Zone, TypeDNSKEY, cache)
+	if err != nil {
+		return err
+	}
+
+	var parsedKeys []dnskey
+
+	for _, rdata := range keys.rdatas {
+
+		key, err := parseDNSKEY(rdata)
+		if err != nil {
+			continue
+		}
+
+		role := ZSK
+		if key.isSecureEntryPoint() {
+			role = KSK
+		}
+
+		parsedKeys = append(parsedKeys, key)
+		report.Keys = append(report.Keys, Key{KeyTag: key.keyTag(), Role: role, Algorithm: algorithmName(key.algorithm)})
+		report.DNSKEYAlgorithms = appendUnique(report.DNSKEYAlgorithms, algorithmName(key.algorithm))
+
+	}
+
+	report.Signed = len(parsedKeys) > 0
+
+	signatures := append([]rrsig{}, keys.signatures...)
+
+	if report.Zone != "." {
+
+		delegation, err := v.fetch(report.Zone, TypeDS, cache)
+		if err != nil {
+			return err
+		}
+
+		for _, rdata := range delegation.rdatas {
+
+			record, err := parseDS(rdata)
+			if err != nil {
+				continue
+			}
+
+			matches := false
+
+			for _, key := range parsedKeys {
+				if record.matches(report.Zone, key) {
+					matches = true
+				}
+			}
+
+			report.DS = append(report.DS, DS{
+				KeyTag:     record.keyTag,
+				Algorithm:  algorithmName(record.algorithm),
+				DigestType: digestName(record.digestType),
+				MatchesKey: matches,
+			})
+			report.DSAlgorithms = appendUnique(report.DSAlgorithms, algorithmName(record.algorithm))
+
+		}
+
+		report.HasDS = len(report.DS) > 0
+		signatures = append(signatures, delegation.signatures...)
+
+	}
+
+	soa, err := v.fetch(report.Zone, dnsmessage.TypeSOA, cache)
+	if err != nil {
+		return err
+	}
+
+	signatures = append(signatures, soa.signatures...)
+
+	for _, signature := range signatures {
+
+		report.Signatures = append(report.Signatures, Signature{
+			TypeCovered: typeName(signature.typeCovered),
+			KeyTag:      signature.keyTag,
+			Algorithm:   algorithmName(signature.algorithm),
+			Inception:   signature.inceptionTime(),
+			Expiration:  signature.expirationTime(),
+		})
+
+		if expiration := signature.expirationTime(); report.NextExpiration == nil || expiration.Before(*report.NextExpiration) {
+			report.NextExpiration = &expiration
+		}
+
+	}
+
+	sort.Slice(report.Signatures, func(i, j int) bool {
+		return report.Signatures[i].Expiration.Before(report.Signatures[j].Expiration)
+	})
+
+	report.ExpiringSoon = report.NextExpiration != nil && report.NextExpiration.Sub(now) < expiryWarning
+
+	return nil
+
+}
+
+// validate walks the zones from the root, checking each DS RRset with the keys of the parent and
+// each DNSKEY RRset with a key the DS records point to. The walk stops as insecure at the first
+// delegation without DS records
+func (v *Validator) validate(report *Report, zones []string, cache map[string]rrset, now time.Time) error {
+
+	trusted, err := v.zoneKeys(".", v.anchors(), cache, now)
+	if err != nil {
+		return err
+	}
+
+	for _, zone := range zones[1:] {
+
+		delegation, err := v.fetch(zone, TypeDS, cache)
+		if err != nil {
+			return err
+		}
+
+		if len(delegation.rdatas) == 0 {
+			report.InsecureFrom = zone
+			return nil
+		}
+
+		if _, err := verifyRRSet(zone, delegation.rdatas, delegation.signatures, trusted, now); err != nil {
+			return fmt.Errorf("DS records of %s: %v", zone, err)
+		}
+
+		var records []ds
+
+		for _, rdata := range delegation.rdatas {
+			if record, err := parseDS(rdata); err == nil {
+				records = append(records, record)
+			}
+		}
+
+		if trusted, err = v.zoneKeys(zone, records, cache, now); err != nil {
+			return err
+		}
+
+	}
+
+	soa, err := v.fetch(report.Zone, dnsmessage.TypeSOA, cache)
+	if err != nil {
+		return err
+	}
+
+	if _, err := verifyRRSet(report.Zone, soa.rdatas, soa.signatures, trusted, now); err != nil {
+		return fmt.Errorf("SOA record of %s: %v", report.Zone, err)
+	}
+
+	return nil
+
+}
+
+// zoneKeys returns the DNSKEY records of a zone once their RRset is signed by a key one of
+// the given DS records refers to
+func (v *Validator) zoneKeys(zone string, delegation []ds, cache map[string]rrset, now time.Time) ([]dnskey, error) {
+
+	keys, err := v.fetch(zone, TypeDNSKEY, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed, entryPoints []dnskey
+
+	for _, rdata := range keys.rdatas {
+
+		key, err := parseDNSKEY(rdata)
+		if err != nil {
+			continue
+		}
+
+		parsed = append(parsed, key)
+
+		for _, record := range delegation {
+			if record.matches(zone, key) {
+				entryPoints = append(entryPoints, key)
+				break
+			}
+		}
+
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("%s has DS records but no DNSKEY record", zone)
+	}
+
+	if len(entryPoints) == 0 {
+		return nil, fmt.Errorf("no DNSKEY record of %s matches its DS records", zone)
+	}
+
+	if _, err := verifyRRSet(zone, keys.rdatas, keys.signatures, entryPoints, now); err != nil {
+		return nil, fmt.Errorf("DNSKEY records of %s: %v", zone, err)
+	}
+
+	return parsed, nil
+
+}
+
+// anchors returns the trust anchors as DS records
+func (v *Validator) anchors() []ds {
+
+	var records []ds
+
+	for _, anchor := range v.TrustAnchors {
+
+		digest, err := hex.DecodeString(anchor.Digest)
+		if err != nil {
+			continue
+		}
+
+		records = append(records, ds{keyTag: anchor.KeyTag, algorithm: anchor.Algorithm, digestType: anchor.DigestType, digest: digest})
+
+	}
+
+	return records
+
+}
+
+// zoneCuts returns the zones from the root down to the one the domain belongs to, a name being
+// the apex of a zone when it owns an SOA record
+func (v *Validator) zoneCuts(domain string, cache map[string]rrset) ([]string, error) {
+
+	zones := []string{"."}
+
+	labels := strings.Split(strings.Trim(strings.ToLower(domain), "."), ".")
+
+	for i := len(labels) - 1; i >= 0; i-- {
+
+		name := strings.Join(labels[i:], ".") + "."
+
+		soa, err := v.fetch(name, dnsmessage.TypeSOA, cache)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(soa.rdatas) > 0 {
+			zones = append(zones, name)
+		}
+
+	}
+
+	return zones, nil
+
+}
+
+// fetch returns the records of a type owned by a name and their signatures, asking each
+// question once per check
+func (v *Validator) fetch(name string, recordType dnsmessage.Type, cache map[string]rrset) (rrset, error) {
+
+	key := name + " " + typeName(recordType)
+
+	if set, exists := cache[key]; exists {
+		return set, nil
+	}
+
+	response, err := v.Exchanger.Exchange(name, recordType, dnsrecords.QueryOptions{DNSSECOK: true, CheckingDisabled: true})
+	if err != nil {
+		return rrset{}, queryError{fmt.Errorf("querying %s records of %s: %v", typeName(recordType), name, err)}
+	}
+
+	var set rrset
+
+	for _, answer := range response.Answers {
+
+		if !strings.EqualFold(answer.Header.Name.String(), name) {
+			continue
+		}
+
+		switch {
+
+		case answer.Header.Type == recordType:
+			rdata, err := resourceData(answer.Body)
+			if err != nil {
+				return rrset{}, err
+			}
+			set.rdatas = append(set.rdatas, rdata)
+
+		case answer.Header.Type == TypeRRSIG:
+			unknown, ok := answer.Body.(*dnsmessage.UnknownResource)
+			if !ok {
+				continue
+			}
+			signature, err := parseRRSIG(unknown.Data)
+			if err == nil && signature.typeCovered == recordType {
+				set.signatures = append(set.signatures, signature)
+			}
+
+		}
+
+	}
+
+	cache[key] = set
+
+	return set, nil
+
+}
+
+// resourceData returns the data of a record in wire format, as signatures cover it
+func resourceData(body dnsmessage.ResourceBody) ([]byte, error) {
+
+	switch resource := body.(type) {
+
+	case *dnsmessage.UnknownResource:
+		return resource.Data, nil
+
+	case *dnsmessage.SOAResource:
+		data := append(canonicalName(resource.NS.String()), canonicalName(resource.MBox.String())...)
+		for _, value := range []uint32{resource.Serial, resource.Refresh, resource.Retry, resource.Expire, resource.MinTTL} {
+			data = append(data, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+		}
+		return data, nil
+
+	}
+
+	return nil, errors.New("unexpected record body")
+
+}
+
+func appendUnique(values []string, value string) []string {
+
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+
+}
@@ -0,0 +1,350 @@
+package dnssec
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeExchanger answers from the records and signatures published for each name and type
+type fakeExchanger struct {
+	answers       map[string][]dnsmessage.Resource
+	authenticated bool
+	err           error
+}
+
+func (f *fakeExchanger) Exchange(name string, recordType dnsmessage.Type, options dnsrecords.QueryOptions) (*dnsmessage.Message, error) {
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &dnsmessage.Message{
+		Header:  dnsmessage.Header{Response: true, AuthenticData: f.authenticated && !options.CheckingDisabled},
+		Answers: f.answers[name+" "+typeName(recordType)],
+	}, nil
+
+}
+
+// signer represents an ECDSA P-256 key of a test zone
+type signer struct {
+	zone    string
+	private *ecdsa.PrivateKey
+	key     dnskey
+}
+
+func newSigner(t *testing.T, zone string, flags uint16) *signer {
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rdata := append([]byte{byte(flags >> 8), byte(flags), 3, 13}, pad(private.X, 32)...)
+	rdata = append(rdata, pad(private.Y, 32)...)
+
+	key, err := parseDNSKEY(rdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &signer{zone: zone, private: private, key: key}
+
+}
+
+func pad(value *big.Int, size int) []byte {
+
+	data := value.Bytes()
+
+	return append(make([]byte, size-len(data)), data...)
+
+}
+
+// sign returns the RRSIG record of an RRset valid between the given times
+func (s *signer) sign(t *testing.T, owner string, recordType dnsmessage.Type, rdatas [][]byte, inception, expiration time.Time) dnsmessage.Resource {
+
+	signature := rrsig{
+		typeCovered: recordType,
+		algorithm:   13,
+		labels:      uint8(len(strings.Split(strings.Trim(owner, "."), "."))),
+		originalTTL: 3600,
+		expiration:  uint32(expiration.Unix()),
+		inception:   uint32(inception.Unix()),
+		keyTag:      s.key.keyTag(),
+		signerName:  s.zone,
+	}
+
+	digest := sha256.Sum256(signature.signedData(owner, rdatas))
+
+	r, sValue, err := ecdsa.Sign(rand.Reader, s.private, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rdata := append(signature.header(), pad(r, 32)...)
+	rdata = append(rdata, pad(sValue, 32)...)
+
+	return resource(owner, TypeRRSIG, &dnsmessage.UnknownResource{Type: TypeRRSIG, Data: rdata})
+
+}
+
+func resource(owner string, recordType dnsmessage.Type, body dnsmessage.ResourceBody) dnsmessage.Resource {
+
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(owner), Type: recordType, Class: dnsmessage.ClassINET, TTL: 3600},
+		Body:   body,
+	}
+
+}
+
+func dsRecord(zone string, key dnskey) []byte {
+
+	digest := sha256.Sum256(append(canonicalName(zone), key.rdata...))
+
+	return append([]byte{byte(key.keyTag() >> 8), byte(key.keyTag()), key.algorithm, 2}, digest[:]...)
+
+}
+
+// hierarchy represents a signed root, com. and example.com. served by a fake exchanger
+type hierarchy struct {
+	exchanger  *fakeExchanger
+	ksks       map[string]*signer
+	zsks       map[string]*signer
+	inception  time.Time
+	expiration time.Time
+}
+
+func newHierarchy(t *testing.T, now time.Time) *hierarchy {
+
+	h := &hierarchy{
+		exchanger:  &fakeExchanger{answers: make(map[string][]dnsmessage.Resource), authenticated: true},
+		ksks:       make(map[string]*signer),
+		zsks:       make(map[string]*signer),
+		inception:  now.Add(-24 * time.Hour),
+		expiration: now.Add(30 * 24 * time.Hour),
+	}
+
+	parent := ""
+
+	for _, zone := range []string{".", "com.", "example.com."} {
+
+		h.ksks[zone] = newSigner(t, zone, zoneKeyFlag|secureEntryPoint)
+		h.zsks[zone] = newSigner(t, zone, zoneKeyFlag)
+
+		h.publishKeys(t, zone, h.expiration)
+		h.publishSOA(t, zone, 1, h.expiration)
+
+		if parent != "" {
+			h.publishDS(t, zone, dsRecord(zone, h.ksks[zone].key))
+		}
+
+		parent = zone
+
+	}
+
+	return h
+
+}
+
+func (h *hierarchy) publish(t *testing.T, zoneSigner *signer, owner string, recordType dnsmessage.Type, bodies []dnsmessage.ResourceBody, expiration time.Time) {
+
+	var answers []dnsmessage.Resource
+	var rdatas [][]byte
+
+	for _, body := range bodies {
+
+		rdata, err := resourceData(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		answers = append(answers, resource(owner, recordType, body))
+		rdatas = append(rdatas, rdata)
+
+	}
+
+	if zoneSigner != nil {
+		answers = append(answers, zoneSigner.sign(t, owner, recordType, rdatas, h.inception, expiration))
+	}
+
+	h.exchanger.answers[owner+" "+typeName(recordType)] = answers
+
+}
+
+func (h *hierarchy) publishKeys(t *testing.T, zone string, expiration time.Time) {
+
+	bodies := []dnsmessage.ResourceBody{
+		&dnsmessage.UnknownResource{Type: TypeDNSKEY, Data: h.ksks[zone].key.rdata},
+		&dnsmessage.UnknownResource{Type: TypeDNSKEY, Data: h.zsks[zone].key.rdata},
+	}
+
+	h.publish(t, h.ksks[zone], zone, TypeDNSKEY, bodies, expiration)
+
+}
+
+func (h *hierarchy) publishSOA(t *testing.T, zone string, serial uint32, expiration time.Time) {
+
+	soa := &dnsmessage.SOAResource{
+		NS:      dnsmessage.MustNewName("ns1." + strings.TrimPrefix(zone, ".")),
+		MBox:    dnsmessage.MustNewName("hostmaster." + strings.TrimPrefix(zone, ".")),
+		Serial:  serial,
+		Refresh: 7200,
+		Retry:   3600,
+		Expire:  1209600,
+		MinTTL:  3600,
+	}
+
+	h.publish(t, h.zsks[zone], zone, dnsmessage.TypeSOA, []dnsmessage.ResourceBody{soa}, expiration)
+
+}
+
+func (h *hierarchy) publishDS(t *testing.T, zone string, records ...[]byte) {
+
+	parent := zone[strings.Index(zone, ".")+1:]
+	if parent == "" {
+		parent = "."
+	}
+
+	var bodies []dnsmessage.ResourceBody
+
+	for _, record := range records {
+		bodies = append(bodies, &dnsmessage.UnknownResource{Type: TypeDS, Data: record})
+	}
+
+	h.publish(t, h.zsks[parent], zone, TypeDS, bodies, h.expiration)
+
+}
+
+func (h *hierarchy) validator() *Validator {
+
+	root := h.ksks["."].key
+	digest := sha256.Sum256(append(canonicalName("."), root.rdata...))
+
+	validator := NewValidator(h.exchanger)
+	validator.TrustAnchors = []TrustAnchor{{KeyTag: root.keyTag(), Algorithm: root.algorithm, DigestType: 2, Digest: hex.EncodeToString(digest[:])}}
+	validator.Now = func() time.Time { return h.inception.Add(24 * time.Hour) }
+
+	return validator
+
+}
+
+func TestCheckSecure(t *testing.T) {
+
+	now := time.Now()
+	h := newHierarchy(t, now)
+
+	report := h.validator().Check("www.example.com")
+
+	if report.Status != Secure || report.Error != "" {
+		t.Fatalf("got status %s (%s), want %s", report.Status, report.Error, Secure)
+	}
+
+	if report.Zone != "example.com." || !report.Signed || !report.HasDS || !report.AuthenticatedData {
+		t.Errorf("got %+v, want a signed and delegated example.com.", report)
+	}
+
+	if len(report.Keys) != 2 || len(report.DS) != 1 || !report.DS[0].MatchesKey {
+		t.Errorf("got keys %+v and DS %+v, want two keys and a matching DS", report.Keys, report.DS)
+	}
+
+	if len(report.DNSKEYAlgorithms) != 1 || report.DNSKEYAlgorithms[0] != "ECDSAP256SHA256" {
+		t.Errorf("got algorithms %v, want ECDSAP256SHA256", report.DNSKEYAlgorithms)
+	}
+
+	if len(report.Signatures) != 3 || report.NextExpiration == nil || report.NextExpiration.Unix() != h.expiration.Unix() || report.ExpiringSoon {
+		t.Errorf("got signatures %+v expiring at %v, want three expiring at %v", report.Signatures, report.NextExpiration, h.expiration)
+	}
+
+}
+
+func TestCheckInsecureDelegation(t *testing.T) {
+
+	h := newHierarchy(t, time.Now())
+
+	h.publishDS(t, "example.com.")
+	delete(h.exchanger.answers, "example.com. DNSKEY")
+
+	report := h.validator().Check("example.com")
+
+	if report.Status != Insecure || report.InsecureFrom != "example.com." {
+		t.Errorf("got status %s from %q (%s), want %s from example.com.", report.Status, report.InsecureFrom, report.Error, Insecure)
+	}
+
+	if report.Signed || report.HasDS {
+		t.Errorf("got %+v, want an unsigned zone", report)
+	}
+
+}
+
+func TestCheckBogus(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, h *hierarchy)
+	}{
+		{"expired keys signature", func(t *testing.T, h *hierarchy) {
+			h.publishKeys(t, "example.com.", h.inception.Add(time.Hour))
+		}},
+		{"DS matching no key", func(t *testing.T, h *hierarchy) {
+			h.publishDS(t, "example.com.", dsRecord("example.com.", newSigner(t, "example.com.", zoneKeyFlag|secureEntryPoint).key))
+		}},
+		{"forged SOA", func(t *testing.T, h *hierarchy) {
+			signature := h.exchanger.answers["example.com. SOA"][1]
+			h.publishSOA(t, "example.com.", 2, h.expiration)
+			h.exchanger.answers["example.com. SOA"][1] = signature
+		}},
+		{"unsigned DNSKEY", func(t *testing.T, h *hierarchy) {
+			h.exchanger.answers["example.com. DNSKEY"] = h.exchanger.answers["example.com. DNSKEY"][:2]
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			h := newHierarchy(t, time.Now())
+			tt.tamper(t, h)
+
+			report := h.validator().Check("example.com")
+
+			if report.Status != Bogus || report.Error == "" {
+				t.Errorf("got status %s (%s), want %s", report.Status, report.Error, Bogus)
+			}
+
+		})
+	}
+
+}
+
+func TestCheckExpiringSoon(t *testing.T) {
+
+	h := newHierarchy(t, time.Now())
+	h.publishSOA(t, "example.com.", 1, h.inception.Add(72*time.Hour))
+
+	report := h.validator().Check("example.com")
+
+	if report.Status != Secure || !report.ExpiringSoon {
+		t.Errorf("got status %s expiring soon %v, want %s expiring soon", report.Status, report.ExpiringSoon, Secure)
+	}
+
+}
+
+func TestCheckIndeterminate(t *testing.T) {
+
+	report := NewValidator(&fakeExchanger{err: errors.New("i/o timeout")}).Check("example.com")
+
+	if report.Status != Indeterminate || report.Error == "" {
+		t.Errorf("got status %s (%s), want %s", report.Status, report.Error, Indeterminate)
+	}
+
+}
@@ -0,0 +1,268 @@
+package dnssec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"sort"
+	"time"
+)
+
+var (
+	errUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	errInvalidKey           = errors.New("invalid public key")
+	errBadSignature         = errors.New("signature does not verify")
+)
+
+// signedData returns the data an RRSIG signs for the given RRset, with the records sorted in canonical order
+func (s rrsig) signedData(owner string, rdatas [][]byte) []byte {
+
+	sorted := make([][]byte, len(rdatas))
+	copy(sorted, rdatas)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	data := s.header()
+	name := canonicalName(owner)
+
+	for i, rdata := range sorted {
+
+		if i > 0 && bytes.Equal(rdata, sorted[i-1]) {
+			continue
+		}
+
+		var fixed [10]byte
+
+		binary.BigEndian.PutUint16(fixed[:], uint16(s.typeCovered))
+		binary.BigEndian.PutUint16(fixed[2:], 1)
+		binary.BigEndian.PutUint32(fixed[4:], s.originalTTL)
+		binary.BigEndian.PutUint16(fixed[8:], uint16(len(rdata)))
+
+		data = append(data, name...)
+		data = append(data, fixed[:]...)
+		data = append(data, rdata...)
+
+	}
+
+	return data
+
+}
+
+// validAt returns an error when the signature is not valid yet or has expired
+func (s rrsig) validAt(now time.Time) error {
+
+	if now.Before(s.inceptionTime()) {
+		return fmt.Errorf("signature over %s is not valid before %s", typeName(s.typeCovered), s.inceptionTime().Format(time.RFC3339))
+	}
+
+	if now.After(s.expirationTime()) {
+		return fmt.Errorf("signature over %s expired at %s", typeName(s.typeCovered), s.expirationTime().Format(time.RFC3339))
+	}
+
+	return nil
+
+}
+
+func (s rrsig) inceptionTime() time.Time {
+	return time.Unix(int64(s.inception), 0).UTC()
+}
+
+func (s rrsig) expirationTime() time.Time {
+	return time.Unix(int64(s.expiration), 0).UTC()
+}
+
+// verify checks the signature of an RRset against the given key
+func (s rrsig) verify(key dnskey, owner string, rdatas [][]byte) error {
+
+	if key.algorithm != s.algorithm || key.keyTag() != s.keyTag || !key.isZoneKey() {
+		return errors.New("key does not match the signature")
+	}
+
+	data := s.signedData(owner, rdatas)
+
+	switch s.algorithm {
+
+	case 5, 7:
+		return verifyRSA(key.publicKey, crypto.SHA1, sha1.New(), data, s.signature)
+
+	case 8:
+		return verifyRSA(key.publicKey, crypto.SHA256, sha256.New(), data, s.signature)
+
+	case 10:
+		return verifyRSA(key.publicKey, crypto.SHA512, sha512.New(), data, s.signature)
+
+	case 13:
+		return verifyECDSA(key.publicKey, elliptic.P256(), sha256.New(), data, s.signature)
+
+	case 14:
+		return verifyECDSA(key.publicKey, elliptic.P384(), sha512.New384(), data, s.signature)
+
+	case 15:
+		if len(key.publicKey) != ed25519.PublicKeySize {
+			return errInvalidKey
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key.publicKey), data, s.signature) {
+			return errBadSignature
+		}
+		return nil
+
+	}
+
+	return errUnsupportedAlgorithm
+
+}
+
+func verifyRSA(publicKey []byte, hashType crypto.Hash, digest hash.Hash, data, signature []byte) error {
+
+	key, err := rsaPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	digest.Write(data)
+
+	if err := rsa.VerifyPKCS1v15(key, hashType, digest.Sum(nil), signature); err != nil {
+		return errBadSignature
+	}
+
+	return nil
+
+}
+
+// rsaPublicKey decodes an RSA key in the format of RFC 3110
+func rsaPublicKey(data []byte) (*rsa.PublicKey, error) {
+
+	if len(data) < 3 {
+		return nil, errInvalidKey
+	}
+
+	exponentLength, offset := int(data[0]), 1
+
+	if exponentLength == 0 {
+		exponentLength, offset = int(binary.BigEndian.Uint16(data[1:])), 3
+	}
+
+	if exponentLength == 0 || exponentLength > 4 || offset+exponentLength >= len(data) {
+		return nil, errInvalidKey
+	}
+
+	exponent := 0
+
+	for _, b := range data[offset : offset+exponentLength] {
+		exponent = exponent<<8 | int(b)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(data[offset+exponentLength:]),
+		E: exponent,
+	}, nil
+
+}
+
+func verifyECDSA(publicKey []byte, curve elliptic.Curve, digest hash.Hash, data, signature []byte) error {
+
+	size := (curve.Params().BitSize + 7) / 8
+
+	if len(publicKey) != 2*size {
+		return errInvalidKey
+	}
+
+	if len(signature) != 2*size {
+		return errBadSignature
+	}
+
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(publicKey[:size]),
+		Y:     new(big.Int).SetBytes(publicKey[size:]),
+	}
+
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return errInvalidKey
+	}
+
+	digest.Write(data)
+
+	if !ecdsa.Verify(key, digest.Sum(nil), new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])) {
+		return errBadSignature
+	}
+
+	return nil
+
+}
+
+// matches reports whether a DS record refers to the given key of the zone
+func (d ds) matches(owner string, key dnskey) bool {
+
+	if d.keyTag != key.keyTag() || d.algorithm != key.algorithm {
+		return false
+	}
+
+	var digest hash.Hash
+
+	switch d.digestType {
+	case 1:
+		digest = sha1.New()
+	case 2:
+		digest = sha256.New()
+	case 4:
+		digest = sha512.New384()
+	default:
+		return false
+	}
+
+	digest.Write(canonicalName(owner))
+	digest.Write(key.rdata)
+
+	return bytes.Equal(digest.Sum(nil), d.digest)
+
+}
+
+// verifyRRSet returns the first signature of the RRset made by one of the given keys and
+// valid at the given time, or an error explaining why none is
+func verifyRRSet(owner string, rdatas [][]byte, signatures []rrsig, keys []dnskey, now time.Time) (rrsig, error) {
+
+	if len(signatures) == 0 {
+		return rrsig{}, errors.New("no signature")
+	}
+
+	err := errors.New("no signature made by a trusted key")
+
+	for _, signature := range signatures {
+		for _, key := range keys {
+
+			if key.keyTag() != signature.keyTag || key.algorithm != signature.algorithm {
+				continue
+			}
+
+			if verifyErr := signature.verify(key, owner, rdatas); verifyErr != nil {
+				err = verifyErr
+				continue
+			}
+
+			if timeErr := signature.validAt(now); timeErr != nil {
+				err = timeErr
+				continue
+			}
+
+			return signature, nil
+
+		}
+	}
+
+	return rrsig{}, err
+
+}
@@ -0,0 +1,334 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Page represents what is known about a website when fingerprinting it. Meta holds the
+// content of the <meta> tags by lowercase name
+type Page struct {
+	Header    http.Header
+	Cookies   map[string]string
+	Meta      map[string]string
+	ScriptSrc []string
+	HTML      string
+}
+
+// Technology represents a technology detected on a website
+type Technology struct {
+	Name       string   `json:"name"`
+	Categories []string `json:"categories"`
+	Version    string   `json:"version"`
+}
+
+// Engine detects technologies on a page with a set of signatures
+type Engine struct {
+	signatures map[string]*signature
+}
+
+// rawSignature represents a signature as written in the signature file, following the
+// format of Wappalyzer: patterns are regular expressions optionally followed by
+// "\;version:\1" to extract the version from a capture group
+type rawSignature struct {
+	Categories []string          `json:"cats"`
+	Headers    map[string]string `json:"headers"`
+	Cookies    map[string]string `json:"cookies"`
+	Meta       map[string]string `json:"meta"`
+	ScriptSrc  []string          `json:"scriptSrc"`
+	HTML       []string          `json:"html"`
+	Implies    []string          `json:"implies"`
+}
+
+type signature struct {
+	categories []string
+	headers    map[string]*pattern
+	cookies    map[string]*pattern
+	meta       map[string]*pattern
+	scriptSrc  []*pattern
+	html       []*pattern
+	implies    []string
+}
+
+type pattern struct {
+	regex   *regexp.Regexp
+	version string
+}
+
+var groupReference = regexp.MustCompile(`\\(\d+)`)
+
+// NewEngine returns an Engine using the given signature file contents
+func NewEngine(signatureFile []byte) (*Engine, error) {
+
+	var raw map[string]rawSignature
+
+	if err := json.Unmarshal(signatureFile, &raw); err != nil {
+		return nil, fmt.Errorf("invalid signature file: %s", err.Error())
+	}
+
+	engine := &Engine{signatures: make(map[string]*signature)}
+
+	for name, rawSig := range raw {
+
+		sig, err := compileSignature(rawSig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature for %s: %s", name, err.Error())
+		}
+
+		engine.signatures[name] = sig
+
+	}
+
+	return engine, nil
+
+}
+
+// DefaultEngine returns an Engine using the file set by FINGERPRINT_SIGNATURES, or the
+// signatures bundled with the service when it is not set
+func DefaultEngine() (*Engine, error) {
+
+	path := os.Getenv("FINGERPRINT_SIGNATURES")
+	if path == "" {
+		return NewEngine([]byte(bundledSignatures))
+	}
+
+	signatureFile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEngine(signatureFile)
+
+}
+
+// Detect returns the technologies found on the page, including the ones implied by them, sorted by name
+func (e *Engine) Detect(page Page) []Technology {
+
+	detected := make(map[string]string)
+
+	for name, sig := range e.signatures {
+		if version, matched := sig.match(page); matched {
+			detected[name] = version
+		}
+	}
+
+	var pending []string
+
+	for name := range detected {
+		pending = append(pending, name)
+	}
+
+	for len(pending) > 0 {
+
+		name := pending[0]
+		pending = pending[1:]
+
+		for _, implied := range e.signatures[name].implies {
+			if _, exists := detected[implied]; !exists && e.signatures[implied] != nil {
+				detected[implied] = ""
+				pending = append(pending, implied)
+			}
+		}
+
+	}
+
+	technologies := []Technology{}
+
+	for name, version := range detected {
+		technologies = append(technologies, Technology{
+			Name:       name,
+			Categories: e.signatures[name].categories,
+			Version:    version,
+		})
+	}
+
+	sort.Slice(technologies, func(i, j int) bool {
+		return technologies[i].Name < technologies[j].Name
+	})
+
+	return technologies
+
+}
+
+// match reports whether any pattern of the signature matches the page, along with the
+// most specific version found
+func (s *signature) match(page Page) (string, bool) {
+
+	var version string
+	var matched bool
+
+	check := func(p *pattern, value string) {
+
+		found, foundVersion := p.match(value)
+		if !found {
+			return
+		}
+
+		matched = true
+
+		if len(foundVersion) > len(version) {
+			version = foundVersion
+		}
+
+	}
+
+	for name, p := range s.headers {
+		if values, exists := page.Header[http.CanonicalHeaderKey(name)]; exists {
+			for _, value := range values {
+				check(p, value)
+			}
+		}
+	}
+
+	for name, p := range s.cookies {
+		if value, exists := page.Cookies[name]; exists {
+			check(p, value)
+		}
+	}
+
+	for name, p := range s.meta {
+		if value, exists := page.Meta[strings.ToLower(name)]; exists {
+			check(p, value)
+		}
+	}
+
+	for _, p := range s.scriptSrc {
+		for _, src := range page.ScriptSrc {
+			check(p, src)
+		}
+	}
+
+	for _, p := range s.html {
+		check(p, page.HTML)
+	}
+
+	return version, matched
+
+}
+
+// match reports whether the pattern matches the value and returns the version it extracts
+func (p *pattern) match(value string) (bool, string) {
+
+	groups := p.regex.FindStringSubmatch(value)
+	if groups == nil {
+		return false, ""
+	}
+
+	if p.version == "" {
+		return true, ""
+	}
+
+	version := groupReference.ReplaceAllStringFunc(p.version, func(reference string) string {
+
+		index, _ := strconv.Atoi(reference[1:])
+		if index < len(groups) {
+			return groups[index]
+		}
+
+		return ""
+
+	})
+
+	return true, strings.TrimSpace(version)
+
+}
+
+func compileSignature(raw rawSignature) (*signature, error) {
+
+	var err error
+
+	sig := &signature{
+		categories: raw.Categories,
+		implies:    raw.Implies,
+	}
+
+	if sig.headers, err = compilePatternMap(raw.Headers); err != nil {
+		return nil, err
+	}
+
+	if sig.cookies, err = compilePatternMap(raw.Cookies); err != nil {
+		return nil, err
+	}
+
+	if sig.meta, err = compilePatternMap(raw.Meta); err != nil {
+		return nil, err
+	}
+
+	if sig.scriptSrc, err = compilePatternList(raw.ScriptSrc); err != nil {
+		return nil, err
+	}
+
+	if sig.html, err = compilePatternList(raw.HTML); err != nil {
+		return nil, err
+	}
+
+	return sig, nil
+
+}
+
+func compilePatternMap(raw map[string]string) (map[string]*pattern, error) {
+
+	patterns := make(map[string]*pattern)
+
+	for name, value := range raw {
+
+		p, err := compilePattern(value)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns[name] = p
+
+	}
+
+	return patterns, nil
+
+}
+
+func compilePatternList(raw []string) ([]*pattern, error) {
+
+	var patterns []*pattern
+
+	for _, value := range raw {
+
+		p, err := compilePattern(value)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, p)
+
+	}
+
+	return patterns, nil
+
+}
+
+// compilePattern parses a pattern such as `^WordPress ?([\d.]+)?\;version:\1`
+func compilePattern(value string) (*pattern, error) {
+
+	parts := strings.Split(value, `\;`)
+
+	regex, err := regexp.Compile("(?i)" + parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pattern{regex: regex}
+
+	for _, tag := range parts[1:] {
+		if strings.HasPrefix(tag, "version:") {
+			p.version = strings.TrimPrefix(tag, "version:")
+		}
+	}
+
+	return p, nil
+
+}
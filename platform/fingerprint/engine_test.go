@@ -0,0 +1,52 @@
+package fingerprint
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+
+	engine, err := NewEngine([]byte(bundledSignatures))
+	if err != nil {
+		t.Fatalf("bundled signatures are invalid: %s", err.Error())
+	}
+
+	header := http.Header{}
+	header.Set("Server", "nginx/1.18.0")
+	header.Set("CF-RAY", "5f1a2b3c4d-MIA")
+
+	page := Page{
+		Header:    header,
+		Cookies:   map[string]string{"_ga": "GA1.2.3"},
+		Meta:      map[string]string{"generator": "WordPress 5.4.2"},
+		ScriptSrc: []string{"https://example.com/wp-includes/js/jquery/jquery-3.5.1.min.js"},
+		HTML:      "<html><body><p>Hello</p></body></html>",
+	}
+
+	want := []Technology{
+		{Name: "Cloudflare", Categories: []string{"CDN"}},
+		{Name: "Google Analytics", Categories: []string{"Analytics"}},
+		{Name: "Nginx", Categories: []string{"Web servers"}, Version: "1.18.0"},
+		{Name: "PHP", Categories: []string{"Programming languages"}},
+		{Name: "WordPress", Categories: []string{"CMS", "Blogs"}, Version: "5.4.2"},
+		{Name: "jQuery", Categories: []string{"JavaScript libraries"}, Version: "3.5.1"},
+	}
+
+	got := engine.Detect(page)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+}
+
+func TestNewEngineRejectsInvalidPatterns(t *testing.T) {
+
+	_, err := NewEngine([]byte(`{"Broken": {"cats": ["CMS"], "html": ["(unclosed"]}}`))
+	if err == nil {
+		t.Error("wanted an error, but didn't get one")
+	}
+
+}
@@ -0,0 +1,234 @@
+package fingerprint
+
+// bundledSignatures holds the signatures used when FINGERPRINT_SIGNATURES is not set. It
+// follows the Wappalyzer format so that rules can be copied from it with little effort
+const bundledSignatures = `{
+	"Akamai": {
+		"cats": ["CDN"],
+		"headers": {"X-Akamai-Transformed": "", "Server": "^AkamaiGHost"}
+	},
+	"Amazon CloudFront": {
+		"cats": ["CDN"],
+		"headers": {"X-Amz-Cf-Id": "", "Via": "\\(CloudFront\\)$"}
+	},
+	"Amazon S3": {
+		"cats": ["Web servers"],
+		"headers": {"Server": "^AmazonS3$"}
+	},
+	"Angular": {
+		"cats": ["JavaScript frameworks"],
+		"html": ["<[^>]+ ng-version=\"([\\d.]+)\"\\;version:\\1"],
+		"implies": ["TypeScript"]
+	},
+	"AngularJS": {
+		"cats": ["JavaScript frameworks"],
+		"scriptSrc": ["angular(?:\\.min)?\\.js", "/angular(?:js)?/([\\d.]+)/angular\\;version:\\1"],
+		"html": ["<[^>]+ ng-app"]
+	},
+	"Apache": {
+		"cats": ["Web servers"],
+		"headers": {"Server": "(?:Apache(?:$|/([\\d.]+)|[^/-])|(?:^|\\b)HTTPD)\\;version:\\1"}
+	},
+	"ASP.NET": {
+		"cats": ["Web frameworks"],
+		"headers": {"X-AspNet-Version": "(.+)\\;version:\\1", "X-Powered-By": "^ASP\\.NET"},
+		"cookies": {"ASP.NET_SessionId": "", "ASPSESSION": ""},
+		"html": ["<input[^>]+name=\"__VIEWSTATE"],
+		"implies": ["Microsoft IIS"]
+	},
+	"Bootstrap": {
+		"cats": ["UI frameworks"],
+		"scriptSrc": ["bootstrap(?:\\.bundle)?(?:\\.min)?\\.js", "/bootstrap/([\\d.]+)/\\;version:\\1"],
+		"html": ["<link[^>]+?href=[^>]+bootstrap(?:\\.min)?\\.css"]
+	},
+	"Caddy": {
+		"cats": ["Web servers"],
+		"headers": {"Server": "^Caddy$"}
+	},
+	"Cloudflare": {
+		"cats": ["CDN"],
+		"headers": {"Server": "^cloudflare$", "CF-RAY": "", "CF-Cache-Status": ""},
+		"cookies": {"__cfduid": "", "__cf_bm": ""}
+	},
+	"Django": {
+		"cats": ["Web frameworks"],
+		"cookies": {"csrftoken": "", "django_language": ""},
+		"html": ["<input[^>]+name=\"csrfmiddlewaretoken\""],
+		"implies": ["Python"]
+	},
+	"Drupal": {
+		"cats": ["CMS"],
+		"headers": {"X-Drupal-Cache": "", "X-Generator": "^Drupal(?:\\s([\\d.]+))?\\;version:\\1", "X-Drupal-Dynamic-Cache": ""},
+		"meta": {"generator": "^Drupal(?:\\s([\\d.]+))?\\;version:\\1"},
+		"scriptSrc": ["drupal\\.js", "/core/misc/drupal"],
+		"implies": ["PHP"]
+	},
+	"Express": {
+		"cats": ["Web frameworks"],
+		"headers": {"X-Powered-By": "^Express$"},
+		"implies": ["Node.js"]
+	},
+	"Fastly": {
+		"cats": ["CDN"],
+		"headers": {"X-Fastly-Request-ID": "", "Fastly-Debug-Digest": "", "Via": "varnish.*fastly"}
+	},
+	"Gatsby": {
+		"cats": ["Static site generators"],
+		"meta": {"generator": "^Gatsby(?: ([\\d.]+))?\\;version:\\1"},
+		"html": ["<div id=\"___gatsby\">"],
+		"implies": ["React"]
+	},
+	"Ghost": {
+		"cats": ["CMS"],
+		"headers": {"X-Ghost-Cache-Status": ""},
+		"meta": {"generator": "^Ghost(?:\\s([\\d.]+))?\\;version:\\1"},
+		"implies": ["Node.js"]
+	},
+	"Google Analytics": {
+		"cats": ["Analytics"],
+		"cookies": {"_ga": "", "__utma": ""},
+		"scriptSrc": ["google-analytics\\.com/(?:ga|urchin|analytics)\\.js", "googletagmanager\\.com/gtag/js"]
+	},
+	"Google Tag Manager": {
+		"cats": ["Tag managers"],
+		"scriptSrc": ["googletagmanager\\.com/gtm\\.js"],
+		"html": ["googletagmanager\\.com/ns\\.html[^>]+></iframe>"]
+	},
+	"Hotjar": {
+		"cats": ["Analytics"],
+		"scriptSrc": ["static\\.hotjar\\.com"],
+		"html": ["static\\.hotjar\\.com/c/hotjar-"]
+	},
+	"HubSpot": {
+		"cats": ["Marketing automation"],
+		"scriptSrc": ["js\\.hs-scripts\\.com", "js\\.hsforms\\.net"],
+		"cookies": {"hubspotutk": ""}
+	},
+	"Joomla": {
+		"cats": ["CMS"],
+		"headers": {"X-Content-Encoded-By": "Joomla! ([\\d.]+)\\;version:\\1"},
+		"meta": {"generator": "Joomla!(?: ([\\d.]+))?\\;version:\\1"},
+		"html": ["<div[^>]+id=\"wrapper_r\"", "<(?:script|link)[^>]+(?:templates|media)/system/"],
+		"implies": ["PHP"]
+	},
+	"jQuery": {
+		"cats": ["JavaScript libraries"],
+		"scriptSrc": ["jquery(?:-(\\d+\\.\\d+\\.\\d+))?(?:\\.min)?\\.js\\;version:\\1", "/jquery/(\\d+\\.\\d+\\.\\d+)/jquery\\;version:\\1"]
+	},
+	"Laravel": {
+		"cats": ["Web frameworks"],
+		"cookies": {"laravel_session": "", "XSRF-TOKEN": ""},
+		"implies": ["PHP"]
+	},
+	"LiteSpeed": {
+		"cats": ["Web servers"],
+		"headers": {"Server": "^LiteSpeed$"}
+	},
+	"Magento": {
+		"cats": ["Ecommerce"],
+		"cookies": {"frontend": "", "mage-cache-storage": ""},
+		"scriptSrc": ["js/mage", "skin/frontend/"],
+		"html": ["<script[^>]+data-requiremodule=\"(?:mage/|Magento_)"],
+		"implies": ["PHP"]
+	},
+	"Matomo": {
+		"cats": ["Analytics"],
+		"cookies": {"PIWIK_SESSID": ""},
+		"scriptSrc": ["piwik\\.js", "matomo\\.js"]
+	},
+	"Microsoft IIS": {
+		"cats": ["Web servers"],
+		"headers": {"Server": "^(?:Microsoft-)?IIS(?:/([\\d.]+))?\\;version:\\1"}
+	},
+	"Netlify": {
+		"cats": ["PaaS"],
+		"headers": {"Server": "^Netlify", "X-NF-Request-ID": ""}
+	},
+	"Next.js": {
+		"cats": ["Web frameworks"],
+		"headers": {"X-Powered-By": "^Next\\.js ?([0-9.]+)?\\;version:\\1"},
+		"html": ["<script[^>]+id=\"__NEXT_DATA__\""],
+		"implies": ["React", "Node.js"]
+	},
+	"Nginx": {
+		"cats": ["Web servers"],
+		"headers": {"Server": "nginx(?:/([\\d.]+))?\\;version:\\1"}
+	},
+	"Node.js": {
+		"cats": ["Programming languages"]
+	},
+	"Nuxt.js": {
+		"cats": ["Web frameworks"],
+		"html": ["<div [^>]*id=\"__nuxt\"", "<script [^>]*>window\\.__NUXT__"],
+		"implies": ["Vue.js", "Node.js"]
+	},
+	"PHP": {
+		"cats": ["Programming languages"],
+		"headers": {"X-Powered-By": "^php/?([\\d.]+)?\\;version:\\1", "Server": "php/?([\\d.]+)?\\;version:\\1"},
+		"cookies": {"PHPSESSID": ""}
+	},
+	"Python": {
+		"cats": ["Programming languages"],
+		"headers": {"Server": "(?:^|\\s)Python(?:/([\\d.]+))?\\;version:\\1"}
+	},
+	"React": {
+		"cats": ["JavaScript frameworks"],
+		"scriptSrc": ["react(?:-dom)?(?:\\.production)?(?:\\.min)?\\.js", "/react/([\\d.]+)/\\;version:\\1"],
+		"html": ["<[^>]+data-react", "<div[^>]+id=\"react-root\""]
+	},
+	"Ruby on Rails": {
+		"cats": ["Web frameworks"],
+		"headers": {"Server": "mod_(?:rails|rack)", "X-Powered-By": "mod_(?:rails|rack)"},
+		"cookies": {"_rails_session": ""},
+		"meta": {"csrf-param": "^authenticity_token$"},
+		"implies": ["Ruby"]
+	},
+	"Ruby": {
+		"cats": ["Programming languages"],
+		"headers": {"Server": "(?:Mongrel|WEBrick|Ruby)"}
+	},
+	"Segment": {
+		"cats": ["Analytics"],
+		"scriptSrc": ["cdn\\.segment\\.com/analytics\\.js"]
+	},
+	"Shopify": {
+		"cats": ["Ecommerce"],
+		"headers": {"X-ShopId": "", "X-Shopify-Stage": ""},
+		"cookies": {"_shopify_y": "", "_shopify_s": ""},
+		"scriptSrc": ["cdn\\.shopify\\.com"]
+	},
+	"Squarespace": {
+		"cats": ["CMS"],
+		"headers": {"X-ServedBy": "squarespace"},
+		"html": ["<!-- This is Squarespace\\. -->"]
+	},
+	"TypeScript": {
+		"cats": ["Programming languages"]
+	},
+	"Varnish": {
+		"cats": ["Caching"],
+		"headers": {"Via": "varnish(?: \\(Varnish/([\\d.]+)\\))?\\;version:\\1", "X-Varnish": ""}
+	},
+	"Vercel": {
+		"cats": ["PaaS"],
+		"headers": {"Server": "^Vercel$", "X-Vercel-Id": "", "X-Now-Trace": ""}
+	},
+	"Vue.js": {
+		"cats": ["JavaScript frameworks"],
+		"scriptSrc": ["vue[.-]([\\d.]*\\d)[^/]*\\.js\\;version:\\1", "/vue@([\\d.]+)/\\;version:\\1", "vue(?:\\.min)?\\.js"],
+		"html": ["<[^>]+\\sdata-v(?:ue)?-"]
+	},
+	"Wix": {
+		"cats": ["CMS"],
+		"headers": {"X-Wix-Request-Id": "", "X-Wix-Renderer-Server": ""},
+		"meta": {"generator": "Wix\\.com Website Builder"}
+	},
+	"WordPress": {
+		"cats": ["CMS", "Blogs"],
+		"headers": {"X-Pingback": "/xmlrpc\\.php$", "Link": "rel=\"https://api\\.w\\.org/\""},
+		"meta": {"generator": "^WordPress ?([\\d.]+)?\\;version:\\1"},
+		"scriptSrc": ["/wp-(?:content|includes)/", "wp-embed\\.min\\.js"],
+		"html": ["<link rel=[\"']stylesheet[\"'] [^>]+/wp-(?:content|includes)/"],
+		"implies": ["PHP"]
+	}
+}`
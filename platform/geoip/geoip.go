@@ -0,0 +1,430 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Classifications of the network a server is hosted on
+const (
+	CDN          = "cdn"
+	Hosting      = "hosting"
+	Unclassified = "unclassified"
+)
+
+// Location represents where a server is and which network it belongs to according to the
+// offline databases
+type Location struct {
+	ASN            uint64       `json:"asn,omitempty"`
+	ASOrganization string       `json:"as_organization,omitempty"`
+	City           string       `json:"city,omitempty"`
+	Country        string       `json:"country,omitempty"`
+	CountryCode    string       `json:"country_code,omitempty"`
+	Coordinates    *Coordinates `json:"coordinates,omitempty"`
+	Classification string       `json:"classification"`
+}
+
+// Coordinates represents the approximate position of an address
+type Coordinates struct {
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	AccuracyRadius uint64  `json:"accuracy_radius,omitempty"`
+}
+
+// Config represents where the MaxMind DB files are. Either may be empty, in which case the
+// fields it provides are left out
+type Config struct {
+	CityPath string
+	ASNPath  string
+}
+
+// ConfigFromEnv returns the paths found in the GEOIP_CITY_DATABASE and GEOIP_ASN_DATABASE variables
+func ConfigFromEnv() Config {
+
+	return Config{
+		CityPath: os.Getenv("GEOIP_CITY_DATABASE"),
+		ASNPath:  os.Getenv("GEOIP_ASN_DATABASE"),
+	}
+
+}
+
+// Databases represents the City and ASN databases used to locate addresses. A database file
+// replaced on disk is loaded again on the next lookup, so it can be updated without a restart
+type Databases struct {
+	config Config
+
+	mutex sync.Mutex
+	city  database
+	asn   database
+}
+
+// database represents a single file and the modification time of the version in memory
+type database struct {
+	path    string
+	modTime time.Time
+	size    int64
+	reader  *Reader
+}
+
+// NewDatabases returns the databases found at the paths of the given configuration
+func NewDatabases(config Config) *Databases {
+
+	return &Databases{
+		config: config,
+		city:   database{path: config.CityPath},
+		asn:    database{path: config.ASNPath},
+	}
+
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Databases
+)
+
+// Lookup locates the given address with the databases configured through the environment. It
+// returns nil when no database is configured or none knows the address
+func Lookup(address string) (*Location, error) {
+	return sharedDatabases().Lookup(address)
+}
+
+// Reload loads the databases configured through the environment again
+func Reload() error {
+	return sharedDatabases().Reload()
+}
+
+// sharedDatabases returns the databases at the paths configured through the environment, which
+// is read once on first use
+func sharedDatabases() *Databases {
+
+	sharedOnce.Do(func() {
+		shared = NewDatabases(ConfigFromEnv())
+	})
+
+	return shared
+
+}
+
+// Reload loads both database files again, whether they changed on disk or not. A file failing
+// to load keeps the version in memory, if any, without keeping the other one from loading, and
+// the errors of both are returned together
+func (d *Databases) Reload() error {
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var failures []string
+
+	for _, db := range []*database{&d.city, &d.asn} {
+
+		if db.path == "" {
+			continue
+		}
+
+		info, err := os.Stat(db.path)
+		if err == nil {
+			_, err = db.load(info)
+		}
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+
+	return nil
+
+}
+
+// Lookup locates the given address, or returns nil when none of the databases knows it
+func (d *Databases) Lookup(address string) (*Location, error) {
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", address)
+	}
+
+	d.mutex.Lock()
+
+	city, err := d.city.current()
+	if err != nil {
+		d.mutex.Unlock()
+		return nil, err
+	}
+
+	asn, err := d.asn.current()
+	if err != nil {
+		d.mutex.Unlock()
+		return nil, err
+	}
+
+	d.mutex.Unlock()
+
+	var location Location
+	var userType string
+
+	found := false
+
+	for _, reader := range []*Reader{city, asn} {
+
+		if reader == nil {
+			continue
+		}
+
+		record, err := reader.Lookup(ip)
+		if err != nil {
+			return nil, fmt.Errorf("%s lookup of %s failed: %v", reader.Metadata.DatabaseType, address, err)
+		}
+
+		fields, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		found = true
+
+		if t := userTypeOf(fields); t != "" {
+			userType = t
+		}
+
+		location.fill(fields)
+
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	location.Classification = classify(location.ASN, location.ASOrganization, userType)
+
+	return &location, nil
+
+}
+
+// current returns the reader of the file, loading it again when it changed since it was read,
+// or nil when no path is configured
+func (db *database) current() (*Reader, error) {
+
+	if db.path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.reader != nil && info.ModTime().Equal(db.modTime) && info.Size() == db.size {
+		return db.reader, nil
+	}
+
+	return db.load(info)
+
+}
+
+// load opens the file and replaces the reader in memory only once it opened
+func (db *database) load(info os.FileInfo) (*Reader, error) {
+
+	reader, err := Open(db.path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s failed: %v", db.path, err)
+	}
+
+	db.reader = reader
+	db.modTime = info.ModTime()
+	db.size = info.Size()
+
+	return reader, nil
+
+}
+
+// fill copies the fields of a City, ASN or Enterprise record that are not set yet
+func (l *Location) fill(fields map[string]interface{}) {
+
+	traits := mapField(fields, "traits")
+
+	if l.ASN == 0 {
+		l.ASN = uintField(fields, "autonomous_system_number")
+	}
+
+	if l.ASN == 0 {
+		l.ASN = uintField(traits, "autonomous_system_number")
+	}
+
+	if l.ASOrganization == "" {
+		l.ASOrganization = stringField(fields, "autonomous_system_organization")
+	}
+
+	if l.ASOrganization == "" {
+		l.ASOrganization = stringField(traits, "autonomous_system_organization")
+	}
+
+	if l.City == "" {
+		l.City = englishName(mapField(fields, "city"))
+	}
+
+	country := mapField(fields, "country")
+	if country == nil {
+		country = mapField(fields, "registered_country")
+	}
+
+	if l.Country == "" {
+		l.Country = englishName(country)
+	}
+
+	if l.CountryCode == "" {
+		l.CountryCode = stringField(country, "iso_code")
+	}
+
+	position := mapField(fields, "location")
+
+	if l.Coordinates == nil && position != nil {
+
+		latitude, hasLatitude := position["latitude"].(float64)
+		longitude, hasLongitude := position["longitude"].(float64)
+
+		if hasLatitude && hasLongitude {
+			l.Coordinates = &Coordinates{
+				Latitude:       latitude,
+				Longitude:      longitude,
+				AccuracyRadius: uintField(position, "accuracy_radius"),
+			}
+		}
+
+	}
+
+}
+
+// userTypeOf returns how the network is used according to Enterprise and Insights databases,
+// which is empty in the GeoLite ones
+func userTypeOf(fields map[string]interface{}) string {
+	return stringField(mapField(fields, "traits"), "user_type")
+}
+
+// cdnNetworks holds the autonomous systems of the main content delivery networks
+var cdnNetworks = map[uint64]bool{
+	13335:  true, // Cloudflare
+	209242: true, // Cloudflare
+	20940:  true, // Akamai
+	16625:  true, // Akamai
+	21342:  true, // Akamai
+	32787:  true, // Akamai (Prolexic)
+	54113:  true, // Fastly
+	15133:  true, // Edgecast
+	22822:  true, // Edgio (Limelight)
+	60068:  true, // CDN77
+	19551:  true, // Imperva Incapsula
+	30148:  true, // Sucuri
+	212238: true, // Datacamp (CDN77)
+	133199: true, // BunnyCDN
+}
+
+// hostingNetworks holds the autonomous systems of the main cloud and hosting providers
+var hostingNetworks = map[uint64]bool{
+	16509:  true, // Amazon
+	14618:  true, // Amazon
+	15169:  true, // Google
+	396982: true, // Google Cloud
+	8075:   true, // Microsoft
+	14061:  true, // DigitalOcean
+	16276:  true, // OVH
+	24940:  true, // Hetzner
+	63949:  true, // Linode
+	20473:  true, // Vultr
+	45102:  true, // Alibaba Cloud
+	132203: true, // Tencent Cloud
+	31898:  true, // Oracle Cloud
+	36351:  true, // IBM Cloud
+	8560:   true, // IONOS
+	51167:  true, // Contabo
+	12876:  true, // Scaleway
+	46606:  true, // Unified Layer
+	26496:  true, // GoDaddy
+}
+
+// classify tells whether a network belongs to a content delivery network, a hosting provider
+// or neither, from the user type of Enterprise databases, the autonomous system number and
+// finally the name of its organization
+func classify(asn uint64, organization, userType string) string {
+
+	switch userType {
+	case "content_delivery_network":
+		return CDN
+	case "hosting":
+		return Hosting
+	}
+
+	if cdnNetworks[asn] {
+		return CDN
+	}
+
+	if hostingNetworks[asn] {
+		return Hosting
+	}
+
+	name := strings.ToLower(organization)
+
+	for _, keyword := range []string{"cdn", "content delivery", "edge network"} {
+		if strings.Contains(name, keyword) {
+			return CDN
+		}
+	}
+
+	for _, keyword := range []string{"hosting", "cloud", "data center", "datacenter", "server", "vps", "colocation"} {
+		if strings.Contains(name, keyword) {
+			return Hosting
+		}
+	}
+
+	return Unclassified
+
+}
+
+func mapField(fields map[string]interface{}, name string) map[string]interface{} {
+
+	value, _ := fields[name].(map[string]interface{})
+
+	return value
+
+}
+
+func stringField(fields map[string]interface{}, name string) string {
+
+	value, _ := fields[name].(string)
+
+	return value
+
+}
+
+func uintField(fields map[string]interface{}, name string) uint64 {
+
+	switch value := fields[name].(type) {
+	case uint64:
+		return value
+	case int64:
+		if value > 0 {
+			return uint64(value)
+		}
+	case *big.Int:
+		if value.IsUint64() {
+			return value.Uint64()
+		}
+	}
+
+	return 0
+
+}
+
+// englishName returns the English name of a city or a country record
+func englishName(fields map[string]interface{}) string {
+	return stringField(mapField(fields, "names"), "en")
+}
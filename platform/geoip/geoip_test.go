@@ -0,0 +1,457 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writer builds small MaxMind DB files with a 24 bit record size
+type writer struct {
+	ipVersion int
+	nodes     [][2]record
+	data      bytes.Buffer
+	strings   map[string]int
+}
+
+// Kinds of the records of the tree built by the writer, which point to nothing, to a node or
+// to data once the node count is known
+const (
+	emptyRecord = iota
+	nodeRecord
+	dataRecord
+)
+
+type record struct {
+	kind  int
+	value int
+}
+
+func newWriter(ipVersion int) *writer {
+	return &writer{ipVersion: ipVersion, nodes: [][2]record{{}}, strings: make(map[string]int)}
+}
+
+// insert stores the given value for a network written in CIDR notation
+func (w *writer) insert(t *testing.T, network string, value map[string]interface{}) {
+
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ip := ipNet.IP
+	ones, _ := ipNet.Mask.Size()
+
+	if w.ipVersion == 6 && ip.To4() != nil {
+		ip, ones = ip.To16(), ones+96
+		copy(ip[:12], make([]byte, 12))
+	}
+
+	offset := w.data.Len()
+	w.encode(value)
+
+	node := 0
+
+	for i := 0; i < ones; i++ {
+
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+
+		if i == ones-1 {
+			w.nodes[node][bit] = record{dataRecord, offset}
+			break
+		}
+
+		if w.nodes[node][bit].kind != nodeRecord {
+			w.nodes = append(w.nodes, [2]record{})
+			w.nodes[node][bit] = record{nodeRecord, len(w.nodes) - 1}
+		}
+
+		node = w.nodes[node][bit].value
+
+	}
+
+}
+
+func (w *writer) bytes(t *testing.T, databaseType string) []byte {
+
+	var file bytes.Buffer
+
+	nodeCount := len(w.nodes)
+
+	for _, node := range w.nodes {
+		for _, r := range node {
+
+			value := nodeCount
+
+			switch r.kind {
+			case nodeRecord:
+				value = r.value
+			case dataRecord:
+				value = nodeCount + dataSectionSeparator + r.value
+			}
+
+			file.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+
+		}
+	}
+
+	file.Write(make([]byte, dataSectionSeparator))
+	file.Write(w.data.Bytes())
+	file.Write(metadataMarker)
+
+	metadata := &writer{strings: make(map[string]int)}
+	metadata.encode(map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(w.ipVersion),
+		"database_type":               databaseType,
+		"binary_format_major_version": uint16(2),
+		"build_epoch":                 uint64(1700000000),
+		"languages":                   []interface{}{"en"},
+	})
+
+	file.Write(metadata.data.Bytes())
+
+	return file.Bytes()
+
+}
+
+func (w *writer) header(fieldType, size int) {
+
+	control := 0
+	var extended []byte
+
+	if fieldType > 7 {
+		extended = []byte{byte(fieldType - 7)}
+	} else {
+		control = fieldType << 5
+	}
+
+	switch {
+	case size < 29:
+		w.data.WriteByte(byte(control | size))
+		w.data.Write(extended)
+	case size < 285:
+		w.data.WriteByte(byte(control | 29))
+		w.data.Write(extended)
+		w.data.WriteByte(byte(size - 29))
+	default:
+		w.data.WriteByte(byte(control | 30))
+		w.data.Write(extended)
+		w.data.Write([]byte{byte((size - 285) >> 8), byte(size - 285)})
+	}
+
+}
+
+// encode writes a value, replacing strings already written by pointers to them
+func (w *writer) encode(value interface{}) {
+
+	switch v := value.(type) {
+	case string:
+		if offset, exists := w.strings[v]; exists && offset < 2048 {
+			w.data.Write([]byte{byte(typePointer<<5 | offset>>8), byte(offset)})
+			return
+		}
+		w.strings[v] = w.data.Len()
+		w.header(typeString, len(v))
+		w.data.WriteString(v)
+	case float64:
+		w.header(typeDouble, 8)
+		binary.Write(&w.data, binary.BigEndian, math.Float64bits(v))
+	case uint16:
+		w.header(typeUint16, 2)
+		binary.Write(&w.data, binary.BigEndian, v)
+	case uint32:
+		w.header(typeUint32, 4)
+		binary.Write(&w.data, binary.BigEndian, v)
+	case uint64:
+		w.header(typeUint64, 8)
+		binary.Write(&w.data, binary.BigEndian, v)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		w.header(typeBool, size)
+	case []interface{}:
+		w.header(typeArray, len(v))
+		for _, item := range v {
+			w.encode(item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.header(typeMap, len(v))
+		for _, key := range keys {
+			w.encode(key)
+			w.encode(v[key])
+		}
+	}
+
+}
+
+func writeDatabase(t *testing.T, dir, name string, content []byte) string {
+
+	path := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+
+}
+
+func cityDatabase(t *testing.T) []byte {
+
+	w := newWriter(6)
+
+	country := map[string]interface{}{"iso_code": "US", "names": map[string]interface{}{"en": "United States", "es": "Estados Unidos"}}
+
+	w.insert(t, "104.16.0.0/13", map[string]interface{}{
+		"city":     map[string]interface{}{"names": map[string]interface{}{"en": "San Francisco"}},
+		"country":  country,
+		"location": map[string]interface{}{"latitude": 37.7697, "longitude": -122.3933, "accuracy_radius": uint16(1000)},
+	})
+	w.insert(t, "2606:4700::/32", map[string]interface{}{
+		"registered_country": country,
+	})
+	w.insert(t, "203.0.113.0/24", map[string]interface{}{
+		"country": map[string]interface{}{"iso_code": "AU", "names": map[string]interface{}{"en": "Australia"}},
+		"traits":  map[string]interface{}{"user_type": "hosting", "is_anycast": true},
+	})
+
+	return w.bytes(t, "GeoLite2-City")
+
+}
+
+func asnDatabase(t *testing.T, organization string) []byte {
+
+	w := newWriter(6)
+
+	w.insert(t, "104.16.0.0/12", map[string]interface{}{"autonomous_system_number": uint32(13335), "autonomous_system_organization": organization})
+	w.insert(t, "2606:4700::/32", map[string]interface{}{"autonomous_system_number": uint32(13335), "autonomous_system_organization": organization})
+	w.insert(t, "198.51.100.0/24", map[string]interface{}{"autonomous_system_number": uint32(64500), "autonomous_system_organization": "Example Cloud Hosting"})
+
+	return w.bytes(t, "GeoLite2-ASN")
+
+}
+
+func TestLookup(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("GEOIP_CITY_DATABASE", writeDatabase(t, dir, "city.mmdb", cityDatabase(t)))
+	defer os.Unsetenv("GEOIP_CITY_DATABASE")
+
+	os.Setenv("GEOIP_ASN_DATABASE", writeDatabase(t, dir, "asn.mmdb", asnDatabase(t, "CLOUDFLARENET")))
+	defer os.Unsetenv("GEOIP_ASN_DATABASE")
+
+	tests := []struct {
+		address string
+		want    *Location
+	}{
+		{"104.16.132.229", &Location{ASN: 13335, ASOrganization: "CLOUDFLARENET", City: "San Francisco", Country: "United States", CountryCode: "US",
+			Coordinates: &Coordinates{Latitude: 37.7697, Longitude: -122.3933, AccuracyRadius: 1000}, Classification: CDN}},
+		{"104.24.0.1", &Location{ASN: 13335, ASOrganization: "CLOUDFLARENET", Classification: CDN}},
+		{"2606:4700::6810:84e5", &Location{ASN: 13335, ASOrganization: "CLOUDFLARENET", Country: "United States", CountryCode: "US", Classification: CDN}},
+		{"203.0.113.10", &Location{Country: "Australia", CountryCode: "AU", Classification: Hosting}},
+		{"198.51.100.7", &Location{ASN: 64500, ASOrganization: "Example Cloud Hosting", Classification: Hosting}},
+		{"192.0.2.1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+
+			got, err := Lookup(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (got == nil) != (tt.want == nil) || (got != nil && !equalLocations(*got, *tt.want)) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+		})
+	}
+
+}
+
+func TestLookupReloadsReplacedDatabase(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeDatabase(t, dir, "asn.mmdb", asnDatabase(t, "CLOUDFLARENET"))
+	databases := NewDatabases(Config{ASNPath: path})
+
+	location, err := databases.Lookup("104.16.132.229")
+	if err != nil || location == nil || location.ASOrganization != "CLOUDFLARENET" {
+		t.Fatalf("got %+v and %v before the update", location, err)
+	}
+
+	writeDatabase(t, dir, "asn.mmdb", asnDatabase(t, "Cloudflare, Inc."))
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	location, err = databases.Lookup("104.16.132.229")
+	if err != nil || location == nil || location.ASOrganization != "Cloudflare, Inc." {
+		t.Errorf("got %+v and %v, want the organization of the updated database", location, err)
+	}
+
+}
+
+func TestReloadKeepsDatabaseFailingToOpen(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeDatabase(t, dir, "asn.mmdb", asnDatabase(t, "CLOUDFLARENET"))
+	databases := NewDatabases(Config{ASNPath: path})
+
+	if err := databases.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeDatabase(t, dir, "asn.mmdb", []byte("half written"))
+
+	if err := databases.Reload(); err == nil {
+		t.Error("got no error for a corrupt database, want one")
+	}
+
+	if reader := databases.asn.reader; reader == nil || reader.Metadata.DatabaseType != "GeoLite2-ASN" {
+		t.Errorf("got %+v, want the previous database kept", reader)
+	}
+
+	writeDatabase(t, dir, "asn.mmdb", asnDatabase(t, "CLOUDFLARENET"))
+
+	databases = NewDatabases(Config{CityPath: filepath.Join(dir, "missing.mmdb"), ASNPath: path})
+
+	if err := databases.Reload(); err == nil {
+		t.Error("got no error for a missing city database, want one")
+	}
+
+	if reader := databases.asn.reader; reader == nil {
+		t.Error("got no ASN database, want it loaded despite the missing city database")
+	}
+
+}
+
+func TestLookupWithoutDatabases(t *testing.T) {
+
+	location, err := NewDatabases(Config{}).Lookup("104.16.132.229")
+	if location != nil || err != nil {
+		t.Errorf("got %+v and %v, want neither", location, err)
+	}
+
+	_, err = NewDatabases(Config{CityPath: "missing.mmdb"}).Lookup("104.16.132.229")
+	if err == nil {
+		t.Error("got no error for a missing database, want one")
+	}
+
+	_, err = NewDatabases(Config{}).Lookup("not an address")
+	if err == nil {
+		t.Error("got no error for an invalid address, want one")
+	}
+
+}
+
+func TestNewReaderRejectsInvalidFiles(t *testing.T) {
+
+	if _, err := NewReader([]byte("not a database")); err == nil {
+		t.Error("got no error without metadata, want one")
+	}
+
+	truncated := asnDatabase(t, "CLOUDFLARENET")
+	truncated = truncated[bytes.LastIndex(truncated, metadataMarker)-dataSectionSeparator:]
+
+	if _, err := NewReader(truncated); err == nil {
+		t.Error("got no error for a search tree larger than the file, want one")
+	}
+
+}
+
+func TestDecoderRejectsOversizedContainers(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"array", []byte{0x1F, typeArray - 7, 0xFF, 0xFF, 0xFF}},
+		{"map", []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{"pointer loop", []byte{0x20, 0x00}},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := (&decoder{data: tt.data}).decode(0, 0); err == nil {
+			t.Errorf("got no error for an oversized %s, want one", tt.name)
+		}
+	}
+
+}
+
+// TestNewReaderSurvivesCorruptFiles feeds randomly corrupted databases to the reader, which
+// must reject them or answer lookups without panicking
+func TestNewReaderSurvivesCorruptFiles(t *testing.T) {
+
+	random := rand.New(rand.NewSource(1))
+
+	originals := [][]byte{cityDatabase(t), asnDatabase(t, "CLOUDFLARENET")}
+	addresses := []net.IP{net.ParseIP("104.16.132.229"), net.ParseIP("203.0.113.10"), net.ParseIP("2606:4700::6810:84e5")}
+
+	for i := 0; i < 5000; i++ {
+
+		file := append([]byte{}, originals[i%len(originals)]...)
+
+		for j := random.Intn(8); j >= 0; j-- {
+			file[random.Intn(len(file))] = byte(random.Intn(256))
+		}
+
+		reader, err := NewReader(file)
+		if err != nil {
+			continue
+		}
+
+		for _, address := range addresses {
+			reader.Lookup(address)
+		}
+
+	}
+
+}
+
+func equalLocations(a, b Location) bool {
+
+	if (a.Coordinates == nil) != (b.Coordinates == nil) || (a.Coordinates != nil && *a.Coordinates != *b.Coordinates) {
+		return false
+	}
+
+	a.Coordinates, b.Coordinates = nil, nil
+
+	return a == b
+
+}
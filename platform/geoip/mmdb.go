@@ -0,0 +1,431 @@
+package geoip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+)
+
+// metadataMarker precedes the metadata map at the end of a MaxMind DB file
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator is the size of the zeroed bytes between the search tree and the data section
+const dataSectionSeparator = 16
+
+// Types of the fields of the data section
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// Metadata represents the description a MaxMind DB file gives of itself
+type Metadata struct {
+	DatabaseType string
+	IPVersion    int
+	RecordSize   int
+	NodeCount    int
+	BuildEpoch   uint64
+}
+
+// Reader represents a MaxMind DB (MMDB) file loaded in memory, such as the GeoLite2 City and
+// ASN databases
+type Reader struct {
+	Metadata Metadata
+
+	buffer      []byte
+	dataSection []byte
+	ipv4Start   int
+}
+
+// Open loads the MaxMind DB file at the given path
+func Open(path string) (*Reader, error) {
+
+	buffer, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReader(buffer)
+
+}
+
+// NewReader returns a reader of the given MaxMind DB file contents
+func NewReader(buffer []byte) (*Reader, error) {
+
+	start := bytes.LastIndex(buffer, metadataMarker)
+	if start == -1 {
+		return nil, errors.New("invalid MaxMind DB file, metadata not found")
+	}
+
+	metadataSection := buffer[start+len(metadataMarker):]
+
+	decoded, _, err := (&decoder{data: metadataSection}).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: %v", err)
+	}
+
+	fields, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid MaxMind DB metadata, not a map")
+	}
+
+	metadata := Metadata{
+		DatabaseType: stringField(fields, "database_type"),
+		IPVersion:    int(uintField(fields, "ip_version")),
+		RecordSize:   int(uintField(fields, "record_size")),
+		NodeCount:    int(uintField(fields, "node_count")),
+		BuildEpoch:   uintField(fields, "build_epoch"),
+	}
+
+	if metadata.RecordSize != 24 && metadata.RecordSize != 28 && metadata.RecordSize != 32 {
+		return nil, fmt.Errorf("unsupported MaxMind DB record size %d", metadata.RecordSize)
+	}
+
+	if metadata.IPVersion != 4 && metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("unsupported MaxMind DB IP version %d", metadata.IPVersion)
+	}
+
+	if metadata.NodeCount < 0 || metadata.NodeCount > start {
+		return nil, fmt.Errorf("invalid MaxMind DB node count %d", metadata.NodeCount)
+	}
+
+	treeSize := metadata.NodeCount * metadata.RecordSize / 4
+
+	if treeSize+dataSectionSeparator > start {
+		return nil, errors.New("invalid MaxMind DB file, search tree larger than the file")
+	}
+
+	reader := &Reader{
+		Metadata:    metadata,
+		buffer:      buffer,
+		dataSection: buffer[treeSize+dataSectionSeparator : start],
+	}
+
+	if metadata.IPVersion == 6 {
+
+		// IPv4 addresses live under ::/96 in databases covering both versions
+		for i := 0; i < 96 && reader.ipv4Start < metadata.NodeCount; i++ {
+			reader.ipv4Start = reader.record(reader.ipv4Start, 0)
+		}
+
+	}
+
+	return reader, nil
+
+}
+
+// Lookup returns the data stored for the network the given address belongs to, or nil when the
+// database has none
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+
+	node := 0
+	address := ip.To4()
+
+	if address != nil {
+		node = r.ipv4Start
+	} else if r.Metadata.IPVersion == 4 {
+		return nil, errors.New("IPv6 address looked up in an IPv4 only database")
+	} else if address = ip.To16(); address == nil {
+		return nil, fmt.Errorf("invalid IP address %q", ip.String())
+	}
+
+	for i := 0; i < len(address)*8 && node < r.Metadata.NodeCount; i++ {
+		bit := (address[i/8] >> (7 - uint(i%8))) & 1
+		node = r.record(node, int(bit))
+	}
+
+	if node == r.Metadata.NodeCount {
+		return nil, nil
+	}
+
+	if node < r.Metadata.NodeCount {
+		return nil, errors.New("invalid MaxMind DB search tree, address deeper than the tree")
+	}
+
+	offset := node - r.Metadata.NodeCount - dataSectionSeparator
+
+	if offset < 0 || offset >= len(r.dataSection) {
+		return nil, errors.New("invalid MaxMind DB search tree, record points outside of the data section")
+	}
+
+	value, _, err := (&decoder{data: r.dataSection}).decode(offset, 0)
+
+	return value, err
+
+}
+
+// record returns the left (0) or right (1) record of a node of the search tree
+func (r *Reader) record(node, side int) int {
+
+	b := r.buffer[node*r.Metadata.RecordSize/4:]
+
+	switch r.Metadata.RecordSize {
+	case 24:
+		b = b[side*3:]
+		return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	case 28:
+		if side == 0 {
+			return int(b[3]&0xF0)<<20 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+		}
+		return int(b[3]&0x0F)<<24 | int(b[4])<<16 | int(b[5])<<8 | int(b[6])
+	default:
+		b = b[side*4:]
+		return int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	}
+
+}
+
+// maxDepth bounds the nesting of maps, arrays and pointers followed by the decoder
+const maxDepth = 32
+
+// maxFields bounds how many fields make up a single record, since pointers let a few bytes
+// refer to the same large map many times
+const maxFields = 1 << 16
+
+// decoder reads the fields of a data section
+type decoder struct {
+	data   []byte
+	fields int
+}
+
+// decode returns the field at the given offset and the offset of the field following it
+func (d *decoder) decode(offset, depth int) (interface{}, int, error) {
+
+	if depth > maxDepth {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+
+	if d.fields++; d.fields > maxFields {
+		return nil, 0, errors.New("too many fields in a single record")
+	}
+
+	fieldType, size, offset, err := d.header(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if fieldType == typePointer {
+
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		value, _, err := d.decode(target, depth+1)
+
+		return value, next, err
+
+	}
+
+	switch fieldType {
+	case typeMap:
+		return d.decodeMap(size, offset, depth)
+	case typeArray:
+		return d.decodeArray(size, offset, depth)
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > len(d.data) {
+		return nil, 0, errors.New("field larger than the data section")
+	}
+
+	field := d.data[offset : offset+size]
+	next := offset + size
+
+	switch fieldType {
+	case typeString:
+		return string(field), next, nil
+	case typeBytes:
+		return append([]byte{}, field...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size %d", size)
+		}
+		return math.Float64frombits(uint64(unsigned(field))), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(uint32(unsigned(field)))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid unsigned integer size %d", size)
+		}
+		return unsigned(field), next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid signed integer size %d", size)
+		}
+		return int64(int32(uint32(unsigned(field)))), next, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(field), next, nil
+	}
+
+	return nil, 0, fmt.Errorf("unexpected field type %d", fieldType)
+
+}
+
+// header returns the type and the size of the field at the given offset, and where its payload starts
+func (d *decoder) header(offset int) (int, int, int, error) {
+
+	if offset >= len(d.data) {
+		return 0, 0, 0, errors.New("field outside of the data section")
+	}
+
+	control := d.data[offset]
+	offset++
+
+	fieldType := int(control >> 5)
+
+	if fieldType == typeExtended {
+
+		if offset >= len(d.data) {
+			return 0, 0, 0, errors.New("extended type outside of the data section")
+		}
+
+		fieldType = 7 + int(d.data[offset])
+		offset++
+
+	}
+
+	size := int(control & 0x1F)
+
+	if fieldType == typePointer || size < 29 {
+		return fieldType, size, offset, nil
+	}
+
+	extra := size - 28
+
+	if offset+extra > len(d.data) {
+		return 0, 0, 0, errors.New("field size outside of the data section")
+	}
+
+	value := int(unsigned(d.data[offset : offset+extra]))
+
+	switch extra {
+	case 1:
+		size = 29 + value
+	case 2:
+		size = 285 + value
+	default:
+		size = 65821 + value
+	}
+
+	return fieldType, size, offset + extra, nil
+
+}
+
+// pointer returns the offset a pointer field refers to and the offset following it. The size
+// bits of the control byte tell how many bytes follow and hold the high bits of the value
+func (d *decoder) pointer(size, offset int) (int, int, error) {
+
+	length := (size>>3)&0x3 + 1
+
+	if offset+length > len(d.data) {
+		return 0, 0, errors.New("pointer outside of the data section")
+	}
+
+	value := int(unsigned(d.data[offset : offset+length]))
+
+	switch length {
+	case 1:
+		value |= (size & 0x7) << 8
+	case 2:
+		value = (value | (size&0x7)<<16) + 2048
+	case 3:
+		value = (value | (size&0x7)<<24) + 526336
+	}
+
+	return value, offset + length, nil
+
+}
+
+// decodeMap returns the map of the given size starting at offset. Each entry takes at least two
+// bytes, which bounds the size a file may declare before anything is allocated
+func (d *decoder) decodeMap(size, offset, depth int) (interface{}, int, error) {
+
+	if size > (len(d.data)-offset)/2 {
+		return nil, 0, errors.New("map larger than the data section")
+	}
+
+	fields := make(map[string]interface{}, size)
+
+	for i := 0; i < size; i++ {
+
+		key, next, err := d.decode(offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		name, ok := key.(string)
+		if !ok {
+			return nil, 0, errors.New("map key is not a string")
+		}
+
+		fields[name], offset, err = d.decode(next, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+	}
+
+	return fields, offset, nil
+
+}
+
+// decodeArray returns the array of the given size starting at offset. Each value takes at least
+// one byte, which bounds the size a file may declare before anything is allocated
+func (d *decoder) decodeArray(size, offset, depth int) (interface{}, int, error) {
+
+	if size > len(d.data)-offset {
+		return nil, 0, errors.New("array larger than the data section")
+	}
+
+	values := make([]interface{}, size)
+
+	for i := range values {
+
+		var err error
+
+		values[i], offset, err = d.decode(offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+	}
+
+	return values, offset, nil
+
+}
+
+// unsigned returns the big endian value of up to 8 bytes
+func unsigned(b []byte) uint64 {
+
+	var value uint64
+
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+
+	return value
+
+}
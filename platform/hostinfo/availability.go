@@ -0,0 +1,48 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"domain-info-api/platform/availability"
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// insertAvailabilityCheck records an availability check of a given host id on the "availability_check" table
+func (c *Connection) insertAvailabilityCheck(hostID int, check *availability.Check, methodName string) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	if check == nil {
+		return nil
+	}
+
+	stmt, err := c.DB.Prepare(`
+	INSERT INTO
+		availability_check (dns_resolved, addresses, port_80_open, port_443_open, url, status_code, latency_ms, tls_handshake_ms, error, is_down, checked_at, host_id)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(check.DNSResolved, strings.Join(check.Addresses, ","), check.Port80Open, check.Port443Open, check.URL,
+		check.StatusCode, check.LatencyMS, check.TLSHandshakeMS, check.Error, check.IsDown, check.CheckedAt, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
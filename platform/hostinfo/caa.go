@@ -0,0 +1,64 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"domain-info-api/platform/caa"
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// serverCertificates returns the certificates of the servers the CAA policy is checked against
+func serverCertificates(servers []Server) []caa.Certificate {
+
+	var certificates []caa.Certificate
+
+	for _, server := range servers {
+		if server.Certificate != nil {
+			certificates = append(certificates, caa.Certificate{
+				Server: server.Address,
+				Issuer: server.Certificate.Issuer,
+				Names:  server.Certificate.Names,
+			})
+		}
+	}
+
+	return certificates
+
+}
+
+// refreshCAA checks the certificates of the servers of a host analyzed again against its CAA
+// policy, stores the report and returns it
+func (c *Connection) refreshCAA(hostID int, domainName string, servers []Server) (caa.Report, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	report := caa.Check(domainName, serverCertificates(servers))
+
+	encoded, customErr := encodeJSONB(report, "CheckDomainExists")
+	if customErr != nil {
+		return caa.Report{}, customErr
+	}
+
+	stmt, err := c.DB.Prepare(`UPDATE host SET caa = $1 WHERE host.id = $2`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return caa.Report{}, customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(encoded, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return caa.Report{}, customErr
+	}
+
+	return report, nil
+
+}
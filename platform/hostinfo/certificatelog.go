@@ -0,0 +1,270 @@
+package hostinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"domain-info-api/platform/caa"
+	"domain-info-api/platform/ctlog"
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// UnexpectedCertificateIssuer is the kind of change event recorded when a certificate logged
+// for a domain comes from an authority it was not seen using before
+const UnexpectedCertificateIssuer = "unexpected_certificate_issuer"
+
+// certificateLogJob represents a host whose logged certificates are waiting to be refreshed
+type certificateLogJob struct {
+	hostID     int
+	domainName string
+	servers    []Server
+	policy     caa.Policy
+}
+
+// certificateLogQueueSize is how many hosts may wait for their logged certificates to be refreshed
+const certificateLogQueueSize = 100
+
+// queueCertificateLog schedules the refresh of the certificates logged for a host on a single
+// worker, started on first use, so that the slow aggregator is neither waited for by requests
+// nor queried for several domains at once. Nothing is scheduled when the connection has no
+// certificate source, and the host is skipped when too many are already waiting
+func (c *Connection) queueCertificateLog(hostID int, domainName string, servers []Server, policy caa.Policy) {
+
+	if c.Certificates == nil {
+		return
+	}
+
+	c.certificateLogWorker.Do(func() {
+
+		c.certificateLogJobs = make(chan certificateLogJob, certificateLogQueueSize)
+
+		go func() {
+			for job := range c.certificateLogJobs {
+
+				events, customErr := c.refreshCertificateLog(job.hostID, job.domainName, job.servers, job.policy)
+				if customErr != nil {
+					continue
+				}
+
+				c.insertChangeEvents(events, job.hostID)
+
+			}
+		}()
+
+	})
+
+	select {
+	case c.certificateLogJobs <- certificateLogJob{hostID: hostID, domainName: domainName, servers: servers, policy: policy}:
+	default:
+		errMessage := fmt.Sprintf("Certificate log queue is full, skipping %s", domainName)
+		customErr := wrappedErr.New(http.StatusServiceUnavailable, "queueCertificateLog", errMessage)
+		log.Println(customErr)
+	}
+
+}
+
+// unexpectedCertificates returns a change event for each new certificate whose issuing
+// organization is neither among the expected ones nor explicitly authorized by the CAA policy
+func unexpectedCertificates(newEntries []ctlog.Entry, expected map[string]bool, policy caa.Policy) []ChangeEvent {
+
+	var events []ChangeEvent
+
+	for _, entry := range newEntries {
+
+		organization := ctlog.Organization(entry.Issuer)
+
+		if expected[organization] || policy.Authorizes(entry.Issuer) {
+			continue
+		}
+
+		detail := fmt.Sprintf("issued by %s on %s, serial %s", organization, entry.NotBefore.Format("2006-01-02"), entry.SerialNumber)
+		events = append(events, newChangeEvent(UnexpectedCertificateIssuer, entry.CommonName, detail))
+
+	}
+
+	sortChangeEvents(events)
+
+	return events
+
+}
+
+// refreshCertificateLog stores the certificates logged since the previous analysis of a host and
+// returns the ones from unexpected issuers as change events. Issuers of certificates logged
+// before or presented by the servers are expected. Nothing is reported on the first query, which
+// only records what was already logged
+func (c *Connection) refreshCertificateLog(hostID int, domainName string, servers []Server, policy caa.Policy) ([]ChangeEvent, *wrappedErr.Error) {
+
+	entries, err := c.Certificates.Certificates(domainName)
+	if err != nil {
+		customErr := wrappedErr.New(http.StatusServiceUnavailable, "refreshCertificateLog", fmt.Sprintf("Certificate log query failed: %s", err.Error()))
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	known, customErr := c.getCertificateLog(hostID, "refreshCertificateLog")
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	seen := make(map[int64]bool)
+	expected := make(map[string]bool)
+
+	for _, entry := range known {
+		seen[entry.LogID] = true
+		expected[ctlog.Organization(entry.Issuer)] = true
+	}
+
+	for _, server := range servers {
+		if server.Certificate != nil {
+			expected[ctlog.Organization(server.Certificate.Issuer)] = true
+		}
+	}
+
+	var newEntries []ctlog.Entry
+
+	for _, entry := range entries {
+		if !seen[entry.LogID] {
+			newEntries = append(newEntries, entry)
+		}
+	}
+
+	customErr = c.insertCertificates(hostID, newEntries, time.Now(), "refreshCertificateLog")
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	if len(known) == 0 {
+		return nil, nil
+	}
+
+	return unexpectedCertificates(newEntries, expected, policy), nil
+
+}
+
+// insertCertificates records the given log entries on the "certificate_log" table for a given host
+// id, all of them or none
+func (c *Connection) insertCertificates(hostID int, entries []ctlog.Entry, seenAt time.Time, methodName string) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		errMessage := fmt.Sprintf("Transaction start failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO
+		certificate_log (log_id, serial_number, issuer, common_name, names, not_before, not_after, logged_at, seen_at, host_id)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (host_id, log_id) DO NOTHING
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	for _, entry := range entries {
+
+		names, customErr := encodeJSONB(entry.Names, methodName)
+		if customErr != nil {
+			return customErr
+		}
+
+		_, err := stmt.Exec(entry.LogID, entry.SerialNumber, entry.Issuer, entry.CommonName, names, entry.NotBefore, entry.NotAfter, entry.LoggedAt, seenAt, hostID)
+		if err != nil {
+			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+			log.Println(customErr)
+			return customErr
+		}
+
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		errMessage := fmt.Sprintf("Transaction commit failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+// getCertificateLog returns the log entries stored for a given host id, newest first
+func (c *Connection) getCertificateLog(hostID int, methodName string) ([]ctlog.Entry, *wrappedErr.Error) {
+	return c.queryCertificateLog(methodName, "certificate_log.host_id=$1", hostID)
+}
+
+// GetCertificates returns the certificates logged for the given domain and its subdomains, newest first
+func (c *Connection) GetCertificates(domainName string) ([]ctlog.Entry, *wrappedErr.Error) {
+	return c.queryCertificateLog("GetCertificates", "certificate_log.host_id IN (SELECT host.id FROM host WHERE host.domain_name=$1)", domainName)
+}
+
+func (c *Connection) queryCertificateLog(methodName, condition string, arg interface{}) ([]ctlog.Entry, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	entries := []ctlog.Entry{}
+
+	rows, err := c.DB.Query(`
+	SELECT
+		certificate_log.log_id, certificate_log.serial_number, certificate_log.issuer, certificate_log.common_name, certificate_log.names,
+		certificate_log.not_before, certificate_log.not_after, certificate_log.logged_at
+	FROM
+		certificate_log
+	WHERE
+		`+condition+`
+	ORDER BY
+		certificate_log.logged_at DESC
+	`, arg)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return entries, customErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var entry ctlog.Entry
+		var names []byte
+
+		err := rows.Scan(&entry.LogID, &entry.SerialNumber, &entry.Issuer, &entry.CommonName, &names, &entry.NotBefore, &entry.NotAfter, &entry.LoggedAt)
+		if err == nil && len(names) > 0 {
+			err = json.Unmarshal(names, &entry.Names)
+		}
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+			log.Println(customErr)
+			return []ctlog.Entry{}, customErr
+		}
+
+		entries = append(entries, entry)
+
+	}
+
+	return entries, nil
+
+}
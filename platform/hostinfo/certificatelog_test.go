@@ -0,0 +1,89 @@
+package hostinfo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"domain-info-api/platform/caa"
+	"domain-info-api/platform/ctlog"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUnexpectedCertificates(t *testing.T) {
+
+	letsEncrypt := ctlog.Entry{LogID: 1, Issuer: "C=US, O=Let's Encrypt, CN=R3", CommonName: "example.com", SerialNumber: "01"}
+	digiCert := ctlog.Entry{LogID: 2, Issuer: "C=US, O=\"DigiCert, Inc.\", CN=DigiCert TLS RSA SHA256 2020 CA1", CommonName: "api.example.com", SerialNumber: "02"}
+	unknown := ctlog.Entry{LogID: 3, Issuer: "CN=Shady CA", CommonName: "login.example.com", SerialNumber: "03"}
+
+	expected := map[string]bool{"Let's Encrypt": true}
+	policy := caa.Policy{Domain: "example.com", Records: []caa.Record{{Tag: "issue", Value: "digicert.com"}}}
+
+	tests := []struct {
+		name         string
+		entries      []ctlog.Entry
+		policy       caa.Policy
+		wantSubjects []string
+	}{
+		{"known issuer", []ctlog.Entry{letsEncrypt}, caa.Policy{}, nil},
+		{"new issuer", []ctlog.Entry{letsEncrypt, digiCert}, caa.Policy{}, []string{"api.example.com"}},
+		{"issuer authorized by CAA", []ctlog.Entry{digiCert}, policy, nil},
+		{"unknown issuer", []ctlog.Entry{unknown, digiCert}, policy, []string{"login.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var subjects []string
+
+			for _, event := range unexpectedCertificates(tt.entries, expected, tt.policy) {
+
+				if event.Kind != UnexpectedCertificateIssuer {
+					t.Errorf("got kind %q, want %q", event.Kind, UnexpectedCertificateIssuer)
+				}
+
+				subjects = append(subjects, event.Subject)
+
+			}
+
+			if len(subjects) != len(tt.wantSubjects) || (len(subjects) > 0 && subjects[0] != tt.wantSubjects[0]) {
+				t.Errorf("got %v, want %v", subjects, tt.wantSubjects)
+			}
+
+		})
+	}
+
+}
+
+func TestInsertCertificatesRollsBack(t *testing.T) {
+
+	db, mock := newMock()
+	defer db.Close()
+
+	entries := []ctlog.Entry{
+		{LogID: 1, Issuer: "C=US, O=Let's Encrypt, CN=R3", CommonName: "example.com", SerialNumber: "01"},
+		{LogID: 2, Issuer: "C=US, O=Let's Encrypt, CN=R3", CommonName: "www.example.com", SerialNumber: "02"},
+	}
+
+	query := "INSERT INTO certificate_log (log_id, serial_number, issuer, common_name, names, not_before, not_after, logged_at, seen_at, host_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (host_id, log_id) DO NOTHING"
+
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(query)
+	prepared.ExpectExec().WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepared.ExpectExec().WithArgs(int64(2), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 7).WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	connection := Connection{DB: db}
+
+	if customErr := connection.insertCertificates(7, entries, time.Now(), "TestInsertCertificatesRollsBack"); customErr == nil {
+		t.Error("got no error, want one")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+}
@@ -0,0 +1,151 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// Kinds of change events recorded between two analyses of a domain
+const (
+	VulnerabilityFound    = "vulnerability_found"
+	VulnerabilityResolved = "vulnerability_resolved"
+)
+
+// ChangeEvent represents a change detected on a domain when it was analyzed again
+type ChangeEvent struct {
+	Kind       string    `json:"kind"`
+	Subject    string    `json:"subject"`
+	Detail     string    `json:"detail"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+func newChangeEvent(kind, subject, detail string) ChangeEvent {
+
+	return ChangeEvent{
+		Kind:       kind,
+		Subject:    subject,
+		Detail:     detail,
+		DetectedAt: time.Now(),
+	}
+
+}
+
+func sortChangeEvents(events []ChangeEvent) {
+
+	sort.Slice(events, func(i, j int) bool {
+
+		if events[i].Kind != events[j].Kind {
+			return events[i].Kind < events[j].Kind
+		}
+
+		if events[i].Subject != events[j].Subject {
+			return events[i].Subject < events[j].Subject
+		}
+
+		return events[i].Detail < events[j].Detail
+
+	})
+
+}
+
+// insertChangeEvents inserts the given change events into the "change_event" table for a given host id
+func (c *Connection) insertChangeEvents(events []ChangeEvent, hostID int) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	stmt, err := c.DB.Prepare(`
+	INSERT INTO
+		change_event (kind, subject, detail, detected_at, host_id)
+	VALUES
+		($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "insertChangeEvents", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	for _, event := range events {
+
+		_, err := stmt.Exec(event.Kind, event.Subject, event.Detail, event.DetectedAt, hostID)
+		if err != nil {
+			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "insertChangeEvents", errMessage)
+			log.Println(customErr)
+			return customErr
+		}
+
+	}
+
+	return nil
+
+}
+
+// GetChangeEvents returns the change events recorded for the given domain, newest first
+func (c *Connection) GetChangeEvents(domainName string) ([]ChangeEvent, *wrappedErr.Error) {
+
+	var events []ChangeEvent
+	var customErr *wrappedErr.Error
+
+	stmt, err := c.DB.Prepare(`
+	SELECT
+		change_event.kind, change_event.subject, change_event.detail, change_event.detected_at
+	FROM
+		change_event
+	JOIN
+		host ON host.id = change_event.host_id
+	WHERE
+		host.domain_name=$1
+	ORDER BY
+		change_event.detected_at DESC
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "GetChangeEvents", errMessage)
+		log.Println(customErr)
+		return []ChangeEvent{}, customErr
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(domainName)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "GetChangeEvents", errMessage)
+		log.Println(customErr)
+		return []ChangeEvent{}, customErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var event ChangeEvent
+
+		err := rows.Scan(&event.Kind, &event.Subject, &event.Detail, &event.DetectedAt)
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "GetChangeEvents", errMessage)
+			log.Println(customErr)
+			return []ChangeEvent{}, customErr
+		}
+
+		events = append(events, event)
+
+	}
+
+	return events, nil
+
+}
@@ -5,13 +5,23 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 
+	"domain-info-api/platform/ctlog"
 	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/logostore"
 )
 
 // Connection represents an active connection to a database
 type Connection struct {
-	DB *sql.DB
+	DB           *sql.DB
+	Logos        logostore.Store
+	Certificates ctlog.Source
+
+	subdomainWorkers     sync.Once
+	subdomainJobs        chan subdomainJob
+	certificateLogWorker sync.Once
+	certificateLogJobs   chan certificateLogJob
 }
 
 var (
@@ -37,7 +47,131 @@ var (
 	);`
 )
 
-// NewConnection creates the 'host' and 'server' tables and returns a connection to the database
+// migrationQueries holds the schema changes applied in order on top of the initial tables
+var migrationQueries = []string{
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS status_message TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS engine_version TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS criteria_version TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS tested_at TIMESTAMPTZ`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS certs JSONB`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS server_name TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS status_message TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS has_warnings BOOLEAN NOT NULL DEFAULT false`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS is_exceptional BOOLEAN NOT NULL DEFAULT false`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS details JSONB`,
+	`CREATE TABLE IF NOT EXISTS vulnerability (
+		id SERIAL PRIMARY KEY,
+		address TEXT,
+		name TEXT,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS vulnerability_name_idx ON vulnerability (name)`,
+	`CREATE TABLE IF NOT EXISTS change_event (
+		id SERIAL PRIMARY KEY,
+		kind TEXT,
+		subject TEXT,
+		detail TEXT,
+		detected_at TIMESTAMPTZ,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS grade_history (
+		id SERIAL PRIMARY KEY,
+		ssl_grade VARCHAR(2),
+		recorded_at TIMESTAMPTZ,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS grade_history_recorded_at_idx ON grade_history (recorded_at)`,
+	`ALTER TABLE host ALTER COLUMN ssl_grade TYPE TEXT`,
+	`ALTER TABLE host ALTER COLUMN previous_ssl_grade TYPE TEXT`,
+	`ALTER TABLE server ALTER COLUMN ssl_grade TYPE TEXT`,
+	`ALTER TABLE grade_history ALTER COLUMN ssl_grade TYPE TEXT`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS grade_trust_ignored TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS availability_check (
+		id SERIAL PRIMARY KEY,
+		dns_resolved BOOLEAN,
+		addresses TEXT,
+		port_80_open BOOLEAN,
+		port_443_open BOOLEAN,
+		url TEXT,
+		status_code INTEGER,
+		latency_ms INTEGER,
+		tls_handshake_ms INTEGER,
+		error TEXT,
+		is_down BOOLEAN,
+		checked_at TIMESTAMPTZ,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS availability_check_host_idx ON availability_check (host_id, checked_at)`,
+	`CREATE TABLE IF NOT EXISTS incident (
+		id SERIAL PRIMARY KEY,
+		opened_at TIMESTAMPTZ,
+		closed_at TIMESTAMPTZ,
+		failed_checks INTEGER,
+		last_error TEXT,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS metadata JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS logo_hash TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS logo_changed BOOLEAN NOT NULL DEFAULT false`,
+	`CREATE TABLE IF NOT EXISTS logo (
+		domain_name TEXT PRIMARY KEY,
+		data BYTES,
+		content_type TEXT,
+		hash TEXT,
+		source_url TEXT,
+		fetched_at TIMESTAMPTZ
+	)`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS redirects JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS security_headers JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS technologies JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS robots JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS content JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS content_changed BOOLEAN NOT NULL DEFAULT false`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS content_similarity FLOAT`,
+	`CREATE TABLE IF NOT EXISTS content_history (
+		id SERIAL PRIMARY KEY,
+		text_hash TEXT,
+		simhash TEXT,
+		word_count INTEGER,
+		similarity FLOAT,
+		recorded_at TIMESTAMPTZ,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS content_history_host_idx ON content_history (host_id, recorded_at)`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS dns JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS mail_security JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS dnssec JSONB`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS certificate JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS caa JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS subdomains JSONB`,
+	`ALTER TABLE host ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES host(id)`,
+	`CREATE TABLE IF NOT EXISTS certificate_log (
+		id SERIAL PRIMARY KEY,
+		log_id INT8,
+		serial_number TEXT,
+		issuer TEXT,
+		common_name TEXT,
+		names JSONB,
+		not_before TIMESTAMPTZ,
+		not_after TIMESTAMPTZ,
+		logged_at TIMESTAMPTZ,
+		seen_at TIMESTAMPTZ,
+		host_id INTEGER,
+		FOREIGN KEY (host_id) REFERENCES host(id)
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS certificate_log_host_entry_idx ON certificate_log (host_id, log_id)`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS location JSONB`,
+	`ALTER TABLE server ADD COLUMN IF NOT EXISTS provider JSONB`,
+}
+
+// NewConnection creates the 'host' and 'server' tables, applies the schema migrations
+// and returns a connection to the database
 func NewConnection(db *sql.DB) (*Connection, *wrappedErr.Error) {
 
 	var customErr *wrappedErr.Error
@@ -78,6 +212,18 @@ func NewConnection(db *sql.DB) (*Connection, *wrappedErr.Error) {
 		return &Connection{}, customErr
 	}
 
-	return &Connection{DB: db}, nil
+	for _, query := range migrationQueries {
+
+		_, err = db.Exec(query)
+		if err != nil {
+			errMessage := fmt.Sprintf("Failed schema migration: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "NewConnection", errMessage)
+			log.Println(customErr)
+			return &Connection{}, customErr
+		}
+
+	}
+
+	return &Connection{DB: db, Logos: logostore.NewStore(db), Certificates: ctlog.SourceFromEnv()}, nil
 
 }
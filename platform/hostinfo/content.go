@@ -0,0 +1,75 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+	scraping "domain-info-api/platform/webscraping"
+)
+
+// ContentChanged is the kind of change event recorded when the text of the homepage changes
+const ContentChanged = "content_changed"
+
+// compareContent reports whether the homepage text changed between two analyses along with
+// how similar both versions are. The similarity is nil when either fingerprint is missing
+func compareContent(previous, current scraping.Content) (bool, *float64) {
+
+	if previous.TextHash == "" || current.TextHash == "" {
+		return false, nil
+	}
+
+	similarity := scraping.Similarity(previous, current)
+
+	return previous.TextHash != current.TextHash, &similarity
+
+}
+
+// contentChangeEvents returns the change event of a homepage whose text changed
+func contentChangeEvents(changed bool, similarity *float64, current scraping.Content) []ChangeEvent {
+
+	if !changed || similarity == nil {
+		return nil
+	}
+
+	return []ChangeEvent{newChangeEvent(ContentChanged, current.TextHash, fmt.Sprintf("similarity %.2f", *similarity))}
+
+}
+
+// insertContentHistory records the content fingerprint of a given host id on the "content_history" table
+func (c *Connection) insertContentHistory(hostID int, content scraping.Content, similarity *float64, recordedAt time.Time, methodName string) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	if content.TextHash == "" {
+		return nil
+	}
+
+	stmt, err := c.DB.Prepare(`
+	INSERT INTO
+		content_history (text_hash, simhash, word_count, similarity, recorded_at, host_id)
+	VALUES
+		($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(content.TextHash, content.SimHash, content.WordCount, similarity, recordedAt, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
@@ -0,0 +1,37 @@
+package hostinfo
+
+import (
+	"testing"
+
+	scraping "domain-info-api/platform/webscraping"
+)
+
+func TestCompareContent(t *testing.T) {
+
+	previous := scraping.NewContent("Welcome to our store, browse the catalog and find the best deals of the week")
+	current := scraping.NewContent("Welcome to our store, browse the catalog and find the best deals of the month")
+
+	changed, similarity := compareContent(previous, current)
+
+	if !changed || similarity == nil || *similarity >= 1 {
+		t.Fatalf("got changed %v and similarity %v, want a change below 1", changed, similarity)
+	}
+
+	events := contentChangeEvents(changed, similarity, current)
+	if len(events) != 1 || events[0].Kind != ContentChanged || events[0].Subject != current.TextHash {
+		t.Errorf("got %+v, want a single %s event", events, ContentChanged)
+	}
+
+	changed, similarity = compareContent(previous, previous)
+
+	if changed || similarity == nil || *similarity != 1 {
+		t.Errorf("got changed %v and similarity %v for the same content", changed, similarity)
+	}
+
+	changed, similarity = compareContent(scraping.Content{}, current)
+
+	if changed || similarity != nil {
+		t.Errorf("got changed %v and similarity %v without a previous fingerprint", changed, similarity)
+	}
+
+}
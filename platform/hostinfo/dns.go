@@ -0,0 +1,159 @@
+package hostinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"domain-info-api/platform/dnsrecords"
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// Kinds of change events recorded when the DNS records of a domain change
+const (
+	DNSRecordAdded   = "dns_record_added"
+	DNSRecordRemoved = "dns_record_removed"
+	DNSRecordChanged = "dns_record_changed"
+)
+
+// singleValuedTypes holds the record types a name can only have one of, whose replacement
+// is reported as a single change
+var singleValuedTypes = map[string]bool{
+	dnsrecords.CNAME: true,
+	dnsrecords.SOA:   true,
+}
+
+// recordSet represents the records of a given type found under a name
+type recordSet struct {
+	recordType string
+	name       string
+}
+
+func (s recordSet) String() string {
+	return s.recordType + " " + s.name
+}
+
+// diffDNSRecords returns the change events between the DNS inventories of two analyses. TTLs
+// are ignored, and so are the record types that could not be queried on either analysis.
+// Nothing is reported when there is no previous inventory to compare against
+func diffDNSRecords(oldInventory, newInventory dnsrecords.Inventory) []ChangeEvent {
+
+	var events []ChangeEvent
+
+	if oldInventory.Records == nil {
+		return events
+	}
+
+	skipped := func(record dnsrecords.Record) bool {
+		_, oldFailed := oldInventory.Errors[record.Type]
+		_, newFailed := newInventory.Errors[record.Type]
+		return oldFailed || newFailed
+	}
+
+	oldRecords := make(map[dnsrecords.Record]bool)
+
+	for _, record := range oldInventory.Records {
+		record.TTL = 0
+		oldRecords[record] = true
+	}
+
+	removed := make(map[recordSet][]string)
+	added := make(map[recordSet][]string)
+
+	for _, record := range newInventory.Records {
+
+		record.TTL = 0
+
+		if oldRecords[record] {
+			delete(oldRecords, record)
+			continue
+		}
+
+		if !skipped(record) {
+			set := recordSet{record.Type, record.Name}
+			added[set] = append(added[set], record.Value)
+		}
+
+	}
+
+	for record := range oldRecords {
+		if !skipped(record) {
+			set := recordSet{record.Type, record.Name}
+			removed[set] = append(removed[set], record.Value)
+		}
+	}
+
+	for set, values := range added {
+
+		oldValues := removed[set]
+
+		if singleValuedTypes[set.recordType] && len(values) == 1 && len(oldValues) == 1 {
+			events = append(events, newChangeEvent(DNSRecordChanged, set.String(), fmt.Sprintf("%s -> %s", oldValues[0], values[0])))
+			delete(removed, set)
+			continue
+		}
+
+		for _, value := range values {
+			events = append(events, newChangeEvent(DNSRecordAdded, set.String(), value))
+		}
+
+	}
+
+	for set, values := range removed {
+		for _, value := range values {
+			events = append(events, newChangeEvent(DNSRecordRemoved, set.String(), value))
+		}
+	}
+
+	sortChangeEvents(events)
+
+	return events
+
+}
+
+// refreshDNS looks up the DNS records of a host analyzed again and stores them, returning
+// them along with the changes found against the previously stored inventory
+func (c *Connection) refreshDNS(hostID int, domainName string, previous []byte) (dnsrecords.Inventory, []ChangeEvent, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+	var oldInventory dnsrecords.Inventory
+
+	if len(previous) > 0 {
+		err := json.Unmarshal(previous, &oldInventory)
+		if err != nil {
+			errMessage := fmt.Sprintf("Invalid stored DNS records: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+			log.Println(customErr)
+			return oldInventory, nil, customErr
+		}
+	}
+
+	newInventory := dnsrecords.Lookup(domainName)
+
+	inventory, customErr := encodeJSONB(newInventory, "CheckDomainExists")
+	if customErr != nil {
+		return newInventory, nil, customErr
+	}
+
+	stmt, err := c.DB.Prepare(`UPDATE host SET dns = $1 WHERE host.id = $2`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return newInventory, nil, customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(inventory, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return newInventory, nil, customErr
+	}
+
+	return newInventory, diffDNSRecords(oldInventory, newInventory), nil
+
+}
@@ -0,0 +1,50 @@
+package hostinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"domain-info-api/platform/dnsrecords"
+)
+
+func TestDiffDNSRecords(t *testing.T) {
+
+	oldInventory := dnsrecords.Inventory{
+		Records: []dnsrecords.Record{
+			{Type: dnsrecords.A, Name: "example.com.", TTL: 300, Value: "93.184.216.34"},
+			{Type: dnsrecords.MX, Name: "example.com.", TTL: 300, Value: "10 mail.example.com."},
+			{Type: dnsrecords.SOA, Name: "example.com.", TTL: 300, Value: "ns1.example.com. admin.example.com. 1 7200 3600 1209600 300"},
+			{Type: dnsrecords.TXT, Name: "example.com.", TTL: 300, Value: "v=spf1 -all"},
+		},
+	}
+
+	newInventory := dnsrecords.Inventory{
+		Records: []dnsrecords.Record{
+			{Type: dnsrecords.A, Name: "example.com.", TTL: 120, Value: "93.184.216.34"},
+			{Type: dnsrecords.A, Name: "example.com.", TTL: 120, Value: "93.184.216.35"},
+			{Type: dnsrecords.SOA, Name: "example.com.", TTL: 300, Value: "ns1.example.com. admin.example.com. 2 7200 3600 1209600 300"},
+		},
+		Errors: map[string]string{dnsrecords.TXT: "i/o timeout"},
+	}
+
+	var got [][3]string
+
+	for _, event := range diffDNSRecords(oldInventory, newInventory) {
+		got = append(got, [3]string{event.Kind, event.Subject, event.Detail})
+	}
+
+	want := [][3]string{
+		{DNSRecordAdded, "A example.com.", "93.184.216.35"},
+		{DNSRecordChanged, "SOA example.com.", "ns1.example.com. admin.example.com. 1 7200 3600 1209600 300 -> ns1.example.com. admin.example.com. 2 7200 3600 1209600 300"},
+		{DNSRecordRemoved, "MX example.com.", "10 mail.example.com."},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if events := diffDNSRecords(dnsrecords.Inventory{}, newInventory); len(events) != 0 {
+		t.Errorf("got %d events without a previous inventory, want 0", len(events))
+	}
+
+}
@@ -0,0 +1,91 @@
+package hostinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"domain-info-api/platform/dnssec"
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// Kinds of change events recorded when the DNSSEC state of a domain changes
+const (
+	DNSSECAdded   = "dnssec_added"
+	DNSSECRemoved = "dnssec_removed"
+	DNSSECBroken  = "dnssec_broken"
+)
+
+// diffDNSSEC returns the change events between the DNSSEC reports of two analyses. Nothing is
+// reported when there is no previous report or when either check could not reach a conclusion
+func diffDNSSEC(oldReport, newReport dnssec.Report) []ChangeEvent {
+
+	var events []ChangeEvent
+
+	if oldReport.Status == "" || oldReport.Status == dnssec.Indeterminate || newReport.Status == dnssec.Indeterminate {
+		return events
+	}
+
+	switch {
+
+	case newReport.Status == dnssec.Bogus && oldReport.Status != dnssec.Bogus:
+		events = append(events, newChangeEvent(DNSSECBroken, newReport.Zone, newReport.Error))
+
+	case !oldReport.Signed && newReport.Signed:
+		events = append(events, newChangeEvent(DNSSECAdded, newReport.Zone, fmt.Sprintf("signed, chain of trust %s", newReport.Status)))
+
+	case oldReport.Signed && !newReport.Signed:
+		events = append(events, newChangeEvent(DNSSECRemoved, newReport.Zone, "DNSKEY records are no longer published"))
+
+	}
+
+	return events
+
+}
+
+// refreshDNSSEC checks the DNSSEC state of a host analyzed again and stores it, returning the
+// changes found against the previously stored report
+func (c *Connection) refreshDNSSEC(hostID int, domainName string, previous []byte) ([]ChangeEvent, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+	var oldReport dnssec.Report
+
+	if len(previous) > 0 {
+		err := json.Unmarshal(previous, &oldReport)
+		if err != nil {
+			errMessage := fmt.Sprintf("Invalid stored DNSSEC report: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+			log.Println(customErr)
+			return nil, customErr
+		}
+	}
+
+	newReport := dnssec.Check(domainName)
+
+	report, customErr := encodeJSONB(newReport, "CheckDomainExists")
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	stmt, err := c.DB.Prepare(`UPDATE host SET dnssec = $1 WHERE host.id = $2`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(report, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	return diffDNSSEC(oldReport, newReport), nil
+
+}
@@ -0,0 +1,48 @@
+package hostinfo
+
+import (
+	"testing"
+
+	"domain-info-api/platform/dnssec"
+)
+
+func TestDiffDNSSEC(t *testing.T) {
+
+	unsigned := dnssec.Report{Zone: "example.com.", Status: dnssec.Insecure}
+	secure := dnssec.Report{Zone: "example.com.", Status: dnssec.Secure, Signed: true, HasDS: true}
+	bogus := dnssec.Report{Zone: "example.com.", Status: dnssec.Bogus, Signed: true, HasDS: true, Error: "signature over DNSKEY expired"}
+	indeterminate := dnssec.Report{Status: dnssec.Indeterminate}
+
+	tests := []struct {
+		name      string
+		oldReport dnssec.Report
+		newReport dnssec.Report
+		wantKinds []string
+	}{
+		{"signing added", unsigned, secure, []string{DNSSECAdded}},
+		{"signing removed", secure, unsigned, []string{DNSSECRemoved}},
+		{"signing broken", secure, bogus, []string{DNSSECBroken}},
+		{"still broken", bogus, bogus, nil},
+		{"unchanged", secure, secure, nil},
+		{"no previous report", dnssec.Report{}, secure, nil},
+		{"check failed", secure, indeterminate, nil},
+		{"previous check failed", indeterminate, unsigned, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var kinds []string
+
+			for _, event := range diffDNSSEC(tt.oldReport, tt.newReport) {
+				kinds = append(kinds, event.Kind)
+			}
+
+			if len(kinds) != len(tt.wantKinds) || (len(kinds) > 0 && kinds[0] != tt.wantKinds[0]) {
+				t.Errorf("got %v, want %v", kinds, tt.wantKinds)
+			}
+
+		})
+	}
+
+}
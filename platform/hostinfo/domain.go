@@ -2,12 +2,23 @@ package hostinfo
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"domain-info-api/platform/availability"
+	"domain-info-api/platform/caa"
+	"domain-info-api/platform/dnsrecords"
+	"domain-info-api/platform/dnssec"
 	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/fingerprint"
+	"domain-info-api/platform/mailsecurity"
+	"domain-info-api/platform/securityheaders"
+	sslAPI "domain-info-api/platform/ssllabs"
+	"domain-info-api/platform/subdomains"
+	scraping "domain-info-api/platform/webscraping"
 )
 
 // Items represents an array of domains
@@ -22,6 +33,13 @@ type Domain struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// rowScanner represents either a single row or a set of rows from a query
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const hostColumns = `host.id, host.domain_name, host.server_changed, host.ssl_grade, host.previous_ssl_grade, host.logo, host.title, host.is_down, host.created_at, host.status_message, host.engine_version, host.criteria_version, host.tested_at, host.certs, host.metadata, host.logo_hash, host.logo_changed, host.redirects, host.security_headers, host.technologies, host.robots, host.content, host.content_changed, host.content_similarity, host.dns, host.mail_security, host.dnssec, host.caa, host.subdomains`
+
 // NewDomain returns a new Domain based on the given url
 func NewDomain(URL string) (*Domain, *wrappedErr.Error) {
 
@@ -49,10 +67,10 @@ func (c *Connection) InsertDomain(domain *Domain) *wrappedErr.Error {
 	var customErr *wrappedErr.Error
 
 	insertDomainStmt, err := c.DB.Prepare(`
-	INSERT INTO 
-		host (domain_name, server_changed, ssl_grade, previous_ssl_grade, logo, title, is_down, created_at) 
-	VALUES 
-		($1, $2, $3, $4, $5, $6, $7, $8) 
+	INSERT INTO
+		host (domain_name, server_changed, ssl_grade, previous_ssl_grade, logo, title, is_down, created_at, status_message, engine_version, criteria_version, tested_at, certs, metadata, logo_hash, logo_changed, redirects, security_headers, technologies, robots, content, content_changed, content_similarity, dns, mail_security, dnssec, caa)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
 	RETURNING id
 	`)
 	if err != nil {
@@ -65,15 +83,67 @@ func (c *Connection) InsertDomain(domain *Domain) *wrappedErr.Error {
 	defer insertDomainStmt.Close()
 
 	host := domain.HostInfo
+	assessment := host.Assessment
 
-	record := insertDomainStmt.QueryRow(domain.Name, host.ServersChanged, host.Grade, host.PreviousGrade, host.Logo, host.Title, host.IsDown, domain.CreatedAt)
-	if err != nil {
-		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
-		customErr = wrappedErr.New(http.StatusInternalServerError, "InsertDomain", errMessage)
-		log.Println(customErr)
+	certs, customErr := encodeJSONB(assessment.Certs, "InsertDomain")
+	if customErr != nil {
 		return customErr
 	}
 
+	metadata, customErr := encodeJSONB(host.Metadata, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	redirects, customErr := encodeJSONB(host.Redirects, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	securityHeaders, customErr := encodeJSONB(host.SecurityHeaders, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	technologies, customErr := encodeJSONB(host.Technologies, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	robots, customErr := encodeJSONB(host.Robots, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	content, customErr := encodeJSONB(host.Content, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	dns, customErr := encodeJSONB(host.DNS, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	mailSecurity, customErr := encodeJSONB(host.MailSecurity, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	dnssecReport, customErr := encodeJSONB(host.DNSSEC, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	caaReport, customErr := encodeJSONB(host.CAA, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
+
+	record := insertDomainStmt.QueryRow(domain.Name, host.ServersChanged, host.Grade, host.PreviousGrade, host.Logo, host.Title, host.IsDown, domain.CreatedAt,
+		assessment.StatusMessage, assessment.EngineVersion, assessment.CriteriaVersion, assessment.TestedAt, certs, metadata, host.LogoHash, host.LogoChanged, redirects, securityHeaders, technologies, robots,
+		content, host.ContentChanged, host.ContentSimilarity, dns, mailSecurity, dnssecReport, caaReport)
+
 	var lastInsertID int
 
 	if err := record.Scan(&lastInsertID); err != nil {
@@ -83,66 +153,69 @@ func (c *Connection) InsertDomain(domain *Domain) *wrappedErr.Error {
 		return customErr
 	}
 
-	insertServerStmt, err := c.DB.Prepare(`
-	INSERT INTO 
-		server (address, ssl_grade, country, owner, host_id) 
-	VALUES 
-		($1, $2, $3, $4, $5)
-	`)
-	if err != nil {
-		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
-		customErr = wrappedErr.New(http.StatusInternalServerError, "InsertDomain", errMessage)
-		log.Println(customErr)
+	customErr = c.insertServers(host.Servers, lastInsertID, "InsertDomain")
+	if customErr != nil {
 		return customErr
 	}
 
-	defer insertServerStmt.Close()
-
-	for i := 0; i < len(host.Servers); i++ {
+	customErr = c.recordAvailabilityCheck(lastInsertID, host.Availability, failureThreshold(), "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
 
-		server := host.Servers[i]
+	customErr = c.saveLogo(domain.Name, host.logoImage)
+	if customErr != nil {
+		return customErr
+	}
 
-		_, err := insertServerStmt.Exec(server.Address, server.SslGrade, server.Country, server.Owner, lastInsertID)
-		if err != nil {
-			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
-			customErr = wrappedErr.New(http.StatusInternalServerError, "InsertDomain", errMessage)
-			log.Println(customErr)
-			return customErr
-		}
+	customErr = c.insertContentHistory(lastInsertID, host.Content, host.ContentSimilarity, domain.CreatedAt, "InsertDomain")
+	if customErr != nil {
+		return customErr
+	}
 
+	customErr = c.insertGradeHistory(lastInsertID, host.Grade, domain.CreatedAt, "InsertDomain")
+	if customErr != nil {
+		return customErr
 	}
 
+	c.queueCertificateLog(lastInsertID, domain.Name, host.Servers, host.CAA.Policy)
+
 	return nil
 
 }
 
 // GetAllDomains returns a slice of domains from the database
 func (c *Connection) GetAllDomains() (*Items, *wrappedErr.Error) {
+	return c.queryDomains("GetAllDomains", "SELECT "+hostColumns+" FROM host")
+}
+
+// GetDomainsVulnerableTo returns a slice of domains with at least one server affected by the given vulnerability
+func (c *Connection) GetDomainsVulnerableTo(vulnerability string) (*Items, *wrappedErr.Error) {
+	return c.queryDomains("GetDomainsVulnerableTo", "SELECT "+hostColumns+" FROM host WHERE host.id IN (SELECT vulnerability.host_id FROM vulnerability WHERE vulnerability.name=$1)", vulnerability)
+}
+
+// queryDomains returns the domains selected by the given query along with their servers
+func (c *Connection) queryDomains(methodName, query string, args ...interface{}) (*Items, *wrappedErr.Error) {
 
 	var items Items
 	var customErr *wrappedErr.Error
 
-	rows, err := c.DB.Query("SELECT * FROM host")
+	rows, err := c.DB.Query(query, args...)
 	if err != nil {
 		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
-		customErr = wrappedErr.New(http.StatusInternalServerError, "GetAllDomains", errMessage)
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
 		log.Println(customErr)
 		return &Items{}, customErr
 	}
 
 	defer rows.Close()
 
-	var id int
-	var serverChanged, isDown bool
-	var name, grade, previousGrade, logo, title string
-	var createdAt time.Time
-
 	for rows.Next() {
 
-		var err = rows.Scan(&id, &name, &serverChanged, &grade, &previousGrade, &logo, &title, &isDown, &createdAt)
+		id, domain, err := scanDomain(rows)
 		if err != nil {
 			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
-			customErr = wrappedErr.New(http.StatusInternalServerError, "GetAllDomains", errMessage)
+			customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
 			log.Println(customErr)
 			return &Items{}, customErr
 		}
@@ -152,19 +225,8 @@ func (c *Connection) GetAllDomains() (*Items, *wrappedErr.Error) {
 			return &Items{}, customErr
 		}
 
-		domain := Domain{
-			Name: name,
-			HostInfo: Host{
-				Servers:        servers,
-				ServersChanged: serverChanged,
-				Grade:          grade,
-				PreviousGrade:  previousGrade,
-				Logo:           logo,
-				Title:          title,
-				IsDown:         isDown,
-			},
-			CreatedAt: createdAt,
-		}
+		domain.HostInfo.Servers = servers
+		domain.HostInfo.Footprint = hostingFootprint(servers)
 
 		items.Domains = append(items.Domains, domain)
 
@@ -181,10 +243,10 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 
 	stmt, err := c.DB.Prepare(`
 	SELECT
-		host.id, host.ssl_grade, host.created_at  
-	FROM 
-		host 
-	WHERE 
+		host.id, host.ssl_grade, host.created_at, host.logo_hash, host.security_headers, host.technologies, host.content, host.dns, host.dnssec
+	FROM
+		host
+	WHERE
 		host.domain_name=$1
 	`)
 	if err != nil {
@@ -197,10 +259,15 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 	defer stmt.Close()
 
 	var hostID int
-	var currentGrade string
+	var currentGrade Grade
 	var createdAt time.Time
+	var previousWebsite websiteState
+	var securityHeaders, technologies, content, previousDNS, previousDNSSEC []byte
 
-	err = stmt.QueryRow(domainName).Scan(&hostID, &currentGrade, &createdAt)
+	err = stmt.QueryRow(domainName).Scan(&hostID, &currentGrade, &createdAt, &previousWebsite.LogoHash, &securityHeaders, &technologies, &content, &previousDNS, &previousDNSSEC)
+	if err == nil {
+		err = previousWebsite.decode(securityHeaders, technologies, content)
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return &Domain{}, false, nil
@@ -211,6 +278,9 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 		return &Domain{}, false, customErr
 	}
 
+	var changes []ChangeEvent
+	var refreshedCheck *availability.Check
+
 	if diff := checkTimeDiffNow(createdAt); diff >= 1 {
 
 		oldServers, customErr := c.getAllServers(hostID)
@@ -218,22 +288,88 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 			return &Domain{}, false, customErr
 		}
 
-		newServers, customErr := addServers(domainName)
+		hostSSLData, customErr := sslAPI.Get(domainName)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		newServers, customErr := addServers(hostSSLData)
 		if customErr != nil {
 			return &Domain{}, false, customErr
 		}
 
+		siteInfo, scrapeErr := scraping.FetchWebsiteInfo(domainName)
+
+		tagProviders(newServers, siteInfo.Headers, siteInfo.Address, oldServers)
+
 		newGrade := getLowestGrade(newServers)
 
 		serverChanged := haveServersChanged(newServers, oldServers)
 
-		if serverChanged {
+		customErr = c.updateAllServers(newServers, hostID)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		caaReport, customErr := c.refreshCAA(hostID, domainName, newServers)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		changes = diffVulnerabilities(oldServers, newServers)
+
+		c.queueCertificateLog(hostID, domainName, newServers, caaReport.Policy)
+
+		if scrapeErr == nil {
 
-			customErr = c.updateAllServers(newServers, hostID)
+			changes = append(changes, diffProviders(oldServers, newServers)...)
+
+			websiteChanges, customErr := c.refreshWebsiteInfo(hostID, domainName, siteInfo, previousWebsite)
 			if customErr != nil {
 				return &Domain{}, false, customErr
 			}
 
+			changes = append(changes, websiteChanges...)
+
+		}
+
+		inventory, dnsChanges, customErr := c.refreshDNS(hostID, domainName, previousDNS)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		changes = append(changes, dnsChanges...)
+
+		dnssecChanges, customErr := c.refreshDNSSEC(hostID, domainName, previousDNSSEC)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		changes = append(changes, dnssecChanges...)
+
+		customErr = c.refreshMailSecurity(hostID, domainName, inventory)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		customErr = c.insertChangeEvents(changes, hostID)
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		assessment := newAssessment(hostSSLData)
+
+		check := availability.Probe(domainName)
+		refreshedCheck = &check
+
+		customErr = c.recordAvailabilityCheck(hostID, &check, failureThreshold(), "CheckDomainExists")
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
+		certs, customErr := encodeJSONB(assessment.Certs, "CheckDomainExists")
+		if customErr != nil {
+			return &Domain{}, false, customErr
 		}
 
 		stmt, err := c.DB.Prepare(`
@@ -241,9 +377,15 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 		SET server_changed = $1,
 				ssl_grade = $2,
 				previous_ssl_grade = $3,
-				created_at = $4
+				created_at = $4,
+				status_message = $5,
+				engine_version = $6,
+				criteria_version = $7,
+				tested_at = $8,
+				certs = $9,
+				is_down = $10
 		WHERE
-			host.id = $5
+			host.id = $11
 		`)
 		if err != nil {
 			errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
@@ -254,7 +396,10 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 
 		defer stmt.Close()
 
-		_, err = stmt.Exec(serverChanged, newGrade, currentGrade, time.Now(), hostID)
+		refreshedAt := time.Now()
+
+		_, err = stmt.Exec(serverChanged, newGrade, currentGrade, refreshedAt,
+			assessment.StatusMessage, assessment.EngineVersion, assessment.CriteriaVersion, assessment.TestedAt, certs, check.IsDown, hostID)
 		if err != nil {
 			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
 			customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
@@ -262,6 +407,11 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 			return &Domain{}, false, customErr
 		}
 
+		customErr = c.insertGradeHistory(hostID, newGrade, refreshedAt, "CheckDomainExists")
+		if customErr != nil {
+			return &Domain{}, false, customErr
+		}
+
 	}
 
 	domainObject, customErr := c.getDomain(domainName)
@@ -269,6 +419,9 @@ func (c *Connection) CheckDomainExists(domainName string) (*Domain, bool, *wrapp
 		return &Domain{}, false, customErr
 	}
 
+	domainObject.HostInfo.Changes = changes
+	domainObject.HostInfo.Availability = refreshedCheck
+
 	return domainObject, true, nil
 
 }
@@ -278,7 +431,7 @@ func (c *Connection) getDomain(domainName string) (*Domain, *wrappedErr.Error) {
 
 	var customErr *wrappedErr.Error
 
-	stmt, err := c.DB.Prepare("SELECT * FROM host WHERE host.domain_name=$1")
+	stmt, err := c.DB.Prepare("SELECT " + hostColumns + " FROM host WHERE host.domain_name=$1")
 	if err != nil {
 		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
 		customErr = wrappedErr.New(http.StatusInternalServerError, "getDomain", errMessage)
@@ -290,12 +443,7 @@ func (c *Connection) getDomain(domainName string) (*Domain, *wrappedErr.Error) {
 
 	row := stmt.QueryRow(domainName)
 
-	var id int
-	var grade, previousGrade, logo, title string
-	var serversChanged, isDown bool
-	var createdAt time.Time
-
-	err = row.Scan(&id, &domainName, &serversChanged, &grade, &previousGrade, &logo, &title, &isDown, &createdAt)
+	id, domainObject, err := scanDomain(row)
 	if err != nil {
 		errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
 		customErr = wrappedErr.New(http.StatusInternalServerError, "getDomain", errMessage)
@@ -308,21 +456,166 @@ func (c *Connection) getDomain(domainName string) (*Domain, *wrappedErr.Error) {
 		return &Domain{}, customErr
 	}
 
+	domainObject.HostInfo.Servers = servers
+	domainObject.HostInfo.Footprint = hostingFootprint(servers)
+
+	return &domainObject, nil
+
+}
+
+// scanDomain returns the id and the domain stored in a row selected with hostColumns
+func scanDomain(row rowScanner) (int, Domain, error) {
+
+	var id int
+	var name, logo, logoHash, title string
+	var grade, previousGrade Grade
+	var serversChanged, isDown, logoChanged, contentChanged bool
+	var createdAt time.Time
+	var assessment Assessment
+	var testedAt sql.NullTime
+	var contentSimilarity sql.NullFloat64
+	var certs, metadata, redirects, securityHeaders, technologies, robots, content, dns, mailSecurity, dnssecReport, caaReport, discoveredSubdomains []byte
+	var websiteMetadata scraping.Metadata
+	var redirectChain scraping.RedirectChain
+	var securityHeadersReport securityheaders.Report
+	var detectedTechnologies []fingerprint.Technology
+	var robotsReport scraping.Robots
+	var contentFingerprint scraping.Content
+	var dnsInventory dnsrecords.Inventory
+	var mailSecurityReport mailsecurity.Report
+	var dnssecState dnssec.Report
+	var caaState caa.Report
+	var subdomainList []subdomains.Subdomain
+
+	err := row.Scan(&id, &name, &serversChanged, &grade, &previousGrade, &logo, &title, &isDown, &createdAt,
+		&assessment.StatusMessage, &assessment.EngineVersion, &assessment.CriteriaVersion, &testedAt, &certs, &metadata, &logoHash, &logoChanged, &redirects, &securityHeaders, &technologies, &robots,
+		&content, &contentChanged, &contentSimilarity, &dns, &mailSecurity, &dnssecReport, &caaReport, &discoveredSubdomains)
+	if err != nil {
+		return 0, Domain{}, err
+	}
+
+	if len(certs) > 0 {
+		err = json.Unmarshal(certs, &assessment.Certs)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(metadata) > 0 {
+		err = json.Unmarshal(metadata, &websiteMetadata)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(redirects) > 0 {
+		err = json.Unmarshal(redirects, &redirectChain)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(securityHeaders) > 0 {
+		err = json.Unmarshal(securityHeaders, &securityHeadersReport)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(technologies) > 0 {
+		err = json.Unmarshal(technologies, &detectedTechnologies)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(robots) > 0 {
+		err = json.Unmarshal(robots, &robotsReport)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(content) > 0 {
+		err = json.Unmarshal(content, &contentFingerprint)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(dns) > 0 {
+		err = json.Unmarshal(dns, &dnsInventory)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(mailSecurity) > 0 {
+		err = json.Unmarshal(mailSecurity, &mailSecurityReport)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(dnssecReport) > 0 {
+		err = json.Unmarshal(dnssecReport, &dnssecState)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(caaReport) > 0 {
+		err = json.Unmarshal(caaReport, &caaState)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	if len(discoveredSubdomains) > 0 {
+		err = json.Unmarshal(discoveredSubdomains, &subdomainList)
+		if err != nil {
+			return 0, Domain{}, err
+		}
+	}
+
+	assessment.TestedAt = testedAt.Time
+
+	var similarity *float64
+
+	if contentSimilarity.Valid {
+		similarity = &contentSimilarity.Float64
+	}
+
 	domainObject := Domain{
-		Name: domainName,
+		Name: name,
 		HostInfo: Host{
-			Servers:        servers,
-			ServersChanged: serversChanged,
-			Grade:          grade,
-			PreviousGrade:  previousGrade,
-			Logo:           logo,
-			Title:          title,
-			IsDown:         isDown,
+			ServersChanged:    serversChanged,
+			Grade:             grade,
+			PreviousGrade:     previousGrade,
+			Logo:              logo,
+			LogoHash:          logoHash,
+			LogoChanged:       logoChanged,
+			Title:             title,
+			IsDown:            isDown,
+			Assessment:        assessment,
+			Metadata:          websiteMetadata,
+			Redirects:         redirectChain,
+			SecurityHeaders:   securityHeadersReport,
+			Technologies:      detectedTechnologies,
+			Robots:            robotsReport,
+			Content:           contentFingerprint,
+			ContentChanged:    contentChanged,
+			ContentSimilarity: similarity,
+			DNS:               dnsInventory,
+			MailSecurity:      mailSecurityReport,
+			DNSSEC:            dnssecState,
+			CAA:               caaState,
+			Subdomains:        subdomainList,
 		},
 		CreatedAt: createdAt,
 	}
 
-	return &domainObject, nil
+	return id, domainObject, nil
 
 }
 
@@ -333,11 +626,11 @@ func (c *Connection) getAllServers(hostID int) ([]Server, *wrappedErr.Error) {
 	var newErr *wrappedErr.Error
 
 	stmt, err := c.DB.Prepare(`
-	SELECT 
-		server.address, server.ssl_grade, server.country, server.owner 
-	FROM 
-		server 
-	WHERE 
+	SELECT
+		server.address, server.ssl_grade, server.grade_trust_ignored, server.country, server.owner, server.server_name, server.status_message, server.has_warnings, server.is_exceptional, server.details, server.certificate, server.location, server.provider
+	FROM
+		server
+	WHERE
 		server.host_id=$1
 	`)
 	if err != nil {
@@ -359,11 +652,13 @@ func (c *Connection) getAllServers(hostID int) ([]Server, *wrappedErr.Error) {
 
 	defer rows.Close()
 
-	var address, grade, country, owner string
-
 	for rows.Next() {
 
-		err := rows.Scan(&address, &grade, &country, &owner)
+		var server Server
+		var details, certificate, location, detectedProvider []byte
+
+		err := rows.Scan(&server.Address, &server.SslGrade, &server.GradeTrustIgnored, &server.Country, &server.Owner,
+			&server.ServerName, &server.StatusMessage, &server.HasWarnings, &server.IsExceptional, &details, &certificate, &location, &detectedProvider)
 		if err != nil {
 			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
 			newErr = wrappedErr.New(http.StatusInternalServerError, "getAllServers", errMessage)
@@ -371,17 +666,59 @@ func (c *Connection) getAllServers(hostID int) ([]Server, *wrappedErr.Error) {
 			return []Server{}, newErr
 		}
 
-		server := Server{
-			Address:  address,
-			SslGrade: grade,
-			Country:  country,
-			Owner:    owner,
+		if len(details) > 0 {
+			err = json.Unmarshal(details, &server.Details)
+			if err != nil {
+				errMessage := fmt.Sprintf("JSON decoding failed: %s", err.Error())
+				newErr = wrappedErr.New(http.StatusInternalServerError, "getAllServers", errMessage)
+				log.Println(newErr)
+				return []Server{}, newErr
+			}
+		}
+
+		if len(certificate) > 0 {
+			err = json.Unmarshal(certificate, &server.Certificate)
+			if err != nil {
+				errMessage := fmt.Sprintf("JSON decoding failed: %s", err.Error())
+				newErr = wrappedErr.New(http.StatusInternalServerError, "getAllServers", errMessage)
+				log.Println(newErr)
+				return []Server{}, newErr
+			}
+		}
+
+		if len(location) > 0 {
+			err = json.Unmarshal(location, &server.Location)
+			if err != nil {
+				errMessage := fmt.Sprintf("JSON decoding failed: %s", err.Error())
+				newErr = wrappedErr.New(http.StatusInternalServerError, "getAllServers", errMessage)
+				log.Println(newErr)
+				return []Server{}, newErr
+			}
+		}
+
+		if len(detectedProvider) > 0 {
+			err = json.Unmarshal(detectedProvider, &server.Provider)
+			if err != nil {
+				errMessage := fmt.Sprintf("JSON decoding failed: %s", err.Error())
+				newErr = wrappedErr.New(http.StatusInternalServerError, "getAllServers", errMessage)
+				log.Println(newErr)
+				return []Server{}, newErr
+			}
 		}
 
 		servers = append(servers, server)
 
 	}
 
+	findings, newErr := c.getAllVulnerabilities(hostID)
+	if newErr != nil {
+		return []Server{}, newErr
+	}
+
+	for i := range servers {
+		servers[i].Vulnerabilities = findings[servers[i].Address]
+	}
+
 	return servers, nil
 
 }
@@ -390,6 +727,27 @@ func (c *Connection) updateAllServers(newServers []Server, hostID int) *wrappedE
 
 	var customErr *wrappedErr.Error
 
+	deleteVulnerabilityStmt, err := c.DB.Prepare(`
+	DELETE FROM vulnerability
+	WHERE host_id = $1;
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "updateAllServers", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer deleteVulnerabilityStmt.Close()
+
+	_, err = deleteVulnerabilityStmt.Exec(hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "updateAllServers", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
 	deleteServerStmt, err := c.DB.Prepare(`
 	DELETE FROM server
 	WHERE host_id = $1;
@@ -411,39 +769,111 @@ func (c *Connection) updateAllServers(newServers []Server, hostID int) *wrappedE
 		return customErr
 	}
 
+	return c.insertServers(newServers, hostID, "updateAllServers")
+
+}
+
+// insertServers inserts the given servers into the "server" table for a given host id
+func (c *Connection) insertServers(servers []Server, hostID int, methodName string) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
 	insertServerStmt, err := c.DB.Prepare(`
 	INSERT INTO
-		server (address, ssl_grade, country, owner, host_id)
+		server (address, ssl_grade, grade_trust_ignored, country, owner, server_name, status_message, has_warnings, is_exceptional, details, certificate, location, provider, host_id)
 	VALUES
-		($1, $2, $3, $4, $5)
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`)
 	if err != nil {
 		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
-		customErr = wrappedErr.New(http.StatusInternalServerError, "updateAllServers", errMessage)
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
 		log.Println(customErr)
 		return customErr
 	}
 
 	defer insertServerStmt.Close()
 
-	for i := 0; i < len(newServers); i++ {
+	insertVulnerabilityStmt, err := c.DB.Prepare(`
+	INSERT INTO
+		vulnerability (address, name, host_id)
+	VALUES
+		($1, $2, $3)
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer insertVulnerabilityStmt.Close()
+
+	for i := 0; i < len(servers); i++ {
+
+		server := servers[i]
+
+		details, customErr := encodeJSONB(server.Details, methodName)
+		if customErr != nil {
+			return customErr
+		}
+
+		certificate, customErr := encodeJSONB(server.Certificate, methodName)
+		if customErr != nil {
+			return customErr
+		}
+
+		location, customErr := encodeJSONB(server.Location, methodName)
+		if customErr != nil {
+			return customErr
+		}
 
-		server := newServers[i]
+		detectedProvider, customErr := encodeJSONB(server.Provider, methodName)
+		if customErr != nil {
+			return customErr
+		}
 
-		_, err := insertServerStmt.Exec(server.Address, server.SslGrade, server.Country, server.Owner, hostID)
+		_, err := insertServerStmt.Exec(server.Address, server.SslGrade, server.GradeTrustIgnored, server.Country, server.Owner,
+			server.ServerName, server.StatusMessage, server.HasWarnings, server.IsExceptional, details, certificate, location, detectedProvider, hostID)
 		if err != nil {
 			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
-			customErr = wrappedErr.New(http.StatusInternalServerError, "updateAllServers", errMessage)
+			customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
 			log.Println(customErr)
 			return customErr
 		}
 
+		for _, name := range server.Vulnerabilities {
+
+			_, err := insertVulnerabilityStmt.Exec(server.Address, name, hostID)
+			if err != nil {
+				errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+				customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+				log.Println(customErr)
+				return customErr
+			}
+
+		}
+
 	}
 
 	return nil
 
 }
 
+// encodeJSONB returns the JSON representation of a value to be stored in a JSONB column
+func encodeJSONB(value interface{}, methodName string) ([]byte, *wrappedErr.Error) {
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+		customErr := wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	return data, nil
+
+}
+
 func checkTimeDiffNow(createdAt time.Time) float64 {
 
 	now := time.Now()
@@ -460,7 +890,7 @@ func haveServersChanged(newServers, oldServers []Server) bool {
 
 	for i := 0; i < len(oldServers); i++ {
 
-		if oldServers[i] != newServers[i] {
+		if !sameServer(oldServers[i], newServers[i]) {
 			return true
 		}
 
@@ -469,3 +899,8 @@ func haveServersChanged(newServers, oldServers []Server) bool {
 	return false
 
 }
+
+// sameServer reports whether two servers share address, grades and registrant data
+func sameServer(a, b Server) bool {
+	return a.Address == b.Address && a.SslGrade == b.SslGrade && a.GradeTrustIgnored == b.GradeTrustIgnored && a.Country == b.Country && a.Owner == b.Owner
+}
@@ -61,8 +61,8 @@ func newMock() (*sql.DB, sqlmock.Sqlmock) {
 
 func setUpTables() (hostRows, serverRows *sqlmock.Rows) {
 
-	hostRows = sqlmock.NewRows([]string{"id", "domain_name", "server_changed", "ssl_grade", "previous_ssl_grade", "logo", "title", "is_down", "created_at"})
-	serverRows = sqlmock.NewRows([]string{"id", "address", "ssl_grade", "country", "owner", "host_id"})
+	hostRows = sqlmock.NewRows([]string{"id", "domain_name", "server_changed", "ssl_grade", "previous_ssl_grade", "logo", "title", "is_down", "created_at", "status_message", "engine_version", "criteria_version", "tested_at", "certs", "metadata", "logo_hash", "logo_changed", "redirects", "security_headers", "technologies", "robots", "content", "content_changed", "content_similarity", "dns", "mail_security", "dnssec", "caa", "subdomains"})
+	serverRows = sqlmock.NewRows([]string{"address", "ssl_grade", "grade_trust_ignored", "country", "owner", "server_name", "status_message", "has_warnings", "is_exceptional", "details", "certificate", "location", "provider"})
 
 	return
 
@@ -73,38 +73,58 @@ func TestInsertDomain(t *testing.T) {
 	db, mock := newMock()
 
 	insertDomainQuery := `
-	INSERT INTO 
-		host (domain_name, server_changed, ssl_grade, previous_ssl_grade, logo, title, is_down, created_at) 
-	VALUES 
-		($1, $2, $3, $4, $5, $6, $7, $8) 
+	INSERT INTO
+		host (domain_name, server_changed, ssl_grade, previous_ssl_grade, logo, title, is_down, created_at, status_message, engine_version, criteria_version, tested_at, certs, metadata, logo_hash, logo_changed, redirects, security_headers, technologies, robots, content, content_changed, content_similarity, dns, mail_security, dnssec, caa)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
 	RETURNING id
 	`
 	insertServerQuery := `
-	INSERT INTO 
-		server (address, ssl_grade, country, owner, host_id) 
-	VALUES 
-		($1, $2, $3, $4, $5)
+	INSERT INTO
+		server (address, ssl_grade, grade_trust_ignored, country, owner, server_name, status_message, has_warnings, is_exceptional, details, certificate, location, provider, host_id)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	insertVulnerabilityQuery := `
+	INSERT INTO
+		vulnerability (address, name, host_id)
+	VALUES
+		($1, $2, $3)
+	`
+	insertHistoryQuery := `
+	INSERT INTO
+		grade_history (ssl_grade, recorded_at, host_id)
+	VALUES
+		($1, $2, $3)
 	`
 	hostID := 0
 
 	domainStmt := mock.ExpectPrepare(insertDomainQuery)
 	domainStmt.ExpectQuery().
-		WithArgs(testDomain.Name, testHost.ServersChanged, testHost.Grade, testHost.PreviousGrade, testHost.Logo, testHost.Title, testHost.IsDown, testDomain.CreatedAt).
+		WithArgs(testDomain.Name, testHost.ServersChanged, testHost.Grade, testHost.PreviousGrade, testHost.Logo, testHost.Title, testHost.IsDown, testDomain.CreatedAt,
+			"", "", "", time.Time{}, []byte("null"), sqlmock.AnyArg(), testHost.LogoHash, testHost.LogoChanged, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), testHost.ContentChanged, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).
-		AddRow(hostID))
+			AddRow(hostID))
 
 	serverStmt := mock.ExpectPrepare(insertServerQuery)
+	mock.ExpectPrepare(insertVulnerabilityQuery)
 
 	for i := 0; i < len(testHost.Servers); i++ {
 
 		server := testHost.Servers[i]
 
 		_ = serverStmt.ExpectExec().
-			WithArgs(server.Address, server.SslGrade, server.Country, server.Owner, hostID).
+			WithArgs(server.Address, server.SslGrade, server.GradeTrustIgnored, server.Country, server.Owner, "", "", false, false, []byte("null"), []byte("null"), []byte("null"), []byte("null"), hostID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
 	}
 
+	historyStmt := mock.ExpectPrepare(insertHistoryQuery)
+	historyStmt.ExpectExec().
+		WithArgs(testHost.Grade, testDomain.CreatedAt, hostID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
 	mockConnection.DB = db
 
 	customErr := mockConnection.InsertDomain(&testDomain)
@@ -124,24 +144,33 @@ func TestGetAllDomains(t *testing.T) {
 	db, mock := newMock()
 	hostRows, serverRows := setUpTables()
 
-	query := "SELECT * FROM host"
+	query := "SELECT host.id, host.domain_name, host.server_changed, host.ssl_grade, host.previous_ssl_grade, host.logo, host.title, host.is_down, host.created_at, host.status_message, host.engine_version, host.criteria_version, host.tested_at, host.certs, host.metadata, host.logo_hash, host.logo_changed, host.redirects, host.security_headers, host.technologies, host.robots, host.content, host.content_changed, host.content_similarity, host.dns, host.mail_security, host.dnssec, host.caa, host.subdomains FROM host"
 
 	serverQuery := `
-	SELECT 
-		server.address, server.ssl_grade, server.country, server.owner 
-	FROM 
-		server 
-	WHERE 
+	SELECT
+		server.address, server.ssl_grade, server.grade_trust_ignored, server.country, server.owner, server.server_name, server.status_message, server.has_warnings, server.is_exceptional, server.details, server.certificate, server.location, server.provider
+	FROM
+		server
+	WHERE
 		server.host_id=$1
 	`
 
+	vulnerabilityQuery := `
+	SELECT
+		vulnerability.address, vulnerability.name
+	FROM
+		vulnerability
+	WHERE
+		vulnerability.host_id=$1
+	`
+
 	for i := 0; i < 3; i++ {
 
 		server := testHost.Servers[i]
 
-		hostRows.AddRow(i, testDomain.Name, testHost.ServersChanged, testHost.Grade, testHost.PreviousGrade, testHost.Logo, testHost.Title, testHost.IsDown, testDomain.CreatedAt)
+		hostRows.AddRow(i, testDomain.Name, testHost.ServersChanged, testHost.Grade, testHost.PreviousGrade, testHost.Logo, testHost.Title, testHost.IsDown, testDomain.CreatedAt, "", "", "", nil, nil, nil, "", false, nil, nil, nil, nil, nil, false, nil, nil, nil, nil, nil, nil)
 
-		serverRows.AddRow(i, server.Address, server.SslGrade, server.Country, server.Owner, i)
+		serverRows.AddRow(server.Address, server.SslGrade, server.GradeTrustIgnored, server.Country, server.Owner, "", "", false, false, nil, nil, nil, nil)
 
 	}
 
@@ -152,7 +181,12 @@ func TestGetAllDomains(t *testing.T) {
 		serverStmt := mock.ExpectPrepare(serverQuery)
 		serverStmt.ExpectQuery().
 			WithArgs(i).
-			WillReturnRows(sqlmock.NewRows([]string{"address", "ssl_grade", "country", "owner"}))
+			WillReturnRows(sqlmock.NewRows([]string{"address", "ssl_grade", "grade_trust_ignored", "country", "owner", "server_name", "status_message", "has_warnings", "is_exceptional", "details", "certificate", "location", "provider"}))
+
+		vulnerabilityStmt := mock.ExpectPrepare(vulnerabilityQuery)
+		vulnerabilityStmt.ExpectQuery().
+			WithArgs(i).
+			WillReturnRows(sqlmock.NewRows([]string{"address", "name"}))
 
 	}
 
@@ -175,24 +209,33 @@ func TestGetDomain(t *testing.T) {
 	db, mock := newMock()
 	hostRows, serverRows := setUpTables()
 
-	query := "SELECT * FROM host WHERE host.domain_name=$1"
+	query := "SELECT host.id, host.domain_name, host.server_changed, host.ssl_grade, host.previous_ssl_grade, host.logo, host.title, host.is_down, host.created_at, host.status_message, host.engine_version, host.criteria_version, host.tested_at, host.certs, host.metadata, host.logo_hash, host.logo_changed, host.redirects, host.security_headers, host.technologies, host.robots, host.content, host.content_changed, host.content_similarity, host.dns, host.mail_security, host.dnssec, host.caa, host.subdomains FROM host WHERE host.domain_name=$1"
 
 	serverQuery := `
-	SELECT 
-		server.address, server.ssl_grade, server.country, server.owner 
-	FROM 
-		server 
-	WHERE 
+	SELECT
+		server.address, server.ssl_grade, server.grade_trust_ignored, server.country, server.owner, server.server_name, server.status_message, server.has_warnings, server.is_exceptional, server.details, server.certificate, server.location, server.provider
+	FROM
+		server
+	WHERE
 		server.host_id=$1
 	`
 
-	hostRows.AddRow(0, testDomain.Name, testHost.ServersChanged, testHost.Grade, testHost.PreviousGrade, testHost.Logo, testHost.Title, testHost.IsDown, testDomain.CreatedAt)
+	vulnerabilityQuery := `
+	SELECT
+		vulnerability.address, vulnerability.name
+	FROM
+		vulnerability
+	WHERE
+		vulnerability.host_id=$1
+	`
+
+	hostRows.AddRow(0, testDomain.Name, testHost.ServersChanged, testHost.Grade, testHost.PreviousGrade, testHost.Logo, testHost.Title, testHost.IsDown, testDomain.CreatedAt, "", "", "", nil, nil, nil, "", false, nil, nil, nil, nil, nil, false, nil, nil, nil, nil, nil, nil)
 
 	for i := 0; i < 3; i++ {
 
 		server := testHost.Servers[i]
 
-		serverRows.AddRow(i, server.Address, server.SslGrade, server.Country, server.Owner, 0)
+		serverRows.AddRow(server.Address, server.SslGrade, server.GradeTrustIgnored, server.Country, server.Owner, "", "", false, false, nil, nil, nil, nil)
 
 	}
 
@@ -200,15 +243,22 @@ func TestGetDomain(t *testing.T) {
 	domainStmt.ExpectQuery().WithArgs("test.com").WillReturnRows(hostRows)
 
 	serverStmt := mock.ExpectPrepare(serverQuery)
-	serverStmt.ExpectQuery().WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"address", "ssl_grade", "country", "owner"}))
+	serverStmt.ExpectQuery().WithArgs(0).WillReturnRows(serverRows)
+
+	vulnerabilityStmt := mock.ExpectPrepare(vulnerabilityQuery)
+	vulnerabilityStmt.ExpectQuery().WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"address", "name"}).AddRow("server1", Heartbleed))
 
 	mockConnection.DB = db
 
-	_, customErr := mockConnection.getDomain("test.com")
+	domain, customErr := mockConnection.getDomain("test.com")
 	if customErr != nil {
 		t.Errorf("didn't expect an error: %s", customErr)
 	}
 
+	if servers := domain.HostInfo.Servers; len(servers) != 3 || len(servers[0].Vulnerabilities) != 1 || len(servers[1].Vulnerabilities) != 0 {
+		t.Errorf("got servers %+v, want the heartbleed finding on server1 only", servers)
+	}
+
 	err := mock.ExpectationsWereMet()
 	if err != nil {
 		t.Errorf("expectations were not met: %s", err)
@@ -0,0 +1,47 @@
+package hostinfo
+
+// Grade represents a grade given by SSL Labs to a server
+type Grade string
+
+// NoGrade is the grade of a server that could not be assessed, such as an unreachable endpoint
+const NoGrade Grade = ""
+
+// gradeRanks orders every grade returned by SSL Labs from best to worst. T (certificate not
+// trusted) and M (certificate name mismatch) rank below F since the connection cannot be
+// trusted at all, while NoGrade ranks below everything else
+var gradeRanks = map[Grade]int{
+	"A+":    11,
+	"A":     10,
+	"A-":    9,
+	"B":     8,
+	"C":     7,
+	"D":     6,
+	"E":     5,
+	"F":     4,
+	"T":     3,
+	"M":     2,
+	NoGrade: 0,
+}
+
+// Rank returns the position of the grade in the ordering, where higher is better.
+// Grades unknown to the service rank between M and NoGrade
+func (g Grade) Rank() int {
+
+	rank, known := gradeRanks[g]
+	if !known {
+		return 1
+	}
+
+	return rank
+
+}
+
+// Less reports whether the grade is worse than the given one
+func (g Grade) Less(other Grade) bool {
+	return g.Rank() < other.Rank()
+}
+
+// IsGraded reports whether the grade was actually given by SSL Labs
+func (g Grade) IsGraded() bool {
+	return g != NoGrade
+}
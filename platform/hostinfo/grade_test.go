@@ -0,0 +1,24 @@
+package hostinfo
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestGradeOrdering(t *testing.T) {
+
+	want := []Grade{"A+", "A", "A-", "B", "C", "D", "E", "F", "T", "M", "X", NoGrade}
+
+	got := []Grade{"M", NoGrade, "B", "A-", "T", "F", "A+", "X", "D", "A", "E", "C"}
+
+	sort.Slice(got, func(i, j int) bool {
+		return got[j].Less(got[i])
+	})
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+}
@@ -1,26 +1,63 @@
 package hostinfo
 
 import (
+	"strings"
+	"time"
+
+	"domain-info-api/platform/availability"
+	"domain-info-api/platform/caa"
+	"domain-info-api/platform/dnsrecords"
+	"domain-info-api/platform/dnssec"
 	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/fingerprint"
+	"domain-info-api/platform/logostore"
+	"domain-info-api/platform/mailsecurity"
+	"domain-info-api/platform/securityheaders"
 	sslAPI "domain-info-api/platform/ssllabs"
+	"domain-info-api/platform/subdomains"
 	scraping "domain-info-api/platform/webscraping"
-	"strings"
 )
 
 // Host represents info for a given Host
 type Host struct {
-	Servers        []Server `json:"servers"`
-	ServersChanged bool     `json:"servers_changed"`
-	Grade          string   `json:"ssl_grade"`
-	PreviousGrade  string   `json:"previous_ssl_grade"`
-	Logo           string   `json:"logo"`
-	Title          string   `json:"title"`
-	IsDown         bool     `json:"is_down"`
+	Servers           []Server                 `json:"servers"`
+	ServersChanged    bool                     `json:"servers_changed"`
+	Grade             Grade                    `json:"ssl_grade"`
+	PreviousGrade     Grade                    `json:"previous_ssl_grade"`
+	Logo              string                   `json:"logo"`
+	LogoHash          string                   `json:"logo_hash"`
+	LogoChanged       bool                     `json:"logo_changed"`
+	Title             string                   `json:"title"`
+	IsDown            bool                     `json:"is_down"`
+	Assessment        Assessment               `json:"assessment"`
+	Metadata          scraping.Metadata        `json:"metadata"`
+	Redirects         scraping.RedirectChain   `json:"redirects"`
+	SecurityHeaders   securityheaders.Report   `json:"security_headers"`
+	Technologies      []fingerprint.Technology `json:"technologies"`
+	Robots            scraping.Robots          `json:"robots"`
+	Content           scraping.Content         `json:"content"`
+	ContentChanged    bool                     `json:"content_changed"`
+	ContentSimilarity *float64                 `json:"content_similarity"`
+	DNS               dnsrecords.Inventory     `json:"dns"`
+	MailSecurity      mailsecurity.Report      `json:"mail_security"`
+	DNSSEC            dnssec.Report            `json:"dnssec"`
+	CAA               caa.Report               `json:"caa"`
+	Subdomains        []subdomains.Subdomain   `json:"subdomains,omitempty"`
+	SubdomainAnalysis string                   `json:"subdomain_analysis,omitempty"`
+	Footprint         []ProviderShare          `json:"footprint"`
+	Changes           []ChangeEvent            `json:"changes,omitempty"`
+	Availability      *availability.Check      `json:"availability,omitempty"`
+
+	logoImage *logostore.Logo
 }
 
-var statusMessages = map[string]bool{
-	"ERROR": true,
-	"READY": false,
+// Assessment represents the host level data of the SSL Labs assessment behind the grade
+type Assessment struct {
+	StatusMessage   string        `json:"status_message"`
+	EngineVersion   string        `json:"engine_version"`
+	CriteriaVersion string        `json:"criteria_version"`
+	TestedAt        time.Time     `json:"tested_at"`
+	Certs           []sslAPI.Cert `json:"certs"`
 }
 
 // newHost return a Host struct with about the given URL
@@ -28,33 +65,76 @@ func newHost(URL string) (*Host, *wrappedErr.Error) {
 
 	var host Host
 
-	servers, customErr := addServers(URL)
+	responseObject, customErr := sslAPI.Get(URL)
 	if customErr != nil {
 		return &Host{}, customErr
 	}
 
-	siteInfo, customErr := scraping.FetchWebsiteInfo(URL)
+	servers, customErr := addServers(responseObject)
 	if customErr != nil {
 		return &Host{}, customErr
 	}
 
-	responseObject, customErr := sslAPI.Get(URL)
+	siteInfo, customErr := scraping.FetchWebsiteInfo(URL)
 	if customErr != nil {
 		return &Host{}, customErr
 	}
 
-	status := responseObject.Status
+	tagProviders(servers, siteInfo.Headers, siteInfo.Address, nil)
+
+	check := availability.Probe(URL)
+
+	inventory := dnsrecords.Lookup(URL)
+
+	logo := fetchLogo(siteInfo.Logo)
 
 	host = Host{
-		Servers:        servers,
-		ServersChanged: false,
-		Grade:          getLowestGrade(servers),
-		PreviousGrade:  "",
-		Logo:           siteInfo.Logo,
-		Title:          strings.TrimSpace(siteInfo.Title),
-		IsDown:         statusMessages[status],
+		Servers:         servers,
+		Footprint:       hostingFootprint(servers),
+		ServersChanged:  false,
+		Grade:           getLowestGrade(servers),
+		PreviousGrade:   NoGrade,
+		Logo:            siteInfo.Logo,
+		Title:           strings.TrimSpace(siteInfo.Title),
+		IsDown:          check.IsDown,
+		Assessment:      newAssessment(responseObject),
+		Metadata:        siteInfo.Metadata,
+		Redirects:       siteInfo.Redirects,
+		SecurityHeaders: auditSecurityHeaders(siteInfo),
+		Technologies:    siteInfo.Technologies,
+		Robots:          siteInfo.Robots,
+		Content:         siteInfo.Content,
+		DNS:             inventory,
+		MailSecurity:    mailsecurity.Audit(URL, inventory),
+		DNSSEC:          dnssec.Check(URL),
+		CAA:             caa.Check(URL, serverCertificates(servers)),
+		Availability:    &check,
+		logoImage:       logo,
+	}
+
+	if logo != nil {
+		host.LogoHash = logo.Hash
 	}
 
 	return &host, nil
 
 }
+
+// newAssessment returns the host level data of the given SSL Labs response
+func newAssessment(responseObject *sslAPI.Response) Assessment {
+
+	var testedAt time.Time
+
+	if responseObject.TestTime > 0 {
+		testedAt = time.Unix(0, responseObject.TestTime*int64(time.Millisecond)).UTC()
+	}
+
+	return Assessment{
+		StatusMessage:   responseObject.StatusMessage,
+		EngineVersion:   responseObject.EngineVersion,
+		CriteriaVersion: responseObject.CriteriaVersion,
+		TestedAt:        testedAt,
+		Certs:           responseObject.Certs,
+	}
+
+}
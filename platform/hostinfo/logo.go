@@ -0,0 +1,47 @@
+package hostinfo
+
+import (
+	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/logostore"
+)
+
+// LogoChanged is the kind of change event recorded when the content of the logo changes
+const LogoChanged = "logo_changed"
+
+// fetchLogo downloads the given logo, returning nil when there is none or it cannot be used
+func fetchLogo(logoURL string) *logostore.Logo {
+
+	if logoURL == "" {
+		return nil
+	}
+
+	logo, customErr := logostore.Fetch(logoURL)
+	if customErr != nil {
+		return nil
+	}
+
+	return logo
+
+}
+
+// saveLogo keeps the downloaded logo of a domain in the logo store, if any
+func (c *Connection) saveLogo(domainName string, logo *logostore.Logo) *wrappedErr.Error {
+
+	if c.Logos == nil || logo == nil {
+		return nil
+	}
+
+	return c.Logos.Save(domainName, logo)
+
+}
+
+// GetLogo returns the stored logo of the given domain, or nil when there is none
+func (c *Connection) GetLogo(domainName string) (*logostore.Logo, *wrappedErr.Error) {
+
+	if c.Logos == nil {
+		return nil, nil
+	}
+
+	return c.Logos.Load(domainName)
+
+}
@@ -0,0 +1,44 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"domain-info-api/platform/dnsrecords"
+	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/mailsecurity"
+)
+
+// refreshMailSecurity audits the mail security of a host analyzed again, reusing the DNS
+// records just looked up, and stores the report
+func (c *Connection) refreshMailSecurity(hostID int, domainName string, inventory dnsrecords.Inventory) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	report, customErr := encodeJSONB(mailsecurity.Audit(domainName, inventory), "CheckDomainExists")
+	if customErr != nil {
+		return customErr
+	}
+
+	stmt, err := c.DB.Prepare(`UPDATE host SET mail_security = $1 WHERE host.id = $2`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(report, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
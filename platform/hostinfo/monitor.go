@@ -0,0 +1,486 @@
+package hostinfo
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"domain-info-api/platform/availability"
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// Incident represents a period during which a domain failed consecutive availability checks
+type Incident struct {
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	FailedChecks int        `json:"failed_checks"`
+	LastError    string     `json:"last_error"`
+}
+
+// Uptime represents the percentage of successful availability checks over several windows.
+// A window without checks has no value
+type Uptime struct {
+	Day   *float64 `json:"24h"`
+	Week  *float64 `json:"7d"`
+	Month *float64 `json:"30d"`
+}
+
+// IncidentReport represents the incidents and uptime of a given domain
+type IncidentReport struct {
+	Uptime    Uptime     `json:"uptime"`
+	Incidents []Incident `json:"incidents"`
+}
+
+// Monitor periodically checks the availability of every tracked domain
+type Monitor struct {
+	Connection       *Connection
+	Interval         time.Duration
+	FailureThreshold int
+	Concurrency      int
+	Probe            func(domain string) availability.Check
+}
+
+// incidentAction represents what a new availability check does to the incidents of a domain
+type incidentAction int
+
+const (
+	keepIncidents incidentAction = iota
+	openIncident
+	extendIncident
+	closeIncident
+)
+
+// NewMonitor returns a Monitor configured through the MONITOR_INTERVAL, MONITOR_FAILURE_THRESHOLD
+// and MONITOR_CONCURRENCY variables, checking every 5 minutes, 8 domains at a time, and opening
+// an incident after 3 consecutive failures by default
+func NewMonitor(c *Connection) *Monitor {
+
+	monitor := &Monitor{
+		Connection:       c,
+		Interval:         5 * time.Minute,
+		FailureThreshold: failureThreshold(),
+		Concurrency:      8,
+		Probe:            availability.Probe,
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("MONITOR_INTERVAL")); err == nil && interval > 0 {
+		monitor.Interval = interval
+	}
+
+	if concurrency, err := strconv.Atoi(os.Getenv("MONITOR_CONCURRENCY")); err == nil && concurrency > 0 {
+		monitor.Concurrency = concurrency
+	}
+
+	return monitor
+
+}
+
+// failureThreshold returns the number of consecutive failed checks that open an incident
+func failureThreshold() int {
+
+	if threshold, err := strconv.Atoi(os.Getenv("MONITOR_FAILURE_THRESHOLD")); err == nil && threshold > 0 {
+		return threshold
+	}
+
+	return 3
+
+}
+
+// Start checks every tracked domain on each interval until the stop channel is closed
+func (m *Monitor) Start(stop <-chan struct{}) {
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+
+		select {
+		case <-ticker.C:
+			m.RunOnce()
+		case <-stop:
+			return
+		}
+
+	}
+
+}
+
+// RunOnce checks the availability of every tracked domain a single time, probing up to
+// Concurrency domains at once, and returns when every check has been recorded
+func (m *Monitor) RunOnce() {
+
+	hosts, customErr := m.Connection.getAllHostNames()
+	if customErr != nil {
+		return
+	}
+
+	concurrency := m.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+
+	for hostID, domainName := range hosts {
+
+		slots <- struct{}{}
+		wg.Add(1)
+
+		go func(hostID int, domainName string) {
+
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			m.checkHost(hostID, domainName)
+
+		}(hostID, domainName)
+
+	}
+
+	wg.Wait()
+
+}
+
+// checkHost probes a single domain and records the result
+func (m *Monitor) checkHost(hostID int, domainName string) {
+
+	check := m.Probe(domainName)
+
+	customErr := m.Connection.recordAvailabilityCheck(hostID, &check, m.FailureThreshold, "RunOnce")
+	if customErr != nil {
+		return
+	}
+
+	m.Connection.updateIsDown(hostID, check.IsDown)
+
+}
+
+// recordAvailabilityCheck stores an availability check and opens, extends or closes the
+// incident of the host accordingly
+func (c *Connection) recordAvailabilityCheck(hostID int, check *availability.Check, threshold int, methodName string) *wrappedErr.Error {
+
+	if check == nil {
+		return nil
+	}
+
+	customErr := c.insertAvailabilityCheck(hostID, check, methodName)
+	if customErr != nil {
+		return customErr
+	}
+
+	openIncidentID, customErr := c.getOpenIncidentID(hostID)
+	if customErr != nil {
+		return customErr
+	}
+
+	recentDown, customErr := c.getRecentDownStates(hostID, threshold)
+	if customErr != nil {
+		return customErr
+	}
+
+	var query string
+	var args []interface{}
+
+	switch nextIncidentAction(openIncidentID != 0, recentDown, threshold) {
+	case openIncident:
+		query = `INSERT INTO incident (opened_at, failed_checks, last_error, host_id) VALUES ($1, $2, $3, $4)`
+		args = []interface{}{check.CheckedAt, threshold, check.Error, hostID}
+	case extendIncident:
+		query = `UPDATE incident SET failed_checks = failed_checks + 1, last_error = $1 WHERE id = $2`
+		args = []interface{}{check.Error, openIncidentID}
+	case closeIncident:
+		query = `UPDATE incident SET closed_at = $1 WHERE id = $2`
+		args = []interface{}{check.CheckedAt, openIncidentID}
+	default:
+		return nil
+	}
+
+	stmt, err := c.DB.Prepare(query)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(args...)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+// nextIncidentAction decides what to do with the incidents of a host given the down state
+// of its most recent checks, newest first, including the one that was just recorded
+func nextIncidentAction(hasOpenIncident bool, recentDown []bool, threshold int) incidentAction {
+
+	if len(recentDown) == 0 {
+		return keepIncidents
+	}
+
+	if !recentDown[0] {
+		if hasOpenIncident {
+			return closeIncident
+		}
+		return keepIncidents
+	}
+
+	if hasOpenIncident {
+		return extendIncident
+	}
+
+	if len(recentDown) < threshold {
+		return keepIncidents
+	}
+
+	for _, isDown := range recentDown[:threshold] {
+		if !isDown {
+			return keepIncidents
+		}
+	}
+
+	return openIncident
+
+}
+
+func (c *Connection) getAllHostNames() (map[int]string, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	hosts := make(map[int]string)
+
+	rows, err := c.DB.Query("SELECT host.id, host.domain_name FROM host")
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "getAllHostNames", errMessage)
+		log.Println(customErr)
+		return hosts, customErr
+	}
+
+	defer rows.Close()
+
+	var id int
+	var domainName string
+
+	for rows.Next() {
+
+		err := rows.Scan(&id, &domainName)
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "getAllHostNames", errMessage)
+			log.Println(customErr)
+			return hosts, customErr
+		}
+
+		hosts[id] = domainName
+
+	}
+
+	return hosts, nil
+
+}
+
+func (c *Connection) updateIsDown(hostID int, isDown bool) *wrappedErr.Error {
+
+	stmt, err := c.DB.Prepare("UPDATE host SET is_down = $1 WHERE host.id = $2")
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr := wrappedErr.New(http.StatusInternalServerError, "updateIsDown", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(isDown, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr := wrappedErr.New(http.StatusInternalServerError, "updateIsDown", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+func (c *Connection) getOpenIncidentID(hostID int) (int, *wrappedErr.Error) {
+
+	var id int
+
+	err := c.DB.QueryRow("SELECT incident.id FROM incident WHERE incident.host_id = $1 AND incident.closed_at IS NULL", hostID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr := wrappedErr.New(http.StatusInternalServerError, "getOpenIncidentID", errMessage)
+		log.Println(customErr)
+		return 0, customErr
+	}
+
+	return id, nil
+
+}
+
+func (c *Connection) getRecentDownStates(hostID, limit int) ([]bool, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+	var states []bool
+
+	rows, err := c.DB.Query(`
+	SELECT
+		availability_check.is_down
+	FROM
+		availability_check
+	WHERE
+		availability_check.host_id = $1
+	ORDER BY
+		availability_check.checked_at DESC
+	LIMIT $2
+	`, hostID, limit)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "getRecentDownStates", errMessage)
+		log.Println(customErr)
+		return states, customErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var isDown bool
+
+		err := rows.Scan(&isDown)
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "getRecentDownStates", errMessage)
+			log.Println(customErr)
+			return states, customErr
+		}
+
+		states = append(states, isDown)
+
+	}
+
+	return states, nil
+
+}
+
+// GetIncidentReport returns the incidents and the uptime percentages of the given domain.
+// The boolean result is false when the domain is not tracked
+func (c *Connection) GetIncidentReport(domainName string) (*IncidentReport, bool, *wrappedErr.Error) {
+
+	var report = IncidentReport{Incidents: []Incident{}}
+	var customErr *wrappedErr.Error
+
+	var hostID int
+
+	err := c.DB.QueryRow("SELECT host.id FROM host WHERE host.domain_name = $1", domainName).Scan(&hostID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &IncidentReport{}, false, nil
+		}
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "GetIncidentReport", errMessage)
+		log.Println(customErr)
+		return &IncidentReport{}, false, customErr
+	}
+
+	now := time.Now()
+
+	windows := []struct {
+		uptime **float64
+		length time.Duration
+	}{
+		{uptime: &report.Uptime.Day, length: 24 * time.Hour},
+		{uptime: &report.Uptime.Week, length: 7 * 24 * time.Hour},
+		{uptime: &report.Uptime.Month, length: 30 * 24 * time.Hour},
+	}
+
+	for _, window := range windows {
+
+		var up, total int
+
+		err := c.DB.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN availability_check.is_down THEN 0 ELSE 1 END), 0), COUNT(*)
+		FROM
+			availability_check
+		WHERE
+			availability_check.host_id = $1 AND availability_check.checked_at >= $2
+		`, hostID, now.Add(-window.length)).Scan(&up, &total)
+		if err != nil {
+			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "GetIncidentReport", errMessage)
+			log.Println(customErr)
+			return &IncidentReport{}, true, customErr
+		}
+
+		if total > 0 {
+			percentage := float64(up) * 100 / float64(total)
+			*window.uptime = &percentage
+		}
+
+	}
+
+	rows, err := c.DB.Query(`
+	SELECT
+		incident.opened_at, incident.closed_at, incident.failed_checks, incident.last_error
+	FROM
+		incident
+	WHERE
+		incident.host_id = $1
+	ORDER BY
+		incident.opened_at DESC
+	`, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "GetIncidentReport", errMessage)
+		log.Println(customErr)
+		return &IncidentReport{}, true, customErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var incident Incident
+		var closedAt sql.NullTime
+
+		err := rows.Scan(&incident.OpenedAt, &closedAt, &incident.FailedChecks, &incident.LastError)
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "GetIncidentReport", errMessage)
+			log.Println(customErr)
+			return &IncidentReport{}, true, customErr
+		}
+
+		if closedAt.Valid {
+			incident.ClosedAt = &closedAt.Time
+		}
+
+		report.Incidents = append(report.Incidents, incident)
+
+	}
+
+	return &report, true, nil
+
+}
@@ -0,0 +1,92 @@
+package hostinfo
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"domain-info-api/platform/availability"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNextIncidentAction(t *testing.T) {
+
+	var tests = []struct {
+		hasOpenIncident bool
+		recentDown      []bool
+		want            incidentAction
+	}{
+		{hasOpenIncident: false, recentDown: []bool{}, want: keepIncidents},
+		{hasOpenIncident: false, recentDown: []bool{false, true, true}, want: keepIncidents},
+		{hasOpenIncident: false, recentDown: []bool{true, true}, want: keepIncidents},
+		{hasOpenIncident: false, recentDown: []bool{true, false, true}, want: keepIncidents},
+		{hasOpenIncident: false, recentDown: []bool{true, true, true}, want: openIncident},
+		{hasOpenIncident: true, recentDown: []bool{true, true, true}, want: extendIncident},
+		{hasOpenIncident: true, recentDown: []bool{false, true, true}, want: closeIncident},
+	}
+
+	for _, test := range tests {
+		message := fmt.Sprintf("open incident %t, recent down states %v", test.hasOpenIncident, test.recentDown)
+		t.Run(message, func(t *testing.T) {
+			got := nextIncidentAction(test.hasOpenIncident, test.recentDown, 3)
+			if got != test.want {
+				t.Errorf("got %d, want %d", got, test.want)
+			}
+		})
+	}
+
+}
+
+func TestRunOnceBoundsConcurrentProbes(t *testing.T) {
+
+	db, mock := newMock()
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "domain_name"})
+	for i := 1; i <= 6; i++ {
+		rows.AddRow(i, fmt.Sprintf("domain%d.com", i))
+	}
+
+	mock.ExpectQuery("SELECT host.id, host.domain_name FROM host").WillReturnRows(rows)
+
+	var mutex sync.Mutex
+	var running, mostRunning, probed int
+
+	monitor := &Monitor{
+		Connection:       &Connection{DB: db},
+		FailureThreshold: 3,
+		Concurrency:      2,
+		Probe: func(domain string) availability.Check {
+
+			mutex.Lock()
+			running++
+			probed++
+			if running > mostRunning {
+				mostRunning = running
+			}
+			mutex.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			return availability.Check{}
+
+		},
+	}
+
+	monitor.RunOnce()
+
+	if probed != 6 {
+		t.Errorf("got %d domains probed, want 6", probed)
+	}
+
+	if mostRunning != 2 {
+		t.Errorf("got %d probes running at once, want 2", mostRunning)
+	}
+
+}
@@ -0,0 +1,206 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/provider"
+)
+
+// Kinds of change events recorded when the providers serving a domain change
+const (
+	ProviderAdded    = "provider_added"
+	ProviderRemoved  = "provider_removed"
+	ProviderMigrated = "provider_migrated"
+)
+
+// UnknownProvider is the name under which servers without a detected provider are counted
+const UnknownProvider = "unknown"
+
+// ProviderShare represents how many servers of a domain a provider hosts
+type ProviderShare struct {
+	Provider string `json:"provider"`
+	Type     string `json:"type,omitempty"`
+	Servers  int    `json:"servers"`
+}
+
+// tagProviders detects the provider of each server from its network, its reverse DNS names and
+// the headers the website answered with. The headers only describe the server at the given
+// address, so they are ignored when the domain has several servers and none of them answered.
+// A server detected without the headers keeps the provider stored for its address among the
+// previous servers, since header-only evidence would otherwise be lost, and servers are left
+// untagged when the dataset cannot be loaded
+func tagProviders(servers []Server, header http.Header, address string, previous []Server) {
+
+	dataset, err := provider.DefaultDataset()
+	if err != nil {
+		customErr := wrappedErr.New(http.StatusInternalServerError, "tagProviders", fmt.Sprintf("Provider dataset loading failed: %s", err.Error()))
+		log.Println(customErr)
+		return
+	}
+
+	observations := observeServers(servers, header, address)
+
+	provider.ObserveAll(observations)
+
+	for i := range servers {
+		servers[i].Provider = dataset.Detect(observations[i])
+	}
+
+	keepStoredProviders(servers, observations, previous)
+
+}
+
+// keepStoredProviders gives the servers observed without headers the provider stored for the
+// same address, when there is one
+func keepStoredProviders(servers []Server, observations []provider.Observation, previous []Server) {
+
+	stored := make(map[string]*provider.Match)
+
+	for _, server := range previous {
+		if server.Provider != nil {
+			stored[server.Address] = server.Provider
+		}
+	}
+
+	for i := range servers {
+		if observations[i].Header == nil && stored[servers[i].Address] != nil {
+			servers[i].Provider = stored[servers[i].Address]
+		}
+	}
+
+}
+
+// observeServers returns what is known about each server before its reverse names are looked up,
+// giving the headers to the server that answered them, or to the only one
+func observeServers(servers []Server, header http.Header, address string) []provider.Observation {
+
+	observations := make([]provider.Observation, len(servers))
+
+	for i, server := range servers {
+
+		observations[i].Address = server.Address
+
+		if server.Location != nil {
+			observations[i].ASN = server.Location.ASN
+		}
+
+		if len(servers) == 1 || (address != "" && net.ParseIP(server.Address).Equal(net.ParseIP(address))) {
+			observations[i].Header = header
+		}
+
+	}
+
+	return observations
+
+}
+
+// hostingFootprint returns how many servers each provider hosts, the largest share first
+func hostingFootprint(servers []Server) []ProviderShare {
+
+	shares := make(map[string]*ProviderShare)
+
+	for _, server := range servers {
+
+		name, kind := UnknownProvider, ""
+
+		if server.Provider != nil {
+			name, kind = server.Provider.Name, server.Provider.Type
+		}
+
+		if shares[name] == nil {
+			shares[name] = &ProviderShare{Provider: name, Type: kind}
+		}
+
+		shares[name].Servers++
+
+	}
+
+	footprint := make([]ProviderShare, 0, len(shares))
+
+	for _, share := range shares {
+		footprint = append(footprint, *share)
+	}
+
+	sort.Slice(footprint, func(i, j int) bool {
+
+		if footprint[i].Servers != footprint[j].Servers {
+			return footprint[i].Servers > footprint[j].Servers
+		}
+
+		return footprint[i].Provider < footprint[j].Provider
+
+	})
+
+	return footprint
+
+}
+
+// diffProviders returns the change events between the providers detected on the previous and the
+// current servers of a domain. A domain whose providers were all replaced is reported as a single
+// migration. Nothing is reported when either side has no detected provider
+func diffProviders(oldServers, newServers []Server) []ChangeEvent {
+
+	oldProviders := providerNames(oldServers)
+	newProviders := providerNames(newServers)
+
+	if len(oldProviders) == 0 || len(newProviders) == 0 {
+		return nil
+	}
+
+	var added, removed []string
+
+	for name := range newProviders {
+		if !oldProviders[name] {
+			added = append(added, name)
+		}
+	}
+
+	for name := range oldProviders {
+		if !newProviders[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	if len(removed) == len(oldProviders) && len(added) == len(newProviders) {
+		detail := fmt.Sprintf("moved from %s", strings.Join(removed, ", "))
+		return []ChangeEvent{newChangeEvent(ProviderMigrated, strings.Join(added, ", "), detail)}
+	}
+
+	var events []ChangeEvent
+
+	for _, name := range added {
+		events = append(events, newChangeEvent(ProviderAdded, name, "now serves part of the domain"))
+	}
+
+	for _, name := range removed {
+		events = append(events, newChangeEvent(ProviderRemoved, name, "no longer serves the domain"))
+	}
+
+	sortChangeEvents(events)
+
+	return events
+
+}
+
+func providerNames(servers []Server) map[string]bool {
+
+	names := make(map[string]bool)
+
+	for _, server := range servers {
+		if server.Provider != nil {
+			names[server.Provider.Name] = true
+		}
+	}
+
+	return names
+
+}
@@ -0,0 +1,140 @@
+package hostinfo
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"domain-info-api/platform/provider"
+)
+
+func hostedBy(names ...string) []Server {
+
+	var servers []Server
+
+	for i, name := range names {
+
+		server := Server{Address: fmt.Sprintf("192.0.2.%d", i+1)}
+
+		if name != "" {
+			server.Provider = &provider.Match{Name: name, Type: provider.CDN}
+		}
+
+		servers = append(servers, server)
+
+	}
+
+	return servers
+
+}
+
+func TestDiffProviders(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		oldServers   []Server
+		newServers   []Server
+		wantKinds    []string
+		wantSubjects []string
+	}{
+		{"unchanged", hostedBy("Cloudflare", "Cloudflare"), hostedBy("Cloudflare"), nil, nil},
+		{"migrated", hostedBy("Cloudflare", "Cloudflare"), hostedBy("Fastly"), []string{ProviderMigrated}, []string{"Fastly"}},
+		{"provider added", hostedBy("Cloudflare"), hostedBy("Cloudflare", "Akamai"), []string{ProviderAdded}, []string{"Akamai"}},
+		{"provider removed", hostedBy("Cloudflare", "Akamai"), hostedBy("Akamai", ""), []string{ProviderRemoved}, []string{"Cloudflare"}},
+		{"nothing detected before", hostedBy("", ""), hostedBy("Fastly"), nil, nil},
+		{"nothing detected now", hostedBy("Fastly"), hostedBy(""), nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var kinds, subjects []string
+
+			for _, event := range diffProviders(tt.oldServers, tt.newServers) {
+				kinds = append(kinds, event.Kind)
+				subjects = append(subjects, event.Subject)
+			}
+
+			if len(kinds) != len(tt.wantKinds) || (len(kinds) > 0 && (kinds[0] != tt.wantKinds[0] || subjects[0] != tt.wantSubjects[0])) {
+				t.Errorf("got %v %v, want %v %v", kinds, subjects, tt.wantKinds, tt.wantSubjects)
+			}
+
+		})
+	}
+
+}
+
+func TestHostingFootprint(t *testing.T) {
+
+	footprint := hostingFootprint(hostedBy("Fastly", "Cloudflare", "", "Cloudflare"))
+
+	want := []ProviderShare{
+		{Provider: "Cloudflare", Type: provider.CDN, Servers: 2},
+		{Provider: "Fastly", Type: provider.CDN, Servers: 1},
+		{Provider: UnknownProvider, Servers: 1},
+	}
+
+	if len(footprint) != len(want) {
+		t.Fatalf("got %+v, want %+v", footprint, want)
+	}
+
+	for i := range want {
+		if footprint[i] != want[i] {
+			t.Errorf("got %+v at %d, want %+v", footprint[i], i, want[i])
+		}
+	}
+
+}
+
+func TestObserveServersScopesHeaders(t *testing.T) {
+
+	header := http.Header{"Server": {"cloudflare"}}
+
+	tests := []struct {
+		name       string
+		servers    []Server
+		address    string
+		wantHeader []bool
+	}{
+		{"single server", hostedBy(""), "", []bool{true}},
+		{"answering server known", hostedBy("", ""), "192.0.2.2", []bool{false, true}},
+		{"answering server unknown", hostedBy("", ""), "198.51.100.1", []bool{false, false}},
+		{"no answering server", hostedBy("", ""), "", []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			for i, observation := range observeServers(tt.servers, header, tt.address) {
+				if (observation.Header != nil) != tt.wantHeader[i] || observation.Address != tt.servers[i].Address {
+					t.Errorf("got %+v for server %d, want headers %t", observation, i, tt.wantHeader[i])
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestKeepStoredProviders(t *testing.T) {
+
+	previous := hostedBy("Amazon Web Services", "Cloudflare")
+	servers := hostedBy("Fastly", "", "")
+
+	observations := observeServers(servers, http.Header{"Server": {"cloudflare"}}, "192.0.2.1")
+
+	keepStoredProviders(servers, observations, previous)
+
+	if servers[0].Provider == nil || servers[0].Provider.Name != "Fastly" {
+		t.Errorf("got %+v, want the provider detected with the headers", servers[0].Provider)
+	}
+
+	if servers[1].Provider == nil || servers[1].Provider.Name != "Cloudflare" {
+		t.Errorf("got %+v, want the provider stored for the same address", servers[1].Provider)
+	}
+
+	if servers[2].Provider != nil {
+		t.Errorf("got %+v, want no provider for an address without a stored one", servers[2].Provider)
+	}
+
+}
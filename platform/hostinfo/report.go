@@ -0,0 +1,217 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// GradeReport represents the evolution of the grades of every tracked domain over a window
+type GradeReport struct {
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Distribution map[Grade]int `json:"distribution"`
+	Upgrades     int           `json:"upgrades"`
+	Downgrades   int           `json:"downgrades"`
+	Dropped      []GradeDrop   `json:"dropped"`
+}
+
+// GradeDrop represents a domain whose lowest grade dropped during the window
+type GradeDrop struct {
+	Domain        string    `json:"domain"`
+	PreviousGrade Grade     `json:"previous_ssl_grade"`
+	Grade         Grade     `json:"ssl_grade"`
+	DroppedAt     time.Time `json:"dropped_at"`
+}
+
+// gradeRecord represents the grade of a domain recorded on a given analysis
+type gradeRecord struct {
+	Domain     string
+	Grade      Grade
+	RecordedAt time.Time
+}
+
+// insertGradeHistory records the grade of a given host id on the "grade_history" table
+func (c *Connection) insertGradeHistory(hostID int, grade Grade, recordedAt time.Time, methodName string) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	stmt, err := c.DB.Prepare(`
+	INSERT INTO
+		grade_history (ssl_grade, recorded_at, host_id)
+	VALUES
+		($1, $2, $3)
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(grade, recordedAt, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, methodName, errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+// GetGradeReport returns the grade report of every tracked domain since the given time
+func (c *Connection) GetGradeReport(from time.Time) (*GradeReport, *wrappedErr.Error) {
+
+	baseline, customErr := c.queryGradeHistory(`
+	SELECT DISTINCT ON (host.domain_name)
+		host.domain_name, grade_history.ssl_grade, grade_history.recorded_at
+	FROM
+		grade_history
+	JOIN
+		host ON host.id = grade_history.host_id
+	WHERE
+		grade_history.recorded_at < $1
+	ORDER BY
+		host.domain_name, grade_history.recorded_at DESC
+	`, from)
+	if customErr != nil {
+		return &GradeReport{}, customErr
+	}
+
+	records, customErr := c.queryGradeHistory(`
+	SELECT
+		host.domain_name, grade_history.ssl_grade, grade_history.recorded_at
+	FROM
+		grade_history
+	JOIN
+		host ON host.id = grade_history.host_id
+	WHERE
+		grade_history.recorded_at >= $1
+	ORDER BY
+		host.domain_name, grade_history.recorded_at
+	`, from)
+	if customErr != nil {
+		return &GradeReport{}, customErr
+	}
+
+	report := buildGradeReport(baseline, records, from, time.Now())
+
+	return &report, nil
+
+}
+
+func (c *Connection) queryGradeHistory(query string, from time.Time) ([]gradeRecord, *wrappedErr.Error) {
+
+	var records []gradeRecord
+	var customErr *wrappedErr.Error
+
+	stmt, err := c.DB.Prepare(query)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "GetGradeReport", errMessage)
+		log.Println(customErr)
+		return []gradeRecord{}, customErr
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(from)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "GetGradeReport", errMessage)
+		log.Println(customErr)
+		return []gradeRecord{}, customErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var record gradeRecord
+
+		err := rows.Scan(&record.Domain, &record.Grade, &record.RecordedAt)
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "GetGradeReport", errMessage)
+			log.Println(customErr)
+			return []gradeRecord{}, customErr
+		}
+
+		records = append(records, record)
+
+	}
+
+	return records, nil
+
+}
+
+// buildGradeReport computes the report from the last grade recorded for each domain
+// before the window and the grades recorded within it, sorted by domain and time
+func buildGradeReport(baseline, records []gradeRecord, from, to time.Time) GradeReport {
+
+	report := GradeReport{
+		From:         from,
+		To:           to,
+		Distribution: make(map[Grade]int),
+		Dropped:      []GradeDrop{},
+	}
+
+	first := make(map[string]gradeRecord)
+	last := make(map[string]gradeRecord)
+
+	for _, record := range baseline {
+		first[record.Domain] = record
+		last[record.Domain] = record
+	}
+
+	var lastDrop = make(map[string]time.Time)
+
+	for _, record := range records {
+
+		previous, seen := last[record.Domain]
+
+		if !seen {
+			first[record.Domain] = record
+		} else if !previous.Grade.IsGraded() || !record.Grade.IsGraded() {
+			// moving from or to an unreachable state is neither an upgrade nor a downgrade
+		} else if previous.Grade.Less(record.Grade) {
+			report.Upgrades++
+		} else if record.Grade.Less(previous.Grade) {
+			report.Downgrades++
+			lastDrop[record.Domain] = record.RecordedAt
+		}
+
+		last[record.Domain] = record
+
+	}
+
+	for domain, record := range last {
+
+		report.Distribution[record.Grade]++
+
+		if record.Grade.IsGraded() && record.Grade.Less(first[domain].Grade) {
+			report.Dropped = append(report.Dropped, GradeDrop{
+				Domain:        domain,
+				PreviousGrade: first[domain].Grade,
+				Grade:         record.Grade,
+				DroppedAt:     lastDrop[domain],
+			})
+		}
+
+	}
+
+	sort.Slice(report.Dropped, func(i, j int) bool {
+		return report.Dropped[i].Domain < report.Dropped[j].Domain
+	})
+
+	return report
+
+}
@@ -0,0 +1,47 @@
+package hostinfo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildGradeReport(t *testing.T) {
+
+	from := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(7 * 24 * time.Hour)
+
+	at := func(days int) time.Time {
+		return from.Add(time.Duration(days) * 24 * time.Hour)
+	}
+
+	baseline := []gradeRecord{
+		{Domain: "a.com", Grade: "A", RecordedAt: at(-2)},
+		{Domain: "b.com", Grade: "B", RecordedAt: at(-1)},
+		{Domain: "c.com", Grade: "A+", RecordedAt: at(-3)},
+	}
+
+	records := []gradeRecord{
+		{Domain: "a.com", Grade: "B", RecordedAt: at(1)},
+		{Domain: "a.com", Grade: "C", RecordedAt: at(2)},
+		{Domain: "b.com", Grade: "A", RecordedAt: at(3)},
+		{Domain: "d.com", Grade: "F", RecordedAt: at(4)},
+	}
+
+	got := buildGradeReport(baseline, records, from, to)
+
+	wantDistribution := map[Grade]int{"C": 1, "A": 1, "A+": 1, "F": 1}
+	if !reflect.DeepEqual(got.Distribution, wantDistribution) {
+		t.Errorf("got distribution %v, want %v", got.Distribution, wantDistribution)
+	}
+
+	if got.Upgrades != 1 || got.Downgrades != 2 {
+		t.Errorf("got %d upgrades and %d downgrades, want 1 and 2", got.Upgrades, got.Downgrades)
+	}
+
+	wantDropped := []GradeDrop{{Domain: "a.com", PreviousGrade: "A", Grade: "C", DroppedAt: at(2)}}
+	if !reflect.DeepEqual(got.Dropped, wantDropped) {
+		t.Errorf("got dropped %v, want %v", got.Dropped, wantDropped)
+	}
+
+}
@@ -0,0 +1,57 @@
+package hostinfo
+
+import (
+	"fmt"
+	"strings"
+
+	"domain-info-api/platform/securityheaders"
+	scraping "domain-info-api/platform/webscraping"
+)
+
+// SecurityHeaderChanged is the kind of change event recorded when a security header check changes status
+const SecurityHeaderChanged = "security_header_changed"
+
+// auditSecurityHeaders audits the headers of the page the scrape ended on, or returns an empty
+// report when robots.txt kept the homepage from being scraped
+func auditSecurityHeaders(siteInfo scraping.WebsiteInfo) securityheaders.Report {
+
+	if !siteInfo.Robots.HomepageAllowed {
+		return securityheaders.Report{}
+	}
+
+	return securityheaders.Audit(siteInfo.Headers, strings.HasPrefix(siteInfo.Redirects.FinalURL, "https://"))
+
+}
+
+// diffSecurityHeaders returns a change event for every check whose status differs between
+// two audits. Nothing is reported when there is no previous audit to compare against
+func diffSecurityHeaders(oldReport, newReport securityheaders.Report) []ChangeEvent {
+
+	var events []ChangeEvent
+
+	if len(oldReport.Findings) == 0 {
+		return events
+	}
+
+	oldStatuses := make(map[string]string)
+
+	for _, finding := range oldReport.Findings {
+		oldStatuses[finding.Check] = finding.Status
+	}
+
+	for _, finding := range newReport.Findings {
+
+		oldStatus, exists := oldStatuses[finding.Check]
+		if !exists || oldStatus == finding.Status {
+			continue
+		}
+
+		events = append(events, newChangeEvent(SecurityHeaderChanged, finding.Check, fmt.Sprintf("%s -> %s", oldStatus, finding.Status)))
+
+	}
+
+	sortChangeEvents(events)
+
+	return events
+
+}
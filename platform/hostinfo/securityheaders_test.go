@@ -0,0 +1,64 @@
+package hostinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/securityheaders"
+	scraping "domain-info-api/platform/webscraping"
+)
+
+func TestDiffSecurityHeaders(t *testing.T) {
+
+	oldReport := securityheaders.Report{Findings: []audit.Finding{
+		{Check: securityheaders.HSTS, Status: audit.Pass},
+		{Check: securityheaders.ContentSecurity, Status: audit.Fail},
+		{Check: securityheaders.FrameOptions, Status: audit.Pass},
+	}}
+
+	newReport := securityheaders.Report{Findings: []audit.Finding{
+		{Check: securityheaders.HSTS, Status: audit.Fail},
+		{Check: securityheaders.ContentSecurity, Status: audit.Warn},
+		{Check: securityheaders.FrameOptions, Status: audit.Pass},
+	}}
+
+	var got [][3]string
+
+	for _, event := range diffSecurityHeaders(oldReport, newReport) {
+		got = append(got, [3]string{event.Kind, event.Subject, event.Detail})
+	}
+
+	want := [][3]string{
+		{SecurityHeaderChanged, securityheaders.ContentSecurity, "fail -> warn"},
+		{SecurityHeaderChanged, securityheaders.HSTS, "pass -> fail"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if events := diffSecurityHeaders(securityheaders.Report{}, newReport); len(events) != 0 {
+		t.Errorf("got %v, want no events without a previous audit", events)
+	}
+
+}
+
+func TestAuditSecurityHeadersWithDisallowedHomepage(t *testing.T) {
+
+	blocked := auditSecurityHeaders(scraping.WebsiteInfo{Robots: scraping.Robots{HomepageAllowed: false}})
+
+	if len(blocked.Findings) != 0 {
+		t.Fatalf("got %+v, want no audit when robots.txt disallows /", blocked)
+	}
+
+	allowed := auditSecurityHeaders(scraping.WebsiteInfo{
+		Robots:    scraping.Robots{HomepageAllowed: true},
+		Redirects: scraping.RedirectChain{FinalURL: "https://example.com/"},
+	})
+
+	if events := diffSecurityHeaders(blocked, allowed); len(events) != 0 {
+		t.Errorf("got %+v, want no change against a homepage that was not scraped", events)
+	}
+
+}
@@ -1,44 +1,54 @@
 package hostinfo
 
 import (
+	"fmt"
+	"log"
+	"net/http"
+
 	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/geoip"
+	"domain-info-api/platform/provider"
 	sslAPI "domain-info-api/platform/ssllabs"
 	whoisAPI "domain-info-api/platform/whoisrecord"
 )
 
 // Server represents info for specific server in a given domain
 type Server struct {
-	Address  string `json:"address"`
-	SslGrade string `json:"ssl_grade"`
-	Country  string `json:"country"`
-	Owner    string `json:"owner"`
+	Address           string                  `json:"address"`
+	SslGrade          Grade                   `json:"ssl_grade"`
+	GradeTrustIgnored Grade                   `json:"grade_trust_ignored"`
+	Country           string                  `json:"country"`
+	Owner             string                  `json:"owner"`
+	ServerName        string                  `json:"server_name"`
+	StatusMessage     string                  `json:"status_message"`
+	HasWarnings       bool                    `json:"has_warnings"`
+	IsExceptional     bool                    `json:"is_exceptional"`
+	Details           *sslAPI.EndPointDetails `json:"details,omitempty"`
+	Vulnerabilities   []string                `json:"vulnerabilities"`
+	Certificate       *Certificate            `json:"certificate"`
+	Location          *geoip.Location         `json:"location,omitempty"`
+	Provider          *provider.Match         `json:"provider,omitempty"`
 }
 
-var grades = map[string]int{
-	"A+": 7,
-	"A":  6,
-	"B":  5,
-	"C":  4,
-	"D":  3,
-	"E":  2,
-	"F":  1,
+// Certificate represents the leaf certificate a server presents
+type Certificate struct {
+	Subject string   `json:"subject"`
+	Issuer  string   `json:"issuer"`
+	Names   []string `json:"names"`
 }
 
-// addServers returns a slice with all of the servers of a given domain
-func addServers(domain string) ([]Server, *wrappedErr.Error) {
+// addServers returns a slice with all of the servers found in the SSL Labs assessment of a domain
+func addServers(hostSSLData *sslAPI.Response) ([]Server, *wrappedErr.Error) {
 
 	var servers []Server
 
-	hostSSLData, customErr := sslAPI.Get(domain)
-	if customErr != nil {
-		return []Server{}, customErr
-	}
-
 	var IPAddress string
 
 	for i := 0; i < len(hostSSLData.EndPoints); i++ {
 
-		IPAddress = hostSSLData.EndPoints[i].IPAddress
+		endPoint := hostSSLData.EndPoints[i]
+
+		IPAddress = endPoint.IPAddress
 		serverRegistry, customErr := whoisAPI.Get(IPAddress)
 		if customErr != nil {
 			return []Server{}, customErr
@@ -47,10 +57,19 @@ func addServers(domain string) ([]Server, *wrappedErr.Error) {
 		countryCode, organization := assignRegistryData(serverRegistry)
 
 		var server = Server{
-			Address:  IPAddress,
-			SslGrade: hostSSLData.EndPoints[i].Grade,
-			Country:  countryCode,
-			Owner:    organization,
+			Address:           IPAddress,
+			SslGrade:          Grade(endPoint.Grade),
+			GradeTrustIgnored: Grade(endPoint.GradeTrustIgnored),
+			Country:           countryCode,
+			Owner:             organization,
+			ServerName:        endPoint.ServerName,
+			StatusMessage:     endPoint.StatusMessage,
+			HasWarnings:       endPoint.HasWarnings,
+			IsExceptional:     endPoint.IsExceptional,
+			Details:           endPoint.Details,
+			Vulnerabilities:   findVulnerabilities(endPoint.Details),
+			Certificate:       leafCertificate(endPoint.Details, hostSSLData.Certs),
+			Location:          locateServer(IPAddress),
 		}
 
 		servers = append(servers, server)
@@ -61,19 +80,80 @@ func addServers(domain string) ([]Server, *wrappedErr.Error) {
 
 }
 
-// getLowestGrade returns the lowest grade string from the array of servers
-func getLowestGrade(servers []Server) string {
+// locateServer returns where the given address is and which network it belongs to according to
+// the offline databases, or nil when they are not configured or do not know it
+func locateServer(address string) *geoip.Location {
+
+	location, err := geoip.Lookup(address)
+	if err != nil {
+		customErr := wrappedErr.New(http.StatusInternalServerError, "locateServer", fmt.Sprintf("Geolocation failed: %s", err.Error()))
+		log.Println(customErr)
+		return nil
+	}
+
+	return location
+
+}
+
+// leafCertificate returns the first certificate of the first chain an endpoint presents, looked
+// up among the certificates of the assessment, or nil when the endpoint has none
+func leafCertificate(details *sslAPI.EndPointDetails, certs []sslAPI.Cert) *Certificate {
+
+	if details == nil || len(details.CertChains) == 0 || len(details.CertChains[0].CertIDs) == 0 {
+		return nil
+	}
+
+	leafID := details.CertChains[0].CertIDs[0]
+
+	for _, cert := range certs {
+
+		if cert.ID != leafID {
+			continue
+		}
+
+		var names []string
+
+		seen := make(map[string]bool)
+
+		for _, list := range [][]string{cert.CommonNames, cert.AltNames} {
+			for _, name := range list {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+
+		return &Certificate{
+			Subject: cert.Subject,
+			Issuer:  cert.IssuerSubject,
+			Names:   names,
+		}
+
+	}
+
+	return nil
+
+}
+
+// getLowestGrade returns the lowest grade from the array of servers. Servers without a grade
+// are ignored unless none of them was graded, in which case NoGrade is returned
+func getLowestGrade(servers []Server) Grade {
 
-	var lowestGrade, currentLetter string
+	var lowestGrade, currentGrade Grade
 
-	lowestGrade = "A+"
+	lowestGrade = NoGrade
 
 	for i := 0; i < len(servers); i++ {
 
-		currentLetter = servers[i].SslGrade
+		currentGrade = servers[i].SslGrade
+
+		if !currentGrade.IsGraded() {
+			continue
+		}
 
-		if grades[lowestGrade] > grades[currentLetter] {
-			lowestGrade = currentLetter
+		if !lowestGrade.IsGraded() || currentGrade.Less(lowestGrade) {
+			lowestGrade = currentGrade
 		}
 
 	}
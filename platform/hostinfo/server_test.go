@@ -2,12 +2,18 @@ package hostinfo
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
+
+	sslAPI "domain-info-api/platform/ssllabs"
 )
 
 func TestGetLowestGrade(t *testing.T) {
 
-	assertLowestGrade := func(t *testing.T, got, want string) {
+	assertLowestGrade := func(t *testing.T, got, want Grade) {
 		t.Helper()
 
 		if got != want {
@@ -17,7 +23,7 @@ func TestGetLowestGrade(t *testing.T) {
 
 	var tests = []struct {
 		servers []Server
-		want    string
+		want    Grade
 	}{
 		{servers: []Server{
 			Server{
@@ -59,6 +65,23 @@ func TestGetLowestGrade(t *testing.T) {
 				Owner:    "Cloudflare, Inc.",
 			},
 		}, want: "A"},
+		{servers: []Server{
+			Server{Address: "server1", SslGrade: "A-"},
+			Server{Address: "server2", SslGrade: NoGrade},
+			Server{Address: "server3", SslGrade: "A+"},
+		}, want: "A-"},
+		{servers: []Server{
+			Server{Address: "server1", SslGrade: "F"},
+			Server{Address: "server2", SslGrade: "T"},
+		}, want: "T"},
+		{servers: []Server{
+			Server{Address: "server1", SslGrade: "M"},
+			Server{Address: "server2", SslGrade: "T"},
+		}, want: "M"},
+		{servers: []Server{
+			Server{Address: "server1", SslGrade: NoGrade},
+		}, want: NoGrade},
+		{servers: []Server{}, want: NoGrade},
 	}
 
 	for _, test := range tests {
@@ -70,3 +93,74 @@ func TestGetLowestGrade(t *testing.T) {
 	}
 
 }
+
+func TestLeafCertificate(t *testing.T) {
+
+	certs := []sslAPI.Cert{
+		{ID: "intermediate", Subject: "CN=R3, O=Let's Encrypt, C=US", IssuerSubject: "CN=ISRG Root X1, O=Internet Security Research Group, C=US"},
+		{ID: "leaf", Subject: "CN=example.com", IssuerSubject: "CN=R3, O=Let's Encrypt, C=US", CommonNames: []string{"example.com"}, AltNames: []string{"example.com", "*.example.com"}},
+	}
+
+	details := &sslAPI.EndPointDetails{CertChains: []sslAPI.CertChain{{ID: "chain", CertIDs: []string{"leaf", "intermediate"}}}}
+
+	want := &Certificate{Subject: "CN=example.com", Issuer: "CN=R3, O=Let's Encrypt, C=US", Names: []string{"example.com", "*.example.com"}}
+
+	if got := leafCertificate(details, certs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := leafCertificate(&sslAPI.EndPointDetails{}, certs); got != nil {
+		t.Errorf("got %+v for an endpoint without chain, want nil", got)
+	}
+
+}
+
+// analyzeReadyAssessment returns the response of an SSL Labs client polling a server that
+// answers with a READY assessment of test.com, certificates included when all=done is sent
+func analyzeReadyAssessment(t *testing.T) *sslAPI.Response {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Query().Get("all") != "done" {
+			fmt.Fprint(w, `{"host":"test.com","status":"READY","endpoints":[{"ipAddress":"1.1.1.1","statusMessage":"Ready","grade":"A"}]}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"host":"test.com","status":"READY",
+			"endpoints":[{"ipAddress":"1.1.1.1","statusMessage":"Ready","grade":"A","details":{"certChains":[{"id":"chain","certIds":["leaf","intermediate"]}]}}],
+			"certs":[
+				{"id":"leaf","subject":"CN=test.com","commonNames":["test.com"],"altNames":["test.com","www.test.com"],"issuerSubject":"CN=R3, O=Let's Encrypt, C=US"},
+				{"id":"intermediate","subject":"CN=R3, O=Let's Encrypt, C=US","issuerSubject":"CN=ISRG Root X1"}
+			]}`)
+
+	}))
+
+	defer server.Close()
+
+	client := sslAPI.NewClient()
+	client.BaseURL = server.URL
+	client.PollInterval = time.Millisecond
+
+	response, customErr := client.Analyze("test.com", sslAPI.Options{})
+	if customErr != nil {
+		t.Fatalf("didn't expect an error: %s", customErr)
+	}
+
+	return response
+
+}
+
+func TestLeafCertificateFromReadyAssessment(t *testing.T) {
+
+	response := analyzeReadyAssessment(t)
+
+	certificate := leafCertificate(response.EndPoints[0].Details, response.Certs)
+	if certificate == nil {
+		t.Fatal("got no certificate for a READY assessment, want the leaf certificate")
+	}
+
+	if certificate.Issuer != "CN=R3, O=Let's Encrypt, C=US" || len(certificate.Names) != 2 {
+		t.Errorf("got %+v, want the leaf certificate of test.com", certificate)
+	}
+
+}
@@ -0,0 +1,225 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/subdomains"
+)
+
+// Outcomes of the subdomain analysis requested along with a posted domain
+const (
+	SubdomainsQueued         = "queued"
+	SubdomainsNoneFound      = "none_found"
+	SubdomainsQueueFull      = "queue_full"
+	SubdomainDiscoveryFailed = "discovery_failed"
+)
+
+// subdomainLimit returns how many discovered subdomains are analyzed for a single domain
+func subdomainLimit() int {
+
+	if limit, err := strconv.Atoi(os.Getenv("SUBDOMAIN_LIMIT")); err == nil && limit >= 0 {
+		return limit
+	}
+
+	return 10
+
+}
+
+// certificateNames returns the names covered by the certificates found while analyzing a host
+func certificateNames(host Host) []string {
+
+	var names []string
+
+	for _, cert := range host.Assessment.Certs {
+		names = append(names, cert.CommonNames...)
+		names = append(names, cert.AltNames...)
+	}
+
+	for _, server := range host.Servers {
+		if server.Certificate != nil {
+			names = append(names, server.Certificate.Names...)
+		}
+	}
+
+	return names
+
+}
+
+// DiscoverSubdomains enumerates the subdomains of a tracked domain from the names of its
+// certificates, its DNS records and the wordlist, and stores them on its row
+func (c *Connection) DiscoverSubdomains(domain *Domain) ([]subdomains.Subdomain, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	found, err := subdomains.Discover(domain.Name, certificateNames(domain.HostInfo), domain.HostInfo.DNS)
+	if err != nil {
+		errMessage := fmt.Sprintf("Subdomain discovery failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "DiscoverSubdomains", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	if found == nil {
+		found = []subdomains.Subdomain{}
+	}
+
+	encoded, customErr := encodeJSONB(found, "DiscoverSubdomains")
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	stmt, err := c.DB.Prepare(`UPDATE host SET subdomains = $1 WHERE host.domain_name = $2`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "DiscoverSubdomains", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(encoded, domain.Name)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "DiscoverSubdomains", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	return found, nil
+
+}
+
+// subdomainJob represents the discovered subdomains of a domain waiting to be analyzed
+type subdomainJob struct {
+	parentName string
+	found      []subdomains.Subdomain
+}
+
+// subdomainQueueSize is how many domains may wait for their subdomains to be analyzed
+const subdomainQueueSize = 100
+
+// subdomainWorkers returns how many domains have their subdomains analyzed at the same time
+func subdomainWorkers() int {
+
+	if workers, err := strconv.Atoi(os.Getenv("SUBDOMAIN_WORKERS")); err == nil && workers > 0 {
+		return workers
+	}
+
+	return 2
+
+}
+
+// QueueSubdomains schedules the analysis of the discovered subdomains of a domain on a fixed
+// pool of workers, started on first use. The subdomains are skipped, and false returned, when
+// too many domains are already waiting
+func (c *Connection) QueueSubdomains(parentName string, found []subdomains.Subdomain) bool {
+
+	c.subdomainWorkers.Do(func() {
+
+		c.subdomainJobs = make(chan subdomainJob, subdomainQueueSize)
+
+		for i := 0; i < subdomainWorkers(); i++ {
+			go func() {
+				for job := range c.subdomainJobs {
+					c.AnalyzeSubdomains(job.parentName, job.found)
+				}
+			}()
+		}
+
+	})
+
+	select {
+	case c.subdomainJobs <- subdomainJob{parentName: parentName, found: found}:
+		return true
+	default:
+		errMessage := fmt.Sprintf("Subdomain analysis queue is full, skipping the subdomains of %s", parentName)
+		customErr := wrappedErr.New(http.StatusServiceUnavailable, "QueueSubdomains", errMessage)
+		log.Println(customErr)
+		return false
+	}
+
+}
+
+// AnalyzeSubdomains runs the discovered subdomains of a domain through the same analysis as any
+// other domain, until SUBDOMAIN_LIMIT of them are analyzed, and links their rows to the parent
+// one. Subdomains failing to be analyzed are logged and skipped without counting toward the limit
+func (c *Connection) AnalyzeSubdomains(parentName string, found []subdomains.Subdomain) {
+
+	limit := subdomainLimit()
+	analyzed := 0
+
+	for _, subdomain := range found {
+
+		if analyzed >= limit {
+			break
+		}
+
+		_, exists, customErr := c.CheckDomainExists(subdomain.Name)
+		if customErr != nil {
+			continue
+		}
+
+		if !exists {
+
+			domain, customErr := NewDomain(subdomain.Name)
+			if customErr != nil {
+				continue
+			}
+
+			if customErr = c.InsertDomain(domain); customErr != nil {
+				continue
+			}
+
+		}
+
+		if customErr = c.linkParent(subdomain.Name, parentName); customErr != nil {
+			continue
+		}
+
+		analyzed++
+
+	}
+
+}
+
+// linkParent records the domain a subdomain was discovered under
+func (c *Connection) linkParent(domainName, parentName string) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	stmt, err := c.DB.Prepare(`
+	UPDATE host
+	SET parent_id = (SELECT parent.id FROM host AS parent WHERE parent.domain_name = $1)
+	WHERE host.domain_name = $2
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "AnalyzeSubdomains", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(parentName, domainName)
+	if err != nil {
+		errMessage := fmt.Sprintf("Linking %s to %s failed: %s", domainName, parentName, err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "AnalyzeSubdomains", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+// GetSubdomains returns the tracked domains discovered under the given domain
+func (c *Connection) GetSubdomains(domainName string) (*Items, *wrappedErr.Error) {
+	return c.queryDomains("GetSubdomains", "SELECT "+hostColumns+" FROM host WHERE host.parent_id IN (SELECT parent.id FROM host AS parent WHERE parent.domain_name=$1)", domainName)
+}
@@ -0,0 +1,57 @@
+package hostinfo
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"domain-info-api/platform/subdomains"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCertificateNamesFromReadyAssessment(t *testing.T) {
+
+	response := analyzeReadyAssessment(t)
+
+	host := Host{
+		Servers: []Server{{Address: "1.1.1.1", Certificate: leafCertificate(response.EndPoints[0].Details, response.Certs)}},
+	}
+
+	names := make(map[string]bool)
+
+	for _, name := range certificateNames(host) {
+		names[name] = true
+	}
+
+	if !names["www.test.com"] {
+		t.Errorf("got %v, want the names of the certificate served to seed subdomain discovery", certificateNames(host))
+	}
+
+}
+
+func TestAnalyzeSubdomainsSkipsFailuresWithoutCountingThem(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	os.Setenv("SUBDOMAIN_LIMIT", "1")
+	defer os.Unsetenv("SUBDOMAIN_LIMIT")
+
+	found := []subdomains.Subdomain{{Name: "a.example.com"}, {Name: "b.example.com"}, {Name: "c.example.com"}}
+
+	for range found {
+		mock.ExpectPrepare("SELECT").WillReturnError(errors.New("connection lost"))
+	}
+
+	connection := Connection{DB: db}
+	connection.AnalyzeSubdomains("example.com", found)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("got %s, want every subdomain tried once the earlier ones failed", err)
+	}
+
+}
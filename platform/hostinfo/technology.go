@@ -0,0 +1,55 @@
+package hostinfo
+
+import (
+	"fmt"
+
+	"domain-info-api/platform/fingerprint"
+)
+
+// Kinds of change events recorded when the technologies detected on a website change
+const (
+	TechnologyDetected       = "technology_detected"
+	TechnologyRemoved        = "technology_removed"
+	TechnologyVersionChanged = "technology_version_changed"
+)
+
+// diffTechnologies returns the change events between the technologies detected on two
+// analyses. Nothing is reported when either analysis could not fingerprint the website
+func diffTechnologies(oldTechnologies, newTechnologies []fingerprint.Technology) []ChangeEvent {
+
+	var events []ChangeEvent
+
+	if oldTechnologies == nil || newTechnologies == nil {
+		return events
+	}
+
+	oldVersions := make(map[string]string)
+
+	for _, technology := range oldTechnologies {
+		oldVersions[technology.Name] = technology.Version
+	}
+
+	for _, technology := range newTechnologies {
+
+		oldVersion, exists := oldVersions[technology.Name]
+
+		switch {
+		case !exists:
+			events = append(events, newChangeEvent(TechnologyDetected, technology.Name, technology.Version))
+		case oldVersion != technology.Version && oldVersion != "" && technology.Version != "":
+			events = append(events, newChangeEvent(TechnologyVersionChanged, technology.Name, fmt.Sprintf("%s -> %s", oldVersion, technology.Version)))
+		}
+
+		delete(oldVersions, technology.Name)
+
+	}
+
+	for name, version := range oldVersions {
+		events = append(events, newChangeEvent(TechnologyRemoved, name, version))
+	}
+
+	sortChangeEvents(events)
+
+	return events
+
+}
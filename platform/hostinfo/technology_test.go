@@ -0,0 +1,48 @@
+package hostinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"domain-info-api/platform/fingerprint"
+)
+
+func TestDiffTechnologies(t *testing.T) {
+
+	oldTechnologies := []fingerprint.Technology{
+		{Name: "Nginx", Version: "1.18.0"},
+		{Name: "PHP"},
+		{Name: "WordPress", Version: "5.4"},
+	}
+
+	newTechnologies := []fingerprint.Technology{
+		{Name: "Cloudflare"},
+		{Name: "Nginx", Version: "1.20.1"},
+		{Name: "WordPress"},
+	}
+
+	var got [][3]string
+
+	for _, event := range diffTechnologies(oldTechnologies, newTechnologies) {
+		got = append(got, [3]string{event.Kind, event.Subject, event.Detail})
+	}
+
+	want := [][3]string{
+		{TechnologyDetected, "Cloudflare", ""},
+		{TechnologyRemoved, "PHP", ""},
+		{TechnologyVersionChanged, "Nginx", "1.18.0 -> 1.20.1"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if events := diffTechnologies(nil, newTechnologies); len(events) != 0 {
+		t.Errorf("got %v, want no events without a previous detection", events)
+	}
+
+	if events := diffTechnologies(oldTechnologies, nil); len(events) != 0 {
+		t.Errorf("got %v, want no events without a current detection", events)
+	}
+
+}
@@ -0,0 +1,216 @@
+package hostinfo
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+	sslAPI "domain-info-api/platform/ssllabs"
+)
+
+// Names of the TLS vulnerabilities reported for a server
+const (
+	Heartbleed  = "heartbleed"
+	PoodleSSL   = "poodle_ssl"
+	PoodleTLS   = "poodle_tls"
+	Freak       = "freak"
+	Logjam      = "logjam"
+	Drown       = "drown"
+	Robot       = "robot"
+	OpenSSLCCS  = "openssl_ccs"
+	Ticketbleed = "ticketbleed"
+	RC4         = "rc4"
+	TripleDES   = "3des"
+	WeakDH      = "weak_dh"
+)
+
+// Vulnerabilities holds every vulnerability name accepted by the API
+var Vulnerabilities = map[string]bool{
+	Heartbleed:  true,
+	PoodleSSL:   true,
+	PoodleTLS:   true,
+	Freak:       true,
+	Logjam:      true,
+	Drown:       true,
+	Robot:       true,
+	OpenSSLCCS:  true,
+	Ticketbleed: true,
+	RC4:         true,
+	TripleDES:   true,
+	WeakDH:      true,
+}
+
+const minimumDHStrength = 2048
+
+// findVulnerabilities returns the sorted names of the vulnerabilities found in the endpoint details
+func findVulnerabilities(details *sslAPI.EndPointDetails) []string {
+
+	var found []string
+
+	if details == nil {
+		return found
+	}
+
+	flags := map[string]bool{
+		Heartbleed:  details.Heartbleed,
+		PoodleSSL:   details.Poodle,
+		PoodleTLS:   details.PoodleTLS == 2,
+		Freak:       details.Freak,
+		Logjam:      details.Logjam,
+		Drown:       details.DrownVulnerable,
+		Robot:       details.Bleichenbacher == 2 || details.Bleichenbacher == 3,
+		OpenSSLCCS:  details.OpenSslCcs == 3,
+		Ticketbleed: details.Ticketbleed == 2,
+		RC4:         details.SupportsRc4,
+		TripleDES:   false,
+		WeakDH:      details.DhUsesKnownPrimes == 2,
+	}
+
+	for _, protocolSuites := range details.Suites {
+
+		for _, suite := range protocolSuites.List {
+
+			if strings.Contains(suite.Name, "3DES") || strings.Contains(suite.Name, "DES_EDE") {
+				flags[TripleDES] = true
+			}
+
+			if suite.KxType == "DH" && suite.KxStrength > 0 && suite.KxStrength < minimumDHStrength {
+				flags[WeakDH] = true
+			}
+
+		}
+
+	}
+
+	for name, vulnerable := range flags {
+		if vulnerable {
+			found = append(found, name)
+		}
+	}
+
+	sort.Strings(found)
+
+	return found
+
+}
+
+// diffVulnerabilities returns the change events for the vulnerabilities that appeared
+// or disappeared on each server found in both analyses. Servers that were added or removed
+// are reported through ServersChanged instead, their findings telling nothing about a fix
+func diffVulnerabilities(oldServers, newServers []Server) []ChangeEvent {
+
+	var events []ChangeEvent
+
+	oldFindings := vulnerabilitiesByAddress(oldServers)
+	newFindings := vulnerabilitiesByAddress(newServers)
+
+	for address, names := range newFindings {
+
+		if oldFindings[address] == nil {
+			continue
+		}
+
+		for name := range names {
+			if !oldFindings[address][name] {
+				events = append(events, newChangeEvent(VulnerabilityFound, address, name))
+			}
+		}
+
+	}
+
+	for address, names := range oldFindings {
+
+		if newFindings[address] == nil {
+			continue
+		}
+
+		for name := range names {
+			if !newFindings[address][name] {
+				events = append(events, newChangeEvent(VulnerabilityResolved, address, name))
+			}
+		}
+
+	}
+
+	sortChangeEvents(events)
+
+	return events
+
+}
+
+func vulnerabilitiesByAddress(servers []Server) map[string]map[string]bool {
+
+	findings := make(map[string]map[string]bool)
+
+	for _, server := range servers {
+
+		if findings[server.Address] == nil {
+			findings[server.Address] = make(map[string]bool)
+		}
+
+		for _, name := range server.Vulnerabilities {
+			findings[server.Address][name] = true
+		}
+
+	}
+
+	return findings
+
+}
+
+// getAllVulnerabilities returns from the database the vulnerabilities of a given host id by server address
+func (c *Connection) getAllVulnerabilities(hostID int) (map[string][]string, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	findings := make(map[string][]string)
+
+	stmt, err := c.DB.Prepare(`
+	SELECT
+		vulnerability.address, vulnerability.name
+	FROM
+		vulnerability
+	WHERE
+		vulnerability.host_id=$1
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "getAllVulnerabilities", errMessage)
+		log.Println(customErr)
+		return findings, customErr
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "getAllVulnerabilities", errMessage)
+		log.Println(customErr)
+		return findings, customErr
+	}
+
+	defer rows.Close()
+
+	var address, name string
+
+	for rows.Next() {
+
+		err := rows.Scan(&address, &name)
+		if err != nil {
+			errMessage := fmt.Sprintf("Row scan failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "getAllVulnerabilities", errMessage)
+			log.Println(customErr)
+			return findings, customErr
+		}
+
+		findings[address] = append(findings[address], name)
+
+	}
+
+	return findings, nil
+
+}
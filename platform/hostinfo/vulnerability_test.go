@@ -0,0 +1,73 @@
+package hostinfo
+
+import (
+	"reflect"
+	"testing"
+
+	sslAPI "domain-info-api/platform/ssllabs"
+)
+
+func TestFindVulnerabilities(t *testing.T) {
+
+	var tests = []struct {
+		name    string
+		details *sslAPI.EndPointDetails
+		want    []string
+	}{
+		{name: "no details", details: nil, want: nil},
+		{name: "clean endpoint", details: &sslAPI.EndPointDetails{PoodleTLS: 1, Bleichenbacher: 1, OpenSslCcs: 1, Ticketbleed: 1}, want: nil},
+		{name: "flagged endpoint", details: &sslAPI.EndPointDetails{
+			Heartbleed:     true,
+			PoodleTLS:      2,
+			Bleichenbacher: 3,
+			OpenSslCcs:     3,
+			Suites: []sslAPI.ProtocolSuites{
+				{List: []sslAPI.Suite{
+					{Name: "TLS_RSA_WITH_3DES_EDE_CBC_SHA", KxType: "RSA", KxStrength: 2048},
+					{Name: "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", KxType: "DH", KxStrength: 1024},
+				}},
+			},
+		}, want: []string{TripleDES, Heartbleed, OpenSSLCCS, PoodleTLS, Robot, WeakDH}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := findVulnerabilities(test.details)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+}
+
+func TestDiffVulnerabilities(t *testing.T) {
+
+	oldServers := []Server{
+		{Address: "server1", Vulnerabilities: []string{Heartbleed, RC4}},
+		{Address: "server2", Vulnerabilities: []string{Logjam}},
+	}
+
+	newServers := []Server{
+		{Address: "server1", Vulnerabilities: []string{RC4, Freak}},
+		{Address: "server3", Vulnerabilities: []string{Drown}},
+	}
+
+	events := diffVulnerabilities(oldServers, newServers)
+
+	var got [][3]string
+
+	for _, event := range events {
+		got = append(got, [3]string{event.Kind, event.Subject, event.Detail})
+	}
+
+	want := [][3]string{
+		{VulnerabilityFound, "server1", Freak},
+		{VulnerabilityResolved, "server1", Heartbleed},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+}
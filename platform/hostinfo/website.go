@@ -0,0 +1,190 @@
+package hostinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/fingerprint"
+	"domain-info-api/platform/securityheaders"
+	scraping "domain-info-api/platform/webscraping"
+)
+
+// websiteState represents the stored data of a website that is compared against a new
+// scrape to detect changes
+type websiteState struct {
+	LogoHash        string
+	SecurityHeaders securityheaders.Report
+	Technologies    []fingerprint.Technology
+	Content         scraping.Content
+}
+
+// decode fills the state with the stored JSONB columns of a host
+func (s *websiteState) decode(securityHeaders, technologies, content []byte) error {
+
+	if len(securityHeaders) > 0 {
+		if err := json.Unmarshal(securityHeaders, &s.SecurityHeaders); err != nil {
+			return err
+		}
+	}
+
+	if len(technologies) > 0 {
+		if err := json.Unmarshal(technologies, &s.Technologies); err != nil {
+			return err
+		}
+	}
+
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &s.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// refreshWebsiteInfo stores the scraped data of a host analyzed again along with its logo,
+// returning the changes found against the previously stored state. When robots.txt no
+// longer allows scraping the homepage, only the robots.txt report is updated
+func (c *Connection) refreshWebsiteInfo(hostID int, domainName string, siteInfo scraping.WebsiteInfo, previous websiteState) ([]ChangeEvent, *wrappedErr.Error) {
+
+	var changes []ChangeEvent
+
+	robots, customErr := encodeJSONB(siteInfo.Robots, "CheckDomainExists")
+	if customErr != nil {
+		return changes, customErr
+	}
+
+	if !siteInfo.Robots.HomepageAllowed {
+
+		stmt, err := c.DB.Prepare(`UPDATE host SET robots = $1 WHERE host.id = $2`)
+		if err != nil {
+			errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+			log.Println(customErr)
+			return changes, customErr
+		}
+
+		defer stmt.Close()
+
+		_, err = stmt.Exec(robots, hostID)
+		if err != nil {
+			errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+			customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+			log.Println(customErr)
+			return changes, customErr
+		}
+
+		return changes, nil
+
+	}
+
+	logo := fetchLogo(siteInfo.Logo)
+
+	logoHash := previous.LogoHash
+	logoChanged := false
+
+	if logo != nil {
+
+		logoHash = logo.Hash
+		logoChanged = previous.LogoHash != "" && logo.Hash != previous.LogoHash
+
+		customErr = c.saveLogo(domainName, logo)
+		if customErr != nil {
+			return changes, customErr
+		}
+
+	}
+
+	if logoChanged {
+		changes = append(changes, newChangeEvent(LogoChanged, siteInfo.Logo, fmt.Sprintf("%s -> %s", previous.LogoHash, logoHash)))
+	}
+
+	securityHeadersReport := auditSecurityHeaders(siteInfo)
+
+	changes = append(changes, diffSecurityHeaders(previous.SecurityHeaders, securityHeadersReport)...)
+	changes = append(changes, diffTechnologies(previous.Technologies, siteInfo.Technologies)...)
+
+	contentChanged, contentSimilarity := compareContent(previous.Content, siteInfo.Content)
+
+	changes = append(changes, contentChangeEvents(contentChanged, contentSimilarity, siteInfo.Content)...)
+
+	metadata, customErr := encodeJSONB(siteInfo.Metadata, "CheckDomainExists")
+	if customErr != nil {
+		return changes, customErr
+	}
+
+	redirects, customErr := encodeJSONB(siteInfo.Redirects, "CheckDomainExists")
+	if customErr != nil {
+		return changes, customErr
+	}
+
+	securityHeaders, customErr := encodeJSONB(securityHeadersReport, "CheckDomainExists")
+	if customErr != nil {
+		return changes, customErr
+	}
+
+	// the stored technologies are kept when the website could not be fingerprinted
+	var technologies interface{}
+
+	if siteInfo.Technologies != nil {
+
+		detected, customErr := encodeJSONB(siteInfo.Technologies, "CheckDomainExists")
+		if customErr != nil {
+			return changes, customErr
+		}
+
+		technologies = detected
+
+	}
+
+	content, customErr := encodeJSONB(siteInfo.Content, "CheckDomainExists")
+	if customErr != nil {
+		return changes, customErr
+	}
+
+	stmt, err := c.DB.Prepare(`
+	UPDATE host
+	SET logo = $1,
+			title = $2,
+			metadata = $3,
+			logo_hash = $4,
+			logo_changed = $5,
+			redirects = $6,
+			security_headers = $7,
+			technologies = COALESCE($8, host.technologies),
+			robots = $9,
+			content = $10,
+			content_changed = $11,
+			content_similarity = $12
+	WHERE
+		host.id = $13
+	`)
+	if err != nil {
+		errMessage := fmt.Sprintf("Invalid query statement: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return changes, customErr
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(siteInfo.Logo, strings.TrimSpace(siteInfo.Title), metadata, logoHash, logoChanged, redirects, securityHeaders, technologies, robots,
+		content, contentChanged, contentSimilarity, hostID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "CheckDomainExists", errMessage)
+		log.Println(customErr)
+		return changes, customErr
+	}
+
+	customErr = c.insertContentHistory(hostID, siteInfo.Content, contentSimilarity, time.Now(), "CheckDomainExists")
+
+	return changes, customErr
+
+}
@@ -0,0 +1,45 @@
+package hostinfo
+
+import (
+	"testing"
+
+	scraping "domain-info-api/platform/webscraping"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRefreshWebsiteInfoStoresMetadata(t *testing.T) {
+
+	db, mock := newMock()
+	defer db.Close()
+
+	siteInfo := scraping.WebsiteInfo{
+		Title:    "Example",
+		Metadata: scraping.Metadata{Description: "An example website", Language: "en"},
+		Robots:   scraping.Robots{HomepageAllowed: true},
+	}
+
+	metadata, customErr := encodeJSONB(siteInfo.Metadata, "TestRefreshWebsiteInfoStoresMetadata")
+	if customErr != nil {
+		t.Fatal(customErr)
+	}
+
+	query := "UPDATE host SET logo = $1, title = $2, metadata = $3, logo_hash = $4, logo_changed = $5, redirects = $6, security_headers = $7, technologies = COALESCE($8, host.technologies), robots = $9, content = $10, content_changed = $11, content_similarity = $12 WHERE host.id = $13"
+
+	mock.ExpectPrepare(query).ExpectExec().
+		WithArgs("", "Example", metadata, sqlmock.AnyArg(), false, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	connection := Connection{DB: db}
+
+	_, customErr = connection.refreshWebsiteInfo(1, "example.com", siteInfo, websiteState{})
+	if customErr != nil {
+		t.Fatalf("unexpected error: %s", customErr)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+}
@@ -0,0 +1,163 @@
+package logostore
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// DefaultMaxSize is the largest logo accepted when LOGO_MAX_SIZE is not set
+const DefaultMaxSize = 512 << 10
+
+// Logo represents a downloaded logo along with the hash of its content
+type Logo struct {
+	Data        []byte
+	ContentType string
+	Hash        string
+	SourceURL   string
+	FetchedAt   time.Time
+}
+
+// Store represents a place where the logo of each domain is kept. Load returns a nil
+// Logo without error when no logo is stored for the domain
+type Store interface {
+	Save(domainName string, logo *Logo) *wrappedErr.Error
+	Load(domainName string) (*Logo, *wrappedErr.Error)
+}
+
+// allowedContentTypes holds the image formats browsers use as favicons and logos
+var allowedContentTypes = map[string]bool{
+	"image/png":                true,
+	"image/jpeg":               true,
+	"image/gif":                true,
+	"image/webp":               true,
+	"image/svg+xml":            true,
+	"image/x-icon":             true,
+	"image/vnd.microsoft.icon": true,
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewStore returns the store selected by the LOGO_STORE variable: "filesystem" keeps the
+// logos under LOGO_DIR, anything else keeps them in the database
+func NewStore(db *sql.DB) Store {
+
+	if os.Getenv("LOGO_STORE") == "filesystem" {
+
+		dir := os.Getenv("LOGO_DIR")
+		if dir == "" {
+			dir = "logos"
+		}
+
+		return &FileStore{Dir: dir}
+
+	}
+
+	return &DBStore{DB: db}
+
+}
+
+// maxSize returns the largest logo size accepted, configured through LOGO_MAX_SIZE in bytes
+func maxSize() int64 {
+
+	if size, err := strconv.ParseInt(os.Getenv("LOGO_MAX_SIZE"), 10, 64); err == nil && size > 0 {
+		return size
+	}
+
+	return DefaultMaxSize
+
+}
+
+// Fetch downloads the logo found at the given URL, rejecting anything that is not an
+// image or that is larger than the configured maximum size
+func Fetch(logoURL string) (*Logo, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	response, err := httpClient.Get(logoURL)
+	if err != nil {
+		errMessage := fmt.Sprintf("Logo download failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusBadGateway, "Fetch", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		errMessage := fmt.Sprintf("Logo download failed with status %d", response.StatusCode)
+		customErr = wrappedErr.New(http.StatusBadGateway, "Fetch", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	limit := maxSize()
+
+	data, err := ioutil.ReadAll(io.LimitReader(response.Body, limit+1))
+	if err != nil {
+		errMessage := fmt.Sprintf("Logo download failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusBadGateway, "Fetch", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	if int64(len(data)) > limit {
+		errMessage := fmt.Sprintf("Logo is larger than %d bytes", limit)
+		customErr = wrappedErr.New(http.StatusUnprocessableEntity, "Fetch", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	contentType := detectContentType(response.Header.Get("Content-Type"), data)
+
+	if !allowedContentTypes[contentType] {
+		errMessage := fmt.Sprintf("Logo has an unsupported content type: %s", contentType)
+		customErr = wrappedErr.New(http.StatusUnprocessableEntity, "Fetch", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	hash := sha256.Sum256(data)
+
+	return &Logo{
+		Data:        data,
+		ContentType: contentType,
+		Hash:        hex.EncodeToString(hash[:]),
+		SourceURL:   logoURL,
+		FetchedAt:   time.Now(),
+	}, nil
+
+}
+
+// detectContentType trusts the declared image type of the response and sniffs the content
+// otherwise, since many servers answer favicons with generic types
+func detectContentType(declared string, data []byte) string {
+
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err == nil && strings.HasPrefix(mediaType, "image/") {
+		return mediaType
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+
+	if sniffed == "text/xml" || sniffed == "text/plain" {
+		if strings.Contains(string(data), "<svg") {
+			return "image/svg+xml"
+		}
+	}
+
+	return sniffed
+
+}
@@ -0,0 +1,91 @@
+package logostore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFetch(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(pngData)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(make([]byte, DefaultMaxSize+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("sniffs and hashes an image", func(t *testing.T) {
+
+		logo, customErr := Fetch(server.URL + "/logo.png")
+		if customErr != nil {
+			t.Fatalf("got an error, but didn't want one: %v", customErr)
+		}
+
+		if logo.ContentType != "image/png" {
+			t.Errorf("got content type %q, want image/png", logo.ContentType)
+		}
+
+		if len(logo.Hash) != 64 {
+			t.Errorf("got hash %q, want a sha256 hex digest", logo.Hash)
+		}
+
+	})
+
+	for _, path := range []string{"/page.html", "/huge.png", "/missing.png"} {
+		t.Run("rejects "+strings.TrimPrefix(path, "/"), func(t *testing.T) {
+			if _, customErr := Fetch(server.URL + path); customErr == nil {
+				t.Error("wanted an error, but didn't get one")
+			}
+		})
+	}
+
+}
+
+func TestFileStore(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "logos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &FileStore{Dir: dir}
+
+	logo, customErr := store.Load("example.com")
+	if customErr != nil || logo != nil {
+		t.Fatalf("got %v, %v, want no logo and no error", logo, customErr)
+	}
+
+	want := &Logo{Data: pngData, ContentType: "image/png", Hash: "abc", SourceURL: "https://example.com/logo.png"}
+
+	if customErr := store.Save("example.com", want); customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	got, customErr := store.Load("example.com")
+	if customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+}
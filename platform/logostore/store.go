@@ -0,0 +1,160 @@
+package logostore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+)
+
+// DBStore keeps the logos in the "logo" table
+type DBStore struct {
+	DB *sql.DB
+}
+
+// FileStore keeps the logos in a directory, one content file and one metadata file per domain
+type FileStore struct {
+	Dir string
+}
+
+// fileMetadata represents what the FileStore keeps next to the content of a logo
+type fileMetadata struct {
+	ContentType string    `json:"content_type"`
+	Hash        string    `json:"hash"`
+	SourceURL   string    `json:"source_url"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Save inserts or replaces the logo of the given domain
+func (s *DBStore) Save(domainName string, logo *Logo) *wrappedErr.Error {
+
+	_, err := s.DB.Exec(`
+	UPSERT INTO
+		logo (domain_name, data, content_type, hash, source_url, fetched_at)
+	VALUES
+		($1, $2, $3, $4, $5, $6)
+	`, domainName, logo.Data, logo.ContentType, logo.Hash, logo.SourceURL, logo.FetchedAt)
+	if err != nil {
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr := wrappedErr.New(http.StatusInternalServerError, "Save", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+// Load returns the logo of the given domain
+func (s *DBStore) Load(domainName string) (*Logo, *wrappedErr.Error) {
+
+	var logo Logo
+
+	err := s.DB.QueryRow(`
+	SELECT
+		logo.data, logo.content_type, logo.hash, logo.source_url, logo.fetched_at
+	FROM
+		logo
+	WHERE
+		logo.domain_name = $1
+	`, domainName).Scan(&logo.Data, &logo.ContentType, &logo.Hash, &logo.SourceURL, &logo.FetchedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		errMessage := fmt.Sprintf("Query operation failed: %s", err.Error())
+		customErr := wrappedErr.New(http.StatusInternalServerError, "Load", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	return &logo, nil
+
+}
+
+// Save writes the logo of the given domain, replacing the previous one
+func (s *FileStore) Save(domainName string, logo *Logo) *wrappedErr.Error {
+
+	var customErr *wrappedErr.Error
+
+	metadata, err := json.Marshal(fileMetadata{
+		ContentType: logo.ContentType,
+		Hash:        logo.Hash,
+		SourceURL:   logo.SourceURL,
+		FetchedAt:   logo.FetchedAt,
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "Save", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	err = os.MkdirAll(s.Dir, 0755)
+	if err == nil {
+		err = ioutil.WriteFile(s.path(domainName, ".logo"), logo.Data, 0644)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(s.path(domainName, ".json"), metadata, 0644)
+	}
+	if err != nil {
+		errMessage := fmt.Sprintf("Logo write failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "Save", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	return nil
+
+}
+
+// Load reads the logo of the given domain
+func (s *FileStore) Load(domainName string) (*Logo, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+	var metadata fileMetadata
+
+	content, err := ioutil.ReadFile(s.path(domainName, ".json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(content, &metadata)
+	}
+	if err != nil {
+		errMessage := fmt.Sprintf("Logo read failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "Load", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	data, err := ioutil.ReadFile(s.path(domainName, ".logo"))
+	if err != nil {
+		errMessage := fmt.Sprintf("Logo read failed: %s", err.Error())
+		customErr = wrappedErr.New(http.StatusInternalServerError, "Load", errMessage)
+		log.Println(customErr)
+		return nil, customErr
+	}
+
+	return &Logo{
+		Data:        data,
+		ContentType: metadata.ContentType,
+		Hash:        metadata.Hash,
+		SourceURL:   metadata.SourceURL,
+		FetchedAt:   metadata.FetchedAt,
+	}, nil
+
+}
+
+// path returns the file holding the given part of the logo of a domain. Domain names never
+// contain path separators once validated, but the base name is taken to stay inside Dir
+func (s *FileStore) path(domainName, extension string) string {
+	return filepath.Join(s.Dir, filepath.Base(domainName)+extension)
+}
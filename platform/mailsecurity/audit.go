@@ -0,0 +1,205 @@
+package mailsecurity
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Names of the checks of an audit
+const (
+	SPF    = "SPF"
+	DMARC  = "DMARC"
+	DKIM   = "DKIM"
+	MTASTS = "MTA-STS"
+	TLSRPT = "TLS-RPT"
+	BIMI   = "BIMI"
+)
+
+// DefaultSelectors holds the DKIM selectors probed when MAIL_DKIM_SELECTORS is not set,
+// covering the usual defaults of the most common mail providers
+var DefaultSelectors = []string{"default", "google", "selector1", "selector2", "k1", "k2", "s1", "s2", "mail", "dkim", "smtp", "mandrill", "everlytickey1", "mxvault"}
+
+// weights holds how much each check contributes to a score of 100
+var weights = map[string]int{
+	SPF:    25,
+	DMARC:  30,
+	DKIM:   20,
+	MTASTS: 15,
+	TLSRPT: 5,
+	BIMI:   5,
+}
+
+// Report represents the audit of the mail security records published by a domain
+type Report struct {
+	Grade    string          `json:"grade"`
+	Score    int             `json:"score"`
+	SPF      *SPFRecord      `json:"spf"`
+	DMARC    *DMARCRecord    `json:"dmarc"`
+	DKIM     []DKIMKey       `json:"dkim"`
+	MTASTS   *MTASTSRecord   `json:"mta_sts"`
+	TLSRPT   *TLSRPTRecord   `json:"tls_rpt"`
+	BIMI     *BIMIRecord     `json:"bimi"`
+	Findings []audit.Finding `json:"findings"`
+}
+
+// Resolver represents what the audit needs to query DNS records
+type Resolver interface {
+	Query(name string, recordType dnsmessage.Type) ([]dnsrecords.Record, error)
+}
+
+// Auditor represents a client able to audit the mail security of a domain
+type Auditor struct {
+	Resolver  Resolver
+	Selectors []string
+	Client    *http.Client
+}
+
+// NewAuditor returns an Auditor querying the given resolver for the given DKIM selectors
+func NewAuditor(resolver Resolver, selectors []string) *Auditor {
+
+	return &Auditor{
+		Resolver:  resolver,
+		Selectors: selectors,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+
+}
+
+// Audit checks the mail security of the given domain with the configuration found in the environment
+func Audit(domain string, inventory dnsrecords.Inventory) Report {
+	return NewAuditor(dnsrecords.NewResolver(dnsrecords.ConfigFromEnv()), SelectorsFromEnv()).Audit(domain, inventory)
+}
+
+// SelectorsFromEnv returns the DKIM selectors listed in MAIL_DKIM_SELECTORS, separated by
+// commas, or DefaultSelectors when it is not set
+func SelectorsFromEnv() []string {
+
+	var selectors []string
+
+	for _, selector := range strings.Split(os.Getenv("MAIL_DKIM_SELECTORS"), ",") {
+		if selector = strings.TrimSpace(selector); selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+
+	if len(selectors) == 0 {
+		return DefaultSelectors
+	}
+
+	return selectors
+
+}
+
+// Audit checks every mail security record of the domain and grades them. The SPF record is
+// taken from the TXT records of the inventory when they could be queried
+func (a *Auditor) Audit(domain string, inventory dnsrecords.Inventory) Report {
+
+	report := Report{DKIM: []DKIMKey{}}
+
+	var spfFinding, dmarcFinding, dkimFinding, mtaSTSFinding, tlsRPTFinding, bimiFinding audit.Finding
+
+	spfFinding, report.SPF = a.auditSPF(domain, inventory)
+	dmarcFinding, report.DMARC = a.auditDMARC(domain)
+	dkimFinding, report.DKIM = a.auditDKIM(domain)
+	mtaSTSFinding, report.MTASTS = a.auditMTASTS(domain)
+	tlsRPTFinding, report.TLSRPT = a.auditTLSRPT(domain)
+	bimiFinding, report.BIMI = a.auditBIMI(domain)
+
+	report.Findings = []audit.Finding{spfFinding, dmarcFinding, dkimFinding, mtaSTSFinding, tlsRPTFinding, bimiFinding}
+
+	report.Score = audit.Score(report.Findings, weights)
+	report.Grade = grade(report.Score)
+
+	return report
+
+}
+
+func grade(score int) string {
+
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 40:
+		return "D"
+	}
+
+	return "F"
+
+}
+
+// txtRecords returns the TXT records of a name starting with the given version tag
+func (a *Auditor) txtRecords(name, version string) ([]string, error) {
+
+	records, err := a.Resolver.Query(name, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+
+	return withVersion(records, version), nil
+
+}
+
+func withVersion(records []dnsrecords.Record, version string) []string {
+
+	var values []string
+
+	for _, record := range records {
+
+		value := strings.TrimSpace(record.Value)
+		lower := strings.ToLower(value)
+
+		if lower == strings.ToLower(version) || strings.HasPrefix(lower, strings.ToLower(version)+";") || strings.HasPrefix(lower, strings.ToLower(version)+" ") {
+			values = append(values, value)
+		}
+
+	}
+
+	return values
+
+}
+
+// parseTags returns the tags of a record such as "v=DMARC1; p=reject" by lowercase name
+func parseTags(record string) map[string]string {
+
+	tags := make(map[string]string)
+
+	for _, tag := range strings.Split(record, ";") {
+
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		tags[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+
+	}
+
+	return tags
+
+}
+
+// splitURIs returns the URIs of a comma separated tag value
+func splitURIs(value string) []string {
+
+	uris := []string{}
+
+	for _, uri := range strings.Split(value, ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+
+	return uris
+
+}
@@ -0,0 +1,148 @@
+package mailsecurity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/dnsrecords"
+	"domain-info-api/platform/dnsrecords/dnstest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func dkimKey(t *testing.T, bits int) string {
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(der)
+
+}
+
+// policyServer serves an MTA-STS policy and returns a client reaching it for any host
+func policyServer(t *testing.T, policy string) (*http.Client, func()) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/mta-sts.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, policy)
+	}))
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			},
+		},
+	}
+
+	return client, server.Close
+
+}
+
+func TestAudit(t *testing.T) {
+
+	resolver := dnstest.Resolver{dnsmessage.TypeTXT: {
+		"_dmarc.example.com":               {"v=DMARC1; p=reject; rua=mailto:dmarc@example.com, mailto:reports@example.net; adkim=s"},
+		"selector1._domainkey.example.com": {"v=DKIM1; k=rsa; p=" + dkimKey(t, 2048)},
+		"old._domainkey.example.com":       {"v=DKIM1; p="},
+		"_mta-sts.example.com":             {"v=STSv1; id=20200101"},
+		"_smtp._tls.example.com":           {"v=TLSRPTv1; rua=mailto:tls@example.com"},
+		"default._bimi.example.com":        {"v=BIMI1; l=https://example.com/logo.svg"},
+		"_spf.provider.com":                {"v=spf1 ip4:192.0.2.0/24 include:_spf2.provider.com -all"},
+		"_spf2.provider.com":               {"v=spf1 a mx -all"},
+		"unrelated._domainkey.example.com": {"not a dkim record"},
+	}}
+
+	inventory := dnsrecords.Inventory{
+		Records: []dnsrecords.Record{
+			{Type: dnsrecords.TXT, Name: "example.com.", Value: "google-site-verification=abc"},
+			{Type: dnsrecords.TXT, Name: "example.com.", Value: "v=spf1 mx include:_spf.provider.com -all"},
+		},
+	}
+
+	client, closeServer := policyServer(t, "version: STSv1\r\nmode: enforce\r\nmx: mail.example.com\r\nmx: *.example.net\r\nmax_age: 604800\r\n")
+	defer closeServer()
+
+	auditor := NewAuditor(resolver, []string{"selector1", "selector2", "old", "unrelated"})
+	auditor.Client = client
+
+	report := auditor.Audit("example.com", inventory)
+
+	statuses := make(map[string]string)
+
+	for _, finding := range report.Findings {
+		statuses[finding.Check] = finding.Status
+	}
+
+	want := map[string]string{SPF: audit.Pass, DMARC: audit.Pass, DKIM: audit.Pass, MTASTS: audit.Pass, TLSRPT: audit.Pass, BIMI: audit.Warn}
+
+	for check, status := range want {
+		if statuses[check] != status {
+			t.Errorf("got %s for %s, want %s", statuses[check], check, status)
+		}
+	}
+
+	if report.Score != 97 || report.Grade != "A" {
+		t.Errorf("got score %d and grade %s, want 97 and A", report.Score, report.Grade)
+	}
+
+	if report.SPF == nil || report.SPF.LookupCount != 5 {
+		t.Errorf("got %+v, want an SPF record taking 5 lookups", report.SPF)
+	}
+
+	if report.DMARC == nil || len(report.DMARC.AggregateURIs) != 2 || report.DMARC.AlignmentDKIM != "s" || report.DMARC.AlignmentSPF != "r" {
+		t.Errorf("got %+v, want a parsed DMARC record", report.DMARC)
+	}
+
+	if len(report.DKIM) != 2 || report.DKIM[0].KeyBits != 2048 || !report.DKIM[1].Revoked {
+		t.Errorf("got %+v, want a 2048 bits key and a revoked one", report.DKIM)
+	}
+
+	if report.MTASTS == nil || report.MTASTS.Policy == nil || report.MTASTS.Policy.Mode != "enforce" || len(report.MTASTS.Policy.MX) != 2 {
+		t.Errorf("got %+v, want an enforced MTA-STS policy", report.MTASTS)
+	}
+
+}
+
+func TestAuditWithoutRecords(t *testing.T) {
+
+	client, closeServer := policyServer(t, "")
+	defer closeServer()
+
+	auditor := NewAuditor(dnstest.Resolver{}, DefaultSelectors)
+	auditor.Client = client
+
+	report := auditor.Audit("example.com", dnsrecords.Inventory{Records: []dnsrecords.Record{}})
+
+	if report.Score != 0 || report.Grade != "F" {
+		t.Errorf("got score %d and grade %s, want 0 and F", report.Score, report.Grade)
+	}
+
+	for _, finding := range report.Findings {
+		if finding.Status != audit.Fail {
+			t.Errorf("got %s for %s, want %s", finding.Status, finding.Check, audit.Fail)
+		}
+	}
+
+}
@@ -0,0 +1,417 @@
+package mailsecurity
+
+import (
+	"bufio"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"domain-info-api/platform/audit"
+)
+
+// minimumDKIMBits is the smallest RSA key considered safe to sign mail with
+const minimumDKIMBits = 1024
+
+// maxPolicySize is the largest MTA-STS policy file read
+const maxPolicySize = 64 << 10
+
+// DMARCRecord represents the DMARC policy of a domain
+type DMARCRecord struct {
+	Record          string   `json:"record"`
+	Policy          string   `json:"policy"`
+	SubdomainPolicy string   `json:"subdomain_policy"`
+	Percentage      int      `json:"percentage"`
+	AggregateURIs   []string `json:"aggregate_uris"`
+	ForensicURIs    []string `json:"forensic_uris"`
+	AlignmentDKIM   string   `json:"alignment_dkim"`
+	AlignmentSPF    string   `json:"alignment_spf"`
+}
+
+// DKIMKey represents a DKIM public key found under one of the probed selectors
+type DKIMKey struct {
+	Selector string `json:"selector"`
+	Record   string `json:"record"`
+	KeyType  string `json:"key_type"`
+	KeyBits  int    `json:"key_bits,omitempty"`
+	Revoked  bool   `json:"revoked"`
+}
+
+// MTASTSRecord represents the MTA-STS record of a domain along with the policy it announces
+type MTASTSRecord struct {
+	Record string        `json:"record"`
+	ID     string        `json:"id"`
+	Policy *MTASTSPolicy `json:"policy"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// MTASTSPolicy represents the policy file served on mta-sts.<domain>
+type MTASTSPolicy struct {
+	Mode   string   `json:"mode"`
+	MX     []string `json:"mx"`
+	MaxAge int      `json:"max_age"`
+}
+
+// TLSRPTRecord represents where a domain wants SMTP TLS failures to be reported
+type TLSRPTRecord struct {
+	Record     string   `json:"record"`
+	ReportURIs []string `json:"report_uris"`
+}
+
+// BIMIRecord represents the brand logo a domain publishes for mail clients
+type BIMIRecord struct {
+	Record    string `json:"record"`
+	Logo      string `json:"logo"`
+	Authority string `json:"authority"`
+}
+
+func (a *Auditor) auditDMARC(domain string) (audit.Finding, *DMARCRecord) {
+
+	finding := audit.Finding{Check: DMARC}
+
+	records, err := a.txtRecords("_dmarc."+domain, "v=DMARC1")
+
+	switch {
+	case err != nil:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("DMARC record could not be queried: %s", err.Error())
+		return finding, nil
+	case len(records) == 0:
+		finding.Status = audit.Fail
+		finding.Message = "No DMARC record is published"
+		return finding, nil
+	case len(records) > 1:
+		finding.Status = audit.Fail
+		finding.Message = "Several DMARC records are published, which makes receivers ignore them"
+		return finding, nil
+	}
+
+	tags := parseTags(records[0])
+
+	dmarc := DMARCRecord{
+		Record:          records[0],
+		Policy:          strings.ToLower(tags["p"]),
+		SubdomainPolicy: strings.ToLower(tags["sp"]),
+		Percentage:      100,
+		AggregateURIs:   splitURIs(tags["rua"]),
+		ForensicURIs:    splitURIs(tags["ruf"]),
+		AlignmentDKIM:   "r",
+		AlignmentSPF:    "r",
+	}
+
+	if dmarc.SubdomainPolicy == "" {
+		dmarc.SubdomainPolicy = dmarc.Policy
+	}
+
+	if percentage, err := strconv.Atoi(tags["pct"]); err == nil {
+		dmarc.Percentage = percentage
+	}
+
+	if alignment := strings.ToLower(tags["adkim"]); alignment != "" {
+		dmarc.AlignmentDKIM = alignment
+	}
+
+	if alignment := strings.ToLower(tags["aspf"]); alignment != "" {
+		dmarc.AlignmentSPF = alignment
+	}
+
+	switch {
+	case dmarc.Policy != "reject" && dmarc.Policy != "quarantine" && dmarc.Policy != "none":
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("Policy %q is not valid", tags["p"])
+	case dmarc.Policy == "none":
+		finding.Status = audit.Warn
+		finding.Message = "Policy only monitors mail failing DMARC (p=none)"
+	case dmarc.Percentage < 100:
+		finding.Status = audit.Warn
+		finding.Message = fmt.Sprintf("Policy only applies to %d%% of the mail failing DMARC", dmarc.Percentage)
+	case len(dmarc.AggregateURIs) == 0:
+		finding.Status = audit.Warn
+		finding.Message = "Aggregate reports are not requested (rua)"
+	default:
+		finding.Status = audit.Pass
+	}
+
+	return finding, &dmarc
+
+}
+
+// auditDKIM probes every selector concurrently, passing when at least one key is usable
+func (a *Auditor) auditDKIM(domain string) (audit.Finding, []DKIMKey) {
+
+	finding := audit.Finding{Check: DKIM}
+
+	keys := make([]*DKIMKey, len(a.Selectors))
+
+	var wait sync.WaitGroup
+
+	for i, selector := range a.Selectors {
+
+		wait.Add(1)
+
+		go func(i int, selector string) {
+
+			defer wait.Done()
+
+			records, err := a.txtRecords(selector+"._domainkey."+domain, "v=DKIM1")
+			if err != nil || len(records) == 0 {
+				return
+			}
+
+			key := parseDKIM(selector, records[0])
+			keys[i] = &key
+
+		}(i, selector)
+
+	}
+
+	wait.Wait()
+
+	found := []DKIMKey{}
+
+	var usable, weak int
+
+	for _, key := range keys {
+
+		if key == nil {
+			continue
+		}
+
+		found = append(found, *key)
+
+		switch {
+		case key.Revoked:
+		case key.KeyType == "rsa" && key.KeyBits > 0 && key.KeyBits < minimumDKIMBits:
+			weak++
+		default:
+			usable++
+		}
+
+	}
+
+	switch {
+	case usable > 0:
+		finding.Status = audit.Pass
+	case weak > 0:
+		finding.Status = audit.Warn
+		finding.Message = fmt.Sprintf("Keys are shorter than %d bits", minimumDKIMBits)
+	case len(found) > 0:
+		finding.Status = audit.Fail
+		finding.Message = "Every key found has been revoked"
+	default:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("No DKIM key was found under the %d probed selectors", len(a.Selectors))
+	}
+
+	return finding, found
+
+}
+
+// parseDKIM returns the key published in a DKIM record, measuring RSA keys
+func parseDKIM(selector, record string) DKIMKey {
+
+	tags := parseTags(record)
+
+	key := DKIMKey{
+		Selector: selector,
+		Record:   record,
+		KeyType:  strings.ToLower(tags["k"]),
+	}
+
+	if key.KeyType == "" {
+		key.KeyType = "rsa"
+	}
+
+	publicKey := strings.Join(strings.Fields(tags["p"]), "")
+
+	if publicKey == "" {
+		key.Revoked = true
+		return key
+	}
+
+	if key.KeyType != "rsa" {
+		return key
+	}
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return key
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PublicKey(der)
+	}
+
+	if rsaKey, ok := parsed.(*rsa.PublicKey); err == nil && ok {
+		key.KeyBits = rsaKey.N.BitLen()
+	}
+
+	return key
+
+}
+
+func (a *Auditor) auditMTASTS(domain string) (audit.Finding, *MTASTSRecord) {
+
+	finding := audit.Finding{Check: MTASTS}
+
+	records, err := a.txtRecords("_mta-sts."+domain, "v=STSv1")
+
+	switch {
+	case err != nil:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("MTA-STS record could not be queried: %s", err.Error())
+		return finding, nil
+	case len(records) != 1:
+		finding.Status = audit.Fail
+		finding.Message = "No MTA-STS record is published"
+		return finding, nil
+	}
+
+	mtaSTS := MTASTSRecord{Record: records[0], ID: parseTags(records[0])["id"]}
+
+	policy, err := a.fetchMTASTSPolicy(domain)
+	if err != nil {
+		mtaSTS.Error = err.Error()
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("Policy could not be fetched: %s", err.Error())
+		return finding, &mtaSTS
+	}
+
+	mtaSTS.Policy = policy
+
+	switch policy.Mode {
+	case "enforce":
+		finding.Status = audit.Pass
+	case "testing":
+		finding.Status = audit.Warn
+		finding.Message = "Policy is in testing mode"
+	default:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("Policy mode %q does not protect mail", policy.Mode)
+	}
+
+	return finding, &mtaSTS
+
+}
+
+// fetchMTASTSPolicy requests the policy file announced by an MTA-STS record
+func (a *Auditor) fetchMTASTSPolicy(domain string) (*MTASTSPolicy, error) {
+
+	response, err := a.Client.Get("https://mta-sts." + domain + "/.well-known/mta-sts.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	policy := MTASTSPolicy{MX: []string{}}
+
+	var version string
+
+	scanner := bufio.NewScanner(io.LimitReader(response.Body, maxPolicySize))
+
+	for scanner.Scan() {
+
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "version":
+			version = value
+		case "mode":
+			policy.Mode = strings.ToLower(value)
+		case "mx":
+			policy.MX = append(policy.MX, strings.ToLower(value))
+		case "max_age":
+			policy.MaxAge, _ = strconv.Atoi(value)
+		}
+
+	}
+
+	if version != "STSv1" {
+		return nil, fmt.Errorf("policy version %q is not STSv1", version)
+	}
+
+	return &policy, nil
+
+}
+
+func (a *Auditor) auditTLSRPT(domain string) (audit.Finding, *TLSRPTRecord) {
+
+	finding := audit.Finding{Check: TLSRPT}
+
+	records, err := a.txtRecords("_smtp._tls."+domain, "v=TLSRPTv1")
+
+	switch {
+	case err != nil:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("TLS-RPT record could not be queried: %s", err.Error())
+		return finding, nil
+	case len(records) != 1:
+		finding.Status = audit.Fail
+		finding.Message = "No TLS-RPT record is published"
+		return finding, nil
+	}
+
+	tlsRPT := TLSRPTRecord{Record: records[0], ReportURIs: splitURIs(parseTags(records[0])["rua"])}
+
+	finding.Status = audit.Pass
+
+	if len(tlsRPT.ReportURIs) == 0 {
+		finding.Status = audit.Fail
+		finding.Message = "Record does not say where to send reports (rua)"
+	}
+
+	return finding, &tlsRPT
+
+}
+
+func (a *Auditor) auditBIMI(domain string) (audit.Finding, *BIMIRecord) {
+
+	finding := audit.Finding{Check: BIMI}
+
+	records, err := a.txtRecords("default._bimi."+domain, "v=BIMI1")
+
+	switch {
+	case err != nil:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("BIMI record could not be queried: %s", err.Error())
+		return finding, nil
+	case len(records) != 1:
+		finding.Status = audit.Fail
+		finding.Message = "No BIMI record is published"
+		return finding, nil
+	}
+
+	tags := parseTags(records[0])
+
+	bimi := BIMIRecord{Record: records[0], Logo: tags["l"], Authority: tags["a"]}
+
+	switch {
+	case bimi.Logo == "":
+		finding.Status = audit.Fail
+		finding.Message = "Record does not point to a logo"
+	case bimi.Authority == "":
+		finding.Status = audit.Warn
+		finding.Message = "Logo is not backed by a Verified Mark Certificate"
+	default:
+		finding.Status = audit.Pass
+	}
+
+	return finding, &bimi
+
+}
@@ -0,0 +1,201 @@
+package mailsecurity
+
+import (
+	"fmt"
+	"strings"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/dnsrecords"
+)
+
+// maxSPFLookups is the number of DNS lookups an SPF evaluation may take before failing
+const maxSPFLookups = 10
+
+// SPFRecord represents the SPF policy of a domain. LookupCount adds up the DNS lookups
+// needed to evaluate it, following includes and redirects
+type SPFRecord struct {
+	Record      string   `json:"record"`
+	All         string   `json:"all"`
+	Mechanisms  []string `json:"mechanisms"`
+	Includes    []string `json:"includes"`
+	Redirect    string   `json:"redirect,omitempty"`
+	LookupCount int      `json:"lookup_count"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// lookupMechanisms holds the mechanisms that require a DNS lookup
+var lookupMechanisms = map[string]bool{
+	"include": true,
+	"a":       true,
+	"mx":      true,
+	"ptr":     true,
+	"exists":  true,
+}
+
+func (a *Auditor) auditSPF(domain string, inventory dnsrecords.Inventory) (audit.Finding, *SPFRecord) {
+
+	finding := audit.Finding{Check: SPF}
+
+	var records []string
+	var err error
+
+	if _, failed := inventory.Errors[dnsrecords.TXT]; inventory.Records != nil && !failed {
+		records = withVersion(apexRecords(domain, inventory, dnsrecords.TXT), "v=spf1")
+	} else {
+		records, err = a.txtRecords(domain, "v=spf1")
+	}
+
+	switch {
+	case err != nil:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("SPF record could not be queried: %s", err.Error())
+		return finding, nil
+	case len(records) == 0:
+		finding.Status = audit.Fail
+		finding.Message = "No SPF record is published"
+		return finding, nil
+	case len(records) > 1:
+		finding.Status = audit.Fail
+		finding.Message = "Several SPF records are published, which makes SPF fail"
+		return finding, &SPFRecord{Record: strings.Join(records, " | "), Mechanisms: []string{}, Includes: []string{}}
+	}
+
+	spf := parseSPF(records[0])
+
+	spf.LookupCount = a.countSPFLookups(spf, map[string]bool{strings.ToLower(domain): true}, &spf.Errors)
+
+	switch {
+	case spf.LookupCount > maxSPFLookups:
+		finding.Status = audit.Fail
+		finding.Message = fmt.Sprintf("Evaluating the record takes %d DNS lookups, more than the %d allowed", spf.LookupCount, maxSPFLookups)
+	case len(spf.Errors) > 0:
+		finding.Status = audit.Fail
+		finding.Message = spf.Errors[0]
+	case spf.All == "-all":
+		finding.Status = audit.Pass
+	case spf.All == "~all":
+		finding.Status = audit.Warn
+		finding.Message = "Unauthorized senders are only soft failed (~all)"
+	case spf.All == "+all" || spf.All == "all":
+		finding.Status = audit.Fail
+		finding.Message = "Any server is allowed to send mail (+all)"
+	default:
+		finding.Status = audit.Warn
+		finding.Message = "Unauthorized senders are not rejected"
+	}
+
+	return finding, &spf
+
+}
+
+// parseSPF returns the mechanisms of an SPF record
+func parseSPF(record string) SPFRecord {
+
+	spf := SPFRecord{Record: record, Mechanisms: []string{}, Includes: []string{}}
+
+	for _, term := range strings.Fields(record)[1:] {
+
+		lower := strings.ToLower(term)
+
+		if strings.HasPrefix(lower, "redirect=") {
+			spf.Redirect = term[len("redirect="):]
+			continue
+		}
+
+		if strings.Contains(lower, "=") {
+			continue
+		}
+
+		spf.Mechanisms = append(spf.Mechanisms, lower)
+
+		name := strings.TrimLeft(lower, "+-~?")
+
+		switch {
+		case name == "all":
+			spf.All = lower
+		case strings.HasPrefix(name, "include:"):
+			spf.Includes = append(spf.Includes, term[strings.Index(term, ":")+1:])
+		}
+
+	}
+
+	return spf
+
+}
+
+// countSPFLookups returns the DNS lookups needed to evaluate the record, querying the
+// records it includes. visited holds the domains of the include chain being followed, and
+// errors found on the way, such as includes without an SPF record, are appended to errs
+func (a *Auditor) countSPFLookups(spf SPFRecord, visited map[string]bool, errs *[]string) int {
+
+	var count int
+
+	for _, mechanism := range spf.Mechanisms {
+
+		name := strings.TrimLeft(mechanism, "+-~?")
+		name = strings.SplitN(strings.SplitN(name, ":", 2)[0], "/", 2)[0]
+
+		if lookupMechanisms[name] {
+			count++
+		}
+
+	}
+
+	var targets []string
+
+	targets = append(targets, spf.Includes...)
+
+	// redirect is ignored when the record has an all mechanism
+	if spf.Redirect != "" && spf.All == "" {
+		count++
+		targets = append(targets, spf.Redirect)
+	}
+
+	for _, target := range targets {
+
+		if count > maxSPFLookups {
+			break
+		}
+
+		target = strings.ToLower(target)
+
+		if visited[target] {
+			*errs = append(*errs, fmt.Sprintf("SPF record of %s includes itself", target))
+			continue
+		}
+
+		records, err := a.txtRecords(target, "v=spf1")
+
+		switch {
+		case err != nil:
+			*errs = append(*errs, fmt.Sprintf("SPF record of %s could not be queried: %s", target, err.Error()))
+		case len(records) != 1:
+			*errs = append(*errs, fmt.Sprintf("%s does not publish exactly one SPF record", target))
+		default:
+			visited[target] = true
+			count += a.countSPFLookups(parseSPF(records[0]), visited, errs)
+			delete(visited, target)
+		}
+
+	}
+
+	return count
+
+}
+
+// apexRecords returns the records of the given type found for the domain itself
+func apexRecords(domain string, inventory dnsrecords.Inventory, recordType string) []dnsrecords.Record {
+
+	var records []dnsrecords.Record
+
+	name := strings.ToLower(strings.TrimSuffix(domain, ".") + ".")
+
+	for _, record := range inventory.Records {
+		if record.Type == recordType && record.Name == name {
+			records = append(records, record)
+		}
+	}
+
+	return records
+
+}
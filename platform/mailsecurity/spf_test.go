@@ -0,0 +1,86 @@
+package mailsecurity
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"domain-info-api/platform/audit"
+	"domain-info-api/platform/dnsrecords"
+	"domain-info-api/platform/dnsrecords/dnstest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestAuditSPF(t *testing.T) {
+
+	tooManyIncludes := make([]string, 11)
+
+	for i := range tooManyIncludes {
+		tooManyIncludes[i] = fmt.Sprintf("include:spf%d.example.net", i)
+	}
+
+	resolver := dnstest.Resolver{dnsmessage.TypeTXT: {
+		"loop.example.net":    {"v=spf1 include:example.com -all"},
+		"example.org":         {"v=spf1 redirect=_spf.example.org"},
+		"_spf.example.org":    {"v=spf1 ip4:192.0.2.1 ~all"},
+		"missing.example.com": {"google-site-verification=abc"},
+	}}
+
+	for i := range tooManyIncludes {
+		resolver[dnsmessage.TypeTXT][fmt.Sprintf("spf%d.example.net", i)] = []string{"v=spf1 ip4:192.0.2.1 -all"}
+	}
+
+	tests := []struct {
+		name        string
+		record      string
+		wantStatus  string
+		wantLookups int
+	}{
+		{"hard fail", "v=spf1 ip4:192.0.2.0/24 a -all", audit.Pass, 1},
+		{"soft fail", "v=spf1 mx ~all", audit.Warn, 1},
+		{"allows anyone", "v=spf1 +all", audit.Fail, 0},
+		{"redirect", "v=spf1 redirect=example.org", audit.Warn, 2},
+		{"include loop", "v=spf1 include:loop.example.net -all", audit.Fail, 2},
+		{"include without record", "v=spf1 include:missing.example.com -all", audit.Fail, 1},
+		{"too many lookups", "v=spf1 " + strings.Join(tooManyIncludes, " ") + " -all", audit.Fail, 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			inventory := dnsrecords.Inventory{
+				Records: []dnsrecords.Record{{Type: dnsrecords.TXT, Name: "example.com.", Value: tt.record}},
+			}
+
+			finding, spf := NewAuditor(resolver, nil).auditSPF("example.com", inventory)
+
+			if finding.Status != tt.wantStatus {
+				t.Errorf("got status %s (%s), want %s", finding.Status, finding.Message, tt.wantStatus)
+			}
+
+			if spf == nil || spf.LookupCount != tt.wantLookups {
+				t.Errorf("got %+v, want %d lookups", spf, tt.wantLookups)
+			}
+
+		})
+	}
+
+}
+
+func TestAuditSPFWithSeveralRecords(t *testing.T) {
+
+	inventory := dnsrecords.Inventory{
+		Records: []dnsrecords.Record{
+			{Type: dnsrecords.TXT, Name: "example.com.", Value: "v=spf1 -all"},
+			{Type: dnsrecords.TXT, Name: "example.com.", Value: "v=spf1 mx -all"},
+		},
+	}
+
+	finding, _ := NewAuditor(dnstest.Resolver{}, nil).auditSPF("example.com", inventory)
+
+	if finding.Status != audit.Fail {
+		t.Errorf("got status %s, want %s", finding.Status, audit.Fail)
+	}
+
+}
@@ -0,0 +1,100 @@
+package provider
+
+// bundledDataset holds the signatures used when PROVIDER_SIGNATURES is not set. Ranges are the
+// ones the providers publish, header patterns are regular expressions matched against the
+// header value, an empty pattern only requiring the header to be present
+const bundledDataset = `{
+	"Akamai": {
+		"type": "cdn",
+		"asns": [20940, 16625, 21342, 32787],
+		"reverseDNS": ["\\.akamaitechnologies\\.com$", "\\.akamaiedge\\.net$"],
+		"headers": {"Server": "^AkamaiGHost", "X-Akamai-Transformed": "", "Akamai-GRN": ""}
+	},
+	"Amazon CloudFront": {
+		"type": "cdn",
+		"ranges": ["13.32.0.0/15", "13.224.0.0/14", "13.249.0.0/16", "18.64.0.0/14", "18.154.0.0/15", "18.160.0.0/15", "18.164.0.0/15",
+			"18.172.0.0/15", "52.84.0.0/15", "52.222.128.0/17", "54.182.0.0/16", "54.192.0.0/16", "54.230.0.0/16", "54.239.128.0/18",
+			"99.84.0.0/16", "99.86.0.0/16", "108.156.0.0/14", "143.204.0.0/16", "2600:9000::/28"],
+		"reverseDNS": ["\\.r\\.cloudfront\\.net$"],
+		"headers": {"X-Amz-Cf-Id": "", "X-Amz-Cf-Pop": "", "Via": "\\(CloudFront\\)$"}
+	},
+	"Amazon Web Services": {
+		"type": "cloud",
+		"asns": [16509, 14618],
+		"reverseDNS": ["\\.amazonaws\\.com$"],
+		"headers": {"X-Amz-Request-Id": "", "X-Amzn-RequestId": ""}
+	},
+	"Cloudflare": {
+		"type": "cdn",
+		"asns": [13335, 209242],
+		"ranges": ["173.245.48.0/20", "103.21.244.0/22", "103.22.200.0/22", "103.31.4.0/22", "141.101.64.0/18", "108.162.192.0/18",
+			"190.93.240.0/20", "188.114.96.0/20", "197.234.240.0/22", "198.41.128.0/17", "162.158.0.0/15", "104.16.0.0/13",
+			"104.24.0.0/14", "172.64.0.0/13", "131.0.72.0/22", "2400:cb00::/32", "2606:4700::/32", "2803:f800::/32",
+			"2405:b500::/32", "2405:8100::/32", "2a06:98c0::/29", "2c0f:f248::/32"],
+		"headers": {"Server": "^cloudflare$", "CF-RAY": ""}
+	},
+	"DigitalOcean": {
+		"type": "hosting",
+		"asns": [14061]
+	},
+	"Fastly": {
+		"type": "cdn",
+		"asns": [54113],
+		"ranges": ["23.235.32.0/20", "43.249.72.0/22", "103.244.50.0/24", "103.245.222.0/23", "103.245.224.0/24", "104.156.80.0/20",
+			"140.248.64.0/18", "140.248.128.0/17", "146.75.0.0/17", "151.101.0.0/16", "157.52.64.0/18", "167.82.0.0/17",
+			"167.82.128.0/20", "167.82.160.0/20", "167.82.224.0/20", "172.111.64.0/18", "185.31.16.0/22", "199.27.72.0/21",
+			"199.232.0.0/16", "2a04:4e40::/32", "2a04:4e42::/32"],
+		"headers": {"X-Fastly-Request-ID": "", "Fastly-Debug-Digest": "", "X-Served-By": "^cache-"}
+	},
+	"GitHub Pages": {
+		"type": "hosting",
+		"ranges": ["185.199.108.0/22", "2606:50c0::/32"],
+		"headers": {"Server": "^GitHub\\.com$", "X-GitHub-Request-Id": ""}
+	},
+	"Google Cloud": {
+		"type": "cloud",
+		"asns": [396982, 15169, 19527],
+		"reverseDNS": ["\\.googleusercontent\\.com$"],
+		"headers": {"Via": "^1\\.1 google$", "Server": "^(?:Google Frontend|gws)$"}
+	},
+	"Hetzner": {
+		"type": "hosting",
+		"asns": [24940, 213230],
+		"reverseDNS": ["\\.your-server\\.de$", "\\.clients\\.your-server\\.de$"]
+	},
+	"Imperva": {
+		"type": "cdn",
+		"asns": [19551],
+		"headers": {"X-Iinfo": "", "X-CDN": "^Incapsula$"}
+	},
+	"Linode": {
+		"type": "hosting",
+		"asns": [63949],
+		"reverseDNS": ["\\.members\\.linode\\.com$", "\\.ip\\.linodeusercontent\\.com$"]
+	},
+	"Microsoft Azure": {
+		"type": "cloud",
+		"asns": [8075, 8068],
+		"reverseDNS": ["\\.cloudapp\\.azure\\.com$", "\\.cloudapp\\.net$"],
+		"headers": {"X-Azure-Ref": "", "X-MSEdge-Ref": ""}
+	},
+	"Netlify": {
+		"type": "hosting",
+		"headers": {"Server": "^Netlify$", "X-Nf-Request-Id": ""}
+	},
+	"OVHcloud": {
+		"type": "hosting",
+		"asns": [16276],
+		"reverseDNS": ["\\.ovh\\.net$", "\\.ip-[\\d-]+\\.eu$"]
+	},
+	"Sucuri": {
+		"type": "cdn",
+		"asns": [30148],
+		"headers": {"X-Sucuri-ID": "", "Server": "^Sucuri/Cloudproxy$"}
+	},
+	"Vercel": {
+		"type": "hosting",
+		"ranges": ["76.76.21.0/24"],
+		"headers": {"Server": "^Vercel$", "X-Vercel-Id": ""}
+	}
+}`
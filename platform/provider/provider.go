@@ -0,0 +1,405 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Types of provider
+const (
+	CDN     = "cdn"
+	Cloud   = "cloud"
+	Hosting = "hosting"
+)
+
+// Weights of each kind of evidence, an address in a published range being the strongest
+// since providers share autonomous systems, such as CloudFront and the rest of AWS
+const (
+	asnWeight        = 1
+	headerWeight     = 2
+	reverseDNSWeight = 2
+	rangeWeight      = 3
+)
+
+// typeOrder breaks ties in favor of the provider closest to the visitor
+var typeOrder = map[string]int{CDN: 0, Cloud: 1, Hosting: 2}
+
+// Observation represents what is known about a server when detecting its provider
+type Observation struct {
+	Address      string
+	ASN          uint64
+	ReverseNames []string
+	Header       http.Header
+}
+
+// Match represents the provider detected for a server and what gave it away
+type Match struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Evidence []string `json:"evidence"`
+}
+
+// Dataset detects providers with a set of signatures
+type Dataset struct {
+	signatures map[string]*signature
+}
+
+// rawSignature represents a signature as written in the dataset file
+type rawSignature struct {
+	Type       string            `json:"type"`
+	ASNs       []uint64          `json:"asns"`
+	Ranges     []string          `json:"ranges"`
+	ReverseDNS []string          `json:"reverseDNS"`
+	Headers    map[string]string `json:"headers"`
+}
+
+type signature struct {
+	kind       string
+	asns       map[uint64]bool
+	ranges     []*net.IPNet
+	reverseDNS []*regexp.Regexp
+	headers    map[string]*regexp.Regexp
+}
+
+// Resolver represents what reverse lookups need to query PTR records
+type Resolver interface {
+	Query(name string, recordType dnsmessage.Type) ([]dnsrecords.Record, error)
+}
+
+// NewDataset returns a Dataset using the given dataset file contents
+func NewDataset(datasetFile []byte) (*Dataset, error) {
+
+	var raw map[string]rawSignature
+
+	if err := json.Unmarshal(datasetFile, &raw); err != nil {
+		return nil, fmt.Errorf("invalid provider dataset: %s", err.Error())
+	}
+
+	dataset := &Dataset{signatures: make(map[string]*signature)}
+
+	for name, rawSig := range raw {
+
+		sig, err := compileSignature(rawSig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature for %s: %s", name, err.Error())
+		}
+
+		dataset.signatures[name] = sig
+
+	}
+
+	return dataset, nil
+
+}
+
+// cachedDataset represents the last compiled default dataset and the file it came from, the
+// bundled dataset having an empty path
+type cachedDataset struct {
+	dataset *Dataset
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+var (
+	cacheMutex sync.Mutex
+	cache      cachedDataset
+)
+
+// DefaultDataset returns a Dataset using the file set by PROVIDER_SIGNATURES, or the dataset
+// bundled with the service when it is not set. The compiled dataset is kept until the variable
+// or the file changes, so that it can be updated while the service runs
+func DefaultDataset() (*Dataset, error) {
+
+	path := os.Getenv("PROVIDER_SIGNATURES")
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if path == "" {
+
+		if cache.dataset == nil || cache.path != "" {
+
+			dataset, err := NewDataset([]byte(bundledDataset))
+			if err != nil {
+				return nil, err
+			}
+
+			cache = cachedDataset{dataset: dataset}
+
+		}
+
+		return cache.dataset, nil
+
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache.dataset != nil && cache.path == path && info.ModTime().Equal(cache.modTime) && info.Size() == cache.size {
+		return cache.dataset, nil
+	}
+
+	datasetFile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset, err := NewDataset(datasetFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cache = cachedDataset{dataset: dataset, path: path, modTime: info.ModTime(), size: info.Size()}
+
+	return dataset, nil
+
+}
+
+func compileSignature(raw rawSignature) (*signature, error) {
+
+	if _, known := typeOrder[raw.Type]; !known {
+		return nil, fmt.Errorf("unknown type %q", raw.Type)
+	}
+
+	sig := &signature{
+		kind:    raw.Type,
+		asns:    make(map[uint64]bool),
+		headers: make(map[string]*regexp.Regexp),
+	}
+
+	for _, asn := range raw.ASNs {
+		sig.asns[asn] = true
+	}
+
+	for _, cidr := range raw.Ranges {
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		sig.ranges = append(sig.ranges, network)
+
+	}
+
+	for _, expression := range raw.ReverseDNS {
+
+		regex, err := regexp.Compile("(?i)" + expression)
+		if err != nil {
+			return nil, err
+		}
+
+		sig.reverseDNS = append(sig.reverseDNS, regex)
+
+	}
+
+	for name, expression := range raw.Headers {
+
+		regex, err := regexp.Compile("(?i)" + expression)
+		if err != nil {
+			return nil, err
+		}
+
+		sig.headers[http.CanonicalHeaderKey(name)] = regex
+
+	}
+
+	return sig, nil
+
+}
+
+// Detect returns the provider whose signature matches the observation best, or nil when none does
+func (d *Dataset) Detect(observation Observation) *Match {
+
+	var best *Match
+	bestScore := 0
+
+	ip := net.ParseIP(observation.Address)
+
+	for name, sig := range d.signatures {
+
+		score, evidence := sig.match(ip, observation)
+		if score == 0 {
+			continue
+		}
+
+		if best == nil || score > bestScore || (score == bestScore && sig.before(name, d.signatures[best.Name], best.Name)) {
+			best = &Match{Name: name, Type: sig.kind, Evidence: evidence}
+			bestScore = score
+		}
+
+	}
+
+	return best
+
+}
+
+// before tells whether a signature wins a tie against another one
+func (s *signature) before(name string, other *signature, otherName string) bool {
+
+	if typeOrder[s.kind] != typeOrder[other.kind] {
+		return typeOrder[s.kind] < typeOrder[other.kind]
+	}
+
+	return name < otherName
+
+}
+
+// match returns how strongly the observation matches the signature and the evidence found
+func (s *signature) match(ip net.IP, observation Observation) (int, []string) {
+
+	score := 0
+	evidence := []string{}
+
+	if s.asns[observation.ASN] {
+		score += asnWeight
+		evidence = append(evidence, fmt.Sprintf("AS%d", observation.ASN))
+	}
+
+	if ip != nil {
+		for _, network := range s.ranges {
+			if network.Contains(ip) {
+				score += rangeWeight
+				evidence = append(evidence, fmt.Sprintf("address in %s", network.String()))
+				break
+			}
+		}
+	}
+
+	for _, name := range observation.ReverseNames {
+		if matchAny(s.reverseDNS, name) {
+			score += reverseDNSWeight
+			evidence = append(evidence, fmt.Sprintf("reverse DNS %s", name))
+			break
+		}
+	}
+
+	headers := make([]string, 0, len(s.headers))
+
+	for header := range s.headers {
+		headers = append(headers, header)
+	}
+
+	sort.Strings(headers)
+
+	for _, header := range headers {
+
+		if matchAny([]*regexp.Regexp{s.headers[header]}, observation.Header[header]...) {
+			score += headerWeight
+			evidence = append(evidence, fmt.Sprintf("%s header", header))
+			break
+		}
+
+	}
+
+	return score, evidence
+
+}
+
+func matchAny(regexes []*regexp.Regexp, values ...string) bool {
+
+	for _, regex := range regexes {
+		for _, value := range values {
+			if regex.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+
+}
+
+// ReverseNames returns the names the PTR records of the given address point to
+func ReverseNames(resolver Resolver, address string) []string {
+
+	name := reverseName(net.ParseIP(address))
+	if name == "" {
+		return nil
+	}
+
+	records, err := resolver.Query(name, dnsmessage.TypePTR)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+
+	for _, record := range records {
+		if record.Type == "PTR" {
+			names = append(names, strings.TrimSuffix(record.Value, "."))
+		}
+	}
+
+	return names
+
+}
+
+// ObserveAll fills the reverse names of the given observations, looking them up at the same
+// time with the resolver configured through the environment
+func ObserveAll(observations []Observation) {
+	observeAll(dnsrecords.NewResolver(dnsrecords.ConfigFromEnv()), observations)
+}
+
+func observeAll(resolver Resolver, observations []Observation) {
+
+	var wg sync.WaitGroup
+
+	for i := range observations {
+
+		wg.Add(1)
+
+		go func(observation *Observation) {
+			defer wg.Done()
+			observation.ReverseNames = ReverseNames(resolver, observation.Address)
+		}(&observations[i])
+
+	}
+
+	wg.Wait()
+
+}
+
+// reverseName returns the name under in-addr.arpa or ip6.arpa holding the PTR records of an address
+func reverseName(ip net.IP) string {
+
+	if ip == nil {
+		return ""
+	}
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", ipv4[3], ipv4[2], ipv4[1], ipv4[0])
+	}
+
+	const hexDigits = "0123456789abcdef"
+
+	var name strings.Builder
+
+	for i := len(ip) - 1; i >= 0; i-- {
+		name.WriteByte(hexDigits[ip[i]&0x0F])
+		name.WriteByte('.')
+		name.WriteByte(hexDigits[ip[i]>>4])
+		name.WriteByte('.')
+	}
+
+	name.WriteString("ip6.arpa.")
+
+	return name.String()
+
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"domain-info-api/platform/dnsrecords/dnstest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDetect(t *testing.T) {
+
+	dataset, err := DefaultDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		observation Observation
+		wantName    string
+		wantType    string
+	}{
+		{"cloudflare", Observation{Address: "104.16.132.229", ASN: 13335, Header: http.Header{"Server": {"cloudflare"}, "Cf-Ray": {"7d1c-MIA"}}}, "Cloudflare", CDN},
+		{"cloudfront inside aws", Observation{Address: "13.224.10.1", ASN: 16509, Header: http.Header{"Via": {"1.1 abc.cloudfront.net (CloudFront)"}}}, "Amazon CloudFront", CDN},
+		{"aws", Observation{Address: "3.5.0.1", ASN: 16509, ReverseNames: []string{"ec2-3-5-0-1.compute-1.amazonaws.com"}}, "Amazon Web Services", Cloud},
+		{"github pages on fastly", Observation{Address: "185.199.108.153", ASN: 54113, Header: http.Header{"Server": {"GitHub.com"}, "X-Served-By": {"cache-mia11350-MIA"}}}, "GitHub Pages", Hosting},
+		{"fastly", Observation{Address: "151.101.1.69", ASN: 54113}, "Fastly", CDN},
+		{"netlify by headers", Observation{Address: "198.51.100.7", Header: http.Header{"Server": {"Netlify"}}}, "Netlify", Hosting},
+		{"hetzner by reverse dns", Observation{Address: "198.51.100.8", ReverseNames: []string{"static.8.100.51.198.clients.your-server.de"}}, "Hetzner", Hosting},
+		{"ipv6 cloudflare", Observation{Address: "2606:4700::6810:84e5"}, "Cloudflare", CDN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			match := dataset.Detect(tt.observation)
+
+			if match == nil || match.Name != tt.wantName || match.Type != tt.wantType || len(match.Evidence) == 0 {
+				t.Errorf("got %+v, want %s (%s)", match, tt.wantName, tt.wantType)
+			}
+
+		})
+	}
+
+	if match := dataset.Detect(Observation{Address: "192.0.2.1", ASN: 64500}); match != nil {
+		t.Errorf("got %+v for an unknown network, want nil", match)
+	}
+
+}
+
+func TestDefaultDatasetFromFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "provider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "providers.json")
+
+	err = ioutil.WriteFile(path, []byte(`{"Example Cloud": {"type": "cloud", "ranges": ["192.0.2.0/24"]}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("PROVIDER_SIGNATURES", path)
+	defer os.Unsetenv("PROVIDER_SIGNATURES")
+
+	dataset, err := DefaultDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if match := dataset.Detect(Observation{Address: "192.0.2.1"}); match == nil || match.Name != "Example Cloud" {
+		t.Errorf("got %+v, want the provider of the updated dataset", match)
+	}
+
+	if cached, err := DefaultDataset(); err != nil || cached != dataset {
+		t.Errorf("got %p and %v, want the dataset compiled before while the file is unchanged", cached, err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(`{"Other Cloud": {"type": "cloud", "ranges": ["192.0.2.0/24"]}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	dataset, err = DefaultDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if match := dataset.Detect(Observation{Address: "192.0.2.1"}); match == nil || match.Name != "Other Cloud" {
+		t.Errorf("got %+v, want the provider of the file replaced on disk", match)
+	}
+
+	if _, err := NewDataset([]byte(`{"Broken": {"type": "cdn", "ranges": ["not a range"]}}`)); err == nil {
+		t.Error("got no error for an invalid range, want one")
+	}
+
+	if _, err := NewDataset([]byte(`{"Unknown": {"type": "isp"}}`)); err == nil {
+		t.Error("got no error for an unknown type, want one")
+	}
+
+}
+
+func reverseZone() dnstest.Resolver {
+
+	return dnstest.Resolver{dnsmessage.TypePTR: {
+		"229.132.16.104.in-addr.arpa": {"a104-16-132-229.deploy.static.akamaitechnologies.com."},
+		"5.e.4.8.0.1.8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.7.4.6.0.6.2.ip6.arpa": {"host.example.com."},
+		"1.2.0.192.in-addr.arpa": nil,
+	}}
+
+}
+
+func TestReverseNames(t *testing.T) {
+
+	resolver := reverseZone()
+
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"104.16.132.229", "a104-16-132-229.deploy.static.akamaitechnologies.com"},
+		{"2606:4700::6810:84e5", "host.example.com"},
+		{"192.0.2.1", ""},
+		{"192.0.2.2", ""},
+		{"not an address", ""},
+	}
+
+	for _, tt := range tests {
+
+		names := ReverseNames(resolver, tt.address)
+
+		if (tt.want == "" && len(names) != 0) || (tt.want != "" && (len(names) != 1 || names[0] != tt.want)) {
+			t.Errorf("got %v for %s, want %q", names, tt.address, tt.want)
+		}
+
+	}
+
+}
+
+func TestObserveAll(t *testing.T) {
+
+	observations := []Observation{{Address: "104.16.132.229"}, {Address: "192.0.2.1"}, {Address: "2606:4700::6810:84e5"}}
+
+	observeAll(reverseZone(), observations)
+
+	if names := observations[0].ReverseNames; len(names) != 1 || names[0] != "a104-16-132-229.deploy.static.akamaitechnologies.com" {
+		t.Errorf("got %v for the first address, want its PTR name", names)
+	}
+
+	if names := observations[1].ReverseNames; len(names) != 0 {
+		t.Errorf("got %v for an address failing to resolve, want none", names)
+	}
+
+	if names := observations[2].ReverseNames; len(names) != 1 || names[0] != "host.example.com" {
+		t.Errorf("got %v for the last address, want its PTR name", names)
+	}
+
+}
@@ -0,0 +1,377 @@
+package securityheaders
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"domain-info-api/platform/audit"
+)
+
+// Names of the checks of an audit
+const (
+	HSTS               = "Strict-Transport-Security"
+	ContentSecurity    = "Content-Security-Policy"
+	FrameOptions       = "X-Frame-Options"
+	ContentTypeOptions = "X-Content-Type-Options"
+	ReferrerPolicy     = "Referrer-Policy"
+	PermissionsPolicy  = "Permissions-Policy"
+	Cookies            = "Set-Cookie"
+)
+
+// recommendedHSTSAge is the lowest max-age considered safe, while preloadHSTSAge is the
+// lowest one accepted by the HSTS preload list
+const (
+	recommendedHSTSAge = 180 * 24 * 60 * 60
+	preloadHSTSAge     = 365 * 24 * 60 * 60
+)
+
+// Report represents the audit of the security headers sent by a website
+type Report struct {
+	Score    int             `json:"score"`
+	HSTS     *HSTSPolicy     `json:"hsts"`
+	Cookies  []Cookie        `json:"cookies"`
+	Findings []audit.Finding `json:"findings"`
+}
+
+// HSTSPolicy represents a parsed Strict-Transport-Security header
+type HSTSPolicy struct {
+	MaxAge            int  `json:"max_age"`
+	IncludeSubDomains bool `json:"include_subdomains"`
+	Preload           bool `json:"preload"`
+}
+
+// Cookie represents the security flags of a cookie set by the website
+type Cookie struct {
+	Name     string `json:"name"`
+	Secure   bool   `json:"secure"`
+	HTTPOnly bool   `json:"http_only"`
+	SameSite string `json:"same_site"`
+}
+
+// weights holds how much each check contributes to a score of 100
+var weights = map[string]int{
+	HSTS:               25,
+	ContentSecurity:    25,
+	FrameOptions:       10,
+	ContentTypeOptions: 10,
+	ReferrerPolicy:     10,
+	PermissionsPolicy:  10,
+	Cookies:            10,
+}
+
+var safeReferrerPolicies = map[string]bool{
+	"no-referrer":                     true,
+	"same-origin":                     true,
+	"strict-origin":                   true,
+	"strict-origin-when-cross-origin": true,
+}
+
+// Audit checks the headers of a response, served over HTTPS or not, and scores them
+func Audit(header http.Header, isHTTPS bool) Report {
+
+	report := Report{Cookies: []Cookie{}}
+
+	var hstsFinding audit.Finding
+
+	hstsFinding, report.HSTS = auditHSTS(header.Get(HSTS), isHTTPS)
+
+	report.Findings = []audit.Finding{
+		hstsFinding,
+		auditContentSecurityPolicy(header.Get(ContentSecurity)),
+		auditFrameOptions(header.Get(FrameOptions), header.Get(ContentSecurity)),
+		auditContentTypeOptions(header.Get(ContentTypeOptions)),
+		auditReferrerPolicy(header.Get(ReferrerPolicy)),
+		auditPermissionsPolicy(header.Get(PermissionsPolicy)),
+	}
+
+	cookieFinding, cookies := auditCookies(header, isHTTPS)
+
+	report.Findings = append(report.Findings, cookieFinding)
+	report.Cookies = append(report.Cookies, cookies...)
+
+	report.Score = audit.Score(report.Findings, weights)
+
+	return report
+
+}
+
+// ParseHSTS returns the policy declared by a Strict-Transport-Security header
+func ParseHSTS(value string) HSTSPolicy {
+
+	var policy HSTSPolicy
+
+	for _, directive := range strings.Split(value, ";") {
+
+		name, argument := splitDirective(directive)
+
+		switch name {
+		case "max-age":
+			policy.MaxAge, _ = strconv.Atoi(strings.Trim(argument, `"`))
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+
+	}
+
+	return policy
+
+}
+
+func auditHSTS(value string, isHTTPS bool) (audit.Finding, *HSTSPolicy) {
+
+	finding := audit.Finding{Check: HSTS, Value: value}
+
+	if !isHTTPS {
+		finding.Status = audit.Fail
+		finding.Message = "Website is not served over HTTPS"
+		return finding, nil
+	}
+
+	if value == "" {
+		finding.Status = audit.Fail
+		finding.Message = "Header is missing"
+		return finding, nil
+	}
+
+	parsed := ParseHSTS(value)
+
+	switch {
+	case parsed.MaxAge <= 0:
+		finding.Status = audit.Fail
+		finding.Message = "max-age is missing or disables the policy"
+	case parsed.MaxAge < recommendedHSTSAge:
+		finding.Status = audit.Warn
+		finding.Message = fmt.Sprintf("max-age is lower than %d seconds", recommendedHSTSAge)
+	case parsed.Preload && (!parsed.IncludeSubDomains || parsed.MaxAge < preloadHSTSAge):
+		finding.Status = audit.Warn
+		finding.Message = "preload requires includeSubDomains and a max-age of at least one year"
+	default:
+		finding.Status = audit.Pass
+	}
+
+	return finding, &parsed
+
+}
+
+func auditContentSecurityPolicy(value string) audit.Finding {
+
+	finding := audit.Finding{Check: ContentSecurity, Value: value}
+
+	if value == "" {
+		finding.Status = audit.Fail
+		finding.Message = "Header is missing"
+		return finding
+	}
+
+	directives := parseDirectives(value)
+
+	scriptSources, exists := directives["script-src"]
+	if !exists {
+		scriptSources, exists = directives["default-src"]
+	}
+
+	switch {
+	case !exists:
+		finding.Status = audit.Warn
+		finding.Message = "Neither script-src nor default-src restrict scripts"
+	case strings.Contains(scriptSources, "'unsafe-inline'") && !strings.Contains(scriptSources, "'nonce-") && !strings.Contains(scriptSources, "'sha"):
+		finding.Status = audit.Warn
+		finding.Message = "Scripts allow 'unsafe-inline'"
+	case strings.Contains(scriptSources, "'unsafe-eval'"):
+		finding.Status = audit.Warn
+		finding.Message = "Scripts allow 'unsafe-eval'"
+	case hasWildcardSource(scriptSources):
+		finding.Status = audit.Warn
+		finding.Message = "Scripts can be loaded from any origin"
+	default:
+		finding.Status = audit.Pass
+	}
+
+	return finding
+
+}
+
+func auditFrameOptions(value, contentSecurityPolicy string) audit.Finding {
+
+	finding := audit.Finding{Check: FrameOptions, Value: value}
+
+	if _, exists := parseDirectives(contentSecurityPolicy)["frame-ancestors"]; exists {
+		finding.Status = audit.Pass
+		finding.Message = "Framing is restricted by the frame-ancestors directive"
+		return finding
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "DENY", "SAMEORIGIN":
+		finding.Status = audit.Pass
+	case "":
+		finding.Status = audit.Fail
+		finding.Message = "Header is missing"
+	default:
+		finding.Status = audit.Warn
+		finding.Message = "Value is not supported by current browsers"
+	}
+
+	return finding
+
+}
+
+func auditContentTypeOptions(value string) audit.Finding {
+
+	finding := audit.Finding{Check: ContentTypeOptions, Value: value}
+
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "nosniff":
+		finding.Status = audit.Pass
+	case "":
+		finding.Status = audit.Fail
+		finding.Message = "Header is missing"
+	default:
+		finding.Status = audit.Fail
+		finding.Message = "Value must be nosniff"
+	}
+
+	return finding
+
+}
+
+func auditReferrerPolicy(value string) audit.Finding {
+
+	finding := audit.Finding{Check: ReferrerPolicy, Value: value}
+
+	if value == "" {
+		finding.Status = audit.Fail
+		finding.Message = "Header is missing"
+		return finding
+	}
+
+	// browsers use the last policy they support when several are listed
+	policies := strings.Split(value, ",")
+	policy := strings.ToLower(strings.TrimSpace(policies[len(policies)-1]))
+
+	if safeReferrerPolicies[policy] {
+		finding.Status = audit.Pass
+		return finding
+	}
+
+	finding.Status = audit.Warn
+	finding.Message = fmt.Sprintf("Policy %q may leak URLs to other origins", policy)
+
+	return finding
+
+}
+
+func auditPermissionsPolicy(value string) audit.Finding {
+
+	finding := audit.Finding{Check: PermissionsPolicy, Value: value, Status: audit.Pass}
+
+	if value == "" {
+		finding.Status = audit.Fail
+		finding.Message = "Header is missing"
+	}
+
+	return finding
+
+}
+
+func auditCookies(header http.Header, isHTTPS bool) (audit.Finding, []Cookie) {
+
+	finding := audit.Finding{Check: Cookies, Status: audit.Pass}
+
+	var cookies []Cookie
+	var insecure []string
+
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+
+		flags := Cookie{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HTTPOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		}
+
+		cookies = append(cookies, flags)
+
+		if (isHTTPS && !flags.Secure) || !flags.HTTPOnly || flags.SameSite == "" || (flags.SameSite == "None" && !flags.Secure) {
+			insecure = append(insecure, flags.Name)
+		}
+
+	}
+
+	switch {
+	case len(cookies) == 0:
+		finding.Message = "No cookies are set"
+	case len(insecure) > 0:
+		finding.Status = audit.Warn
+		finding.Value = strings.Join(insecure, ", ")
+		finding.Message = "Some cookies lack the Secure, HttpOnly or SameSite flags"
+	}
+
+	return finding, cookies
+
+}
+
+func sameSiteName(mode http.SameSite) string {
+
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+
+	return ""
+
+}
+
+// parseDirectives returns the directives of a Content-Security-Policy header by name
+func parseDirectives(value string) map[string]string {
+
+	directives := make(map[string]string)
+
+	for _, directive := range strings.Split(value, ";") {
+
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+
+		directives[strings.ToLower(fields[0])] = strings.Join(fields[1:], " ")
+
+	}
+
+	return directives
+
+}
+
+func hasWildcardSource(sources string) bool {
+
+	for _, source := range strings.Fields(sources) {
+		if source == "*" || source == "http:" || source == "https:" {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func splitDirective(directive string) (string, string) {
+
+	parts := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+
+	name := strings.ToLower(strings.TrimSpace(parts[0]))
+
+	if len(parts) == 1 {
+		return name, ""
+	}
+
+	return name, strings.TrimSpace(parts[1])
+
+}
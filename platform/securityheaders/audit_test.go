@@ -0,0 +1,93 @@
+package securityheaders
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"domain-info-api/platform/audit"
+)
+
+func TestAudit(t *testing.T) {
+
+	t.Run("hardened website", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set(HSTS, "max-age=63072000; includeSubDomains; preload")
+		header.Set(ContentSecurity, "default-src 'self'; frame-ancestors 'none'")
+		header.Set(ContentTypeOptions, "nosniff")
+		header.Set(ReferrerPolicy, "strict-origin-when-cross-origin")
+		header.Set(PermissionsPolicy, "camera=()")
+		header.Add("Set-Cookie", "session=abc; Secure; HttpOnly; SameSite=Strict")
+
+		report := Audit(header, true)
+
+		if report.Score != 100 {
+			t.Errorf("got score %d, want 100: %+v", report.Score, report.Findings)
+		}
+
+		wantHSTS := &HSTSPolicy{MaxAge: 63072000, IncludeSubDomains: true, Preload: true}
+		if !reflect.DeepEqual(report.HSTS, wantHSTS) {
+			t.Errorf("got HSTS %+v, want %+v", report.HSTS, wantHSTS)
+		}
+
+		wantCookies := []Cookie{{Name: "session", Secure: true, HTTPOnly: true, SameSite: "Strict"}}
+		if !reflect.DeepEqual(report.Cookies, wantCookies) {
+			t.Errorf("got cookies %+v, want %+v", report.Cookies, wantCookies)
+		}
+
+	})
+
+	t.Run("bare website", func(t *testing.T) {
+
+		report := Audit(http.Header{}, false)
+
+		// only the cookie check passes since no cookie is set
+		if report.Score != 10 {
+			t.Errorf("got score %d, want 10", report.Score)
+		}
+
+		for _, finding := range report.Findings {
+			if finding.Check != Cookies && finding.Status != audit.Fail {
+				t.Errorf("got %s for %s, want %s", finding.Status, finding.Check, audit.Fail)
+			}
+		}
+
+	})
+
+	t.Run("weak values", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set(HSTS, "max-age=3600")
+		header.Set(ContentSecurity, "script-src 'self' 'unsafe-inline'")
+		header.Set(FrameOptions, "ALLOW-FROM https://example.com")
+		header.Set(ContentTypeOptions, "nosniff")
+		header.Set(ReferrerPolicy, "unsafe-url")
+		header.Set(PermissionsPolicy, "geolocation=()")
+		header.Add("Set-Cookie", "tracking=1; SameSite=None")
+
+		report := Audit(header, true)
+
+		want := map[string]string{
+			HSTS:               audit.Warn,
+			ContentSecurity:    audit.Warn,
+			FrameOptions:       audit.Warn,
+			ContentTypeOptions: audit.Pass,
+			ReferrerPolicy:     audit.Warn,
+			PermissionsPolicy:  audit.Pass,
+			Cookies:            audit.Warn,
+		}
+
+		for _, finding := range report.Findings {
+			if finding.Status != want[finding.Check] {
+				t.Errorf("got %s for %s, want %s", finding.Status, finding.Check, want[finding.Check])
+			}
+		}
+
+		if report.Score != 60 {
+			t.Errorf("got score %d, want 60", report.Score)
+		}
+
+	})
+
+}
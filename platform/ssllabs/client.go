@@ -0,0 +1,438 @@
+package ssllabs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	wrappedErr "domain-info-api/platform/errorhandling"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultBaseURL = "https://api.ssllabs.com/api/v3"
+
+	// defaultMaxAssessments is only used when SSL Labs did not announce its limit
+	defaultMaxAssessments = 1
+)
+
+// Options represents the optional parameters accepted by the analyze endpoint
+type Options struct {
+	StartNew  bool
+	FromCache bool
+	MaxAge    int
+}
+
+// Client represents a consumer of the SSL Labs API shared by every assessment,
+// keeping track of the concurrency limits announced by the service
+type Client struct {
+	BaseURL                string
+	HTTPClient             *fasthttp.Client
+	PollInterval           time.Duration
+	Timeout                time.Duration
+	TooManyRequestsBackoff time.Duration
+	UnavailableBackoff     time.Duration
+	OverloadedBackoff      time.Duration
+
+	mutex              sync.Mutex
+	slotReleased       *sync.Cond
+	discovery          sync.Mutex
+	limitsKnown        bool
+	maxAssessments     int
+	currentAssessments int
+	runningAssessments int
+	coolOffUntil       time.Time
+}
+
+// NewClient returns a Client with the default settings for the public SSL Labs API
+func NewClient() *Client {
+
+	client := &Client{
+		BaseURL:                defaultBaseURL,
+		HTTPClient:             &fasthttp.Client{},
+		PollInterval:           15 * time.Second,
+		Timeout:                2 * time.Minute,
+		TooManyRequestsBackoff: 30 * time.Second,
+		UnavailableBackoff:     15 * time.Minute,
+		OverloadedBackoff:      30 * time.Minute,
+		maxAssessments:         defaultMaxAssessments,
+	}
+
+	client.slotReleased = sync.NewCond(&client.mutex)
+
+	return client
+
+}
+
+// Analyze returns status and endpoints of the specified domain, waiting in queue
+// whenever the number of running assessments reaches the limit set by SSL Labs. Only
+// assessments that are started or still running take a slot, cached reports are
+// returned right away
+func (c *Client) Analyze(domain string, options Options) (*Response, *wrappedErr.Error) {
+
+	var responseObject Response
+	var customErr *wrappedErr.Error
+
+	deadline := time.Now().Add(c.Timeout)
+
+	holdingSlot := false
+
+	defer func() {
+		if holdingSlot {
+			c.releaseSlot()
+		}
+	}()
+
+	startNew := options.StartNew
+
+	if startNew {
+
+		customErr = c.acquireSlot(deadline)
+		if customErr != nil {
+			return &Response{}, customErr
+		}
+
+		holdingSlot = true
+
+	}
+
+	for {
+
+		body, customErr := c.get("analyze", c.analyzeArgs(domain, options, startNew), deadline)
+		if customErr != nil {
+			return &Response{}, customErr
+		}
+
+		startNew = false
+
+		err := json.Unmarshal(body, &responseObject)
+		if err != nil {
+			errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+			customErr = wrappedErr.New(fasthttp.StatusInternalServerError, "Analyze", errMessage)
+			log.Println(customErr)
+			return &Response{}, customErr
+		}
+
+		status := responseObject.Status
+
+		log.Printf("Domain: '%s'. SSL API Status: %s", domain, status)
+
+		if status == "READY" || status == "ERROR" {
+			return &responseObject, nil
+		}
+
+		if status != "DNS" && status != "IN_PROGRESS" {
+			errMessage := fmt.Sprintf("Unknown status found on SSL Labs API response. Try again later")
+			customErr = wrappedErr.New(fasthttp.StatusNotImplemented, "Analyze", errMessage)
+			log.Println(customErr)
+			return &Response{}, customErr
+		}
+
+		if !holdingSlot {
+
+			customErr = c.acquireSlot(deadline)
+			if customErr != nil {
+				return &Response{}, customErr
+			}
+
+			holdingSlot = true
+
+		}
+
+		if time.Now().Add(c.PollInterval).After(deadline) {
+			return &Response{}, timeoutError("Analyze")
+		}
+
+		time.Sleep(c.PollInterval)
+
+	}
+
+}
+
+// GetEndpointData returns the detailed assessment of a single endpoint of the given domain, such
+// as one whose assessment completed after the rest of the host was reported
+func (c *Client) GetEndpointData(domain, IPAddress string) (*EndPoint, *wrappedErr.Error) {
+
+	var endPoint EndPoint
+	var customErr *wrappedErr.Error
+
+	args := fasthttp.AcquireArgs()
+	args.Set("host", domain)
+	args.Set("s", IPAddress)
+	args.Set("fromCache", "on")
+
+	body, customErr := c.get("getEndpointData", args, time.Now().Add(c.Timeout))
+	if customErr != nil {
+		return &EndPoint{}, customErr
+	}
+
+	err := json.Unmarshal(body, &endPoint)
+	if err != nil {
+		errMessage := fmt.Sprintf("JSON encoding failed: %s", err.Error())
+		customErr = wrappedErr.New(fasthttp.StatusInternalServerError, "GetEndpointData", errMessage)
+		log.Println(customErr)
+		return &EndPoint{}, customErr
+	}
+
+	return &endPoint, nil
+
+}
+
+// get performs a GET request to the given endpoint, backing off while SSL Labs
+// reports being overloaded, and returns the body of the successful response
+func (c *Client) get(endpoint string, args *fasthttp.Args, deadline time.Time) ([]byte, *wrappedErr.Error) {
+
+	defer fasthttp.ReleaseArgs(args)
+
+	backoff := c.TooManyRequestsBackoff
+
+	for {
+
+		customErr := c.waitCoolOff(deadline)
+		if customErr != nil {
+			return nil, customErr
+		}
+
+		statusCode, body, customErr := c.request(endpoint, args)
+		if customErr != nil {
+			return nil, customErr
+		}
+
+		switch statusCode {
+		case fasthttp.StatusOK:
+			return body, nil
+		case fasthttp.StatusTooManyRequests:
+			c.coolOff(backoff)
+			backoff *= 2
+		case fasthttp.StatusServiceUnavailable:
+			c.coolOff(c.UnavailableBackoff)
+		case 529:
+			c.coolOff(c.OverloadedBackoff)
+		default:
+			errMessage := fmt.Sprintf("SSL API consumption failed: unexpected status code %d", statusCode)
+			customErr = wrappedErr.New(fasthttp.StatusInternalServerError, "get", errMessage)
+			log.Println(customErr)
+			return nil, customErr
+		}
+
+	}
+
+}
+
+// analyzeArgs returns the parameters of an analyze poll. SSL Labs only honours all=done once
+// the assessment is over, so polls in progress stay light and the final one carries the
+// details of every endpoint along with the certificates of the host
+func (c *Client) analyzeArgs(domain string, options Options, startNew bool) *fasthttp.Args {
+
+	args := fasthttp.AcquireArgs()
+	args.Set("host", domain)
+	args.Set("all", "done")
+
+	if startNew {
+		args.Set("startNew", "on")
+	} else if options.FromCache {
+		args.Set("fromCache", "on")
+		if options.MaxAge > 0 {
+			args.SetUint("maxAge", options.MaxAge)
+		}
+	}
+
+	return args
+
+}
+
+// request performs a GET request to the given endpoint and records the assessment
+// limits found in the response headers
+func (c *Client) request(endpoint string, args *fasthttp.Args) (int, []byte, *wrappedErr.Error) {
+
+	var customErr *wrappedErr.Error
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(fmt.Sprintf("%s/%s?%s", c.BaseURL, endpoint, args.QueryString()))
+
+	err := c.HTTPClient.Do(req, resp)
+	if err != nil {
+		errMessage := fmt.Sprintf("SSL API consumption failed: %s", err.Error())
+		customErr = wrappedErr.New(fasthttp.StatusInternalServerError, "request", errMessage)
+		log.Println(customErr)
+		return 0, nil, customErr
+	}
+
+	c.trackAssessments(resp)
+
+	body := append([]byte(nil), resp.Body()...)
+
+	return resp.StatusCode(), body, nil
+
+}
+
+func (c *Client) trackAssessments(resp *fasthttp.Response) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if max, err := strconv.Atoi(string(resp.Header.Peek("X-Max-Assessments"))); err == nil && max > 0 {
+		c.maxAssessments = max
+		c.limitsKnown = true
+	}
+
+	if current, err := strconv.Atoi(string(resp.Header.Peek("X-Current-Assessments"))); err == nil {
+		c.currentAssessments = current
+	}
+
+	c.slotReleased.Broadcast()
+
+}
+
+// discoverLimits asks SSL Labs how many assessments may run at once when no response
+// announced it yet, keeping the default limit when the info endpoint cannot be reached
+func (c *Client) discoverLimits(deadline time.Time) {
+
+	c.discovery.Lock()
+	defer c.discovery.Unlock()
+
+	c.mutex.Lock()
+	known := c.limitsKnown
+	c.mutex.Unlock()
+
+	if known {
+		return
+	}
+
+	body, customErr := c.get("info", fasthttp.AcquireArgs(), deadline)
+	if customErr != nil {
+		return
+	}
+
+	var info struct {
+		MaxAssessments     int `json:"maxAssessments"`
+		CurrentAssessments int `json:"currentAssessments"`
+	}
+
+	if err := json.Unmarshal(body, &info); err != nil || info.MaxAssessments <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.limitsKnown {
+		c.maxAssessments = info.MaxAssessments
+		c.currentAssessments = info.CurrentAssessments
+		c.limitsKnown = true
+	}
+
+}
+
+// acquireSlot blocks until an assessment can be started without exceeding the
+// limit announced by SSL Labs, or until the deadline is reached
+func (c *Client) acquireSlot(deadline time.Time) *wrappedErr.Error {
+
+	c.discoverLimits(deadline)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	timer := time.AfterFunc(time.Until(deadline), func() {
+		c.mutex.Lock()
+		c.slotReleased.Broadcast()
+		c.mutex.Unlock()
+	})
+
+	defer timer.Stop()
+
+	for !c.slotAvailable() {
+
+		if !time.Now().Before(deadline) {
+			return timeoutError("acquireSlot")
+		}
+
+		c.slotReleased.Wait()
+
+	}
+
+	c.runningAssessments++
+
+	return nil
+
+}
+
+// slotAvailable reports whether a new assessment fits within the announced limit.
+// The current count reported by SSL Labs is only trusted while one of our own
+// assessments keeps polling, otherwise it could never be refreshed
+func (c *Client) slotAvailable() bool {
+
+	if c.runningAssessments >= c.maxAssessments {
+		return false
+	}
+
+	return c.runningAssessments == 0 || c.currentAssessments < c.maxAssessments
+
+}
+
+func (c *Client) releaseSlot() {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.runningAssessments--
+	c.slotReleased.Broadcast()
+
+}
+
+// coolOff stops every assessment from hitting the API for the given duration
+func (c *Client) coolOff(duration time.Duration) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	until := time.Now().Add(duration)
+	if until.After(c.coolOffUntil) {
+		c.coolOffUntil = until
+	}
+
+	log.Printf("SSL API overloaded. Backing off until %s", c.coolOffUntil.Format(time.RFC3339))
+
+}
+
+func (c *Client) waitCoolOff(deadline time.Time) *wrappedErr.Error {
+
+	c.mutex.Lock()
+	until := c.coolOffUntil
+	c.mutex.Unlock()
+
+	if !until.After(time.Now()) {
+		return nil
+	}
+
+	if until.After(deadline) {
+		errMessage := fmt.Sprint("SSL Labs API is overloaded. Try again later")
+		customErr := wrappedErr.New(fasthttp.StatusServiceUnavailable, "waitCoolOff", errMessage)
+		log.Println(customErr)
+		return customErr
+	}
+
+	time.Sleep(time.Until(until))
+
+	return nil
+
+}
+
+func timeoutError(methodName string) *wrappedErr.Error {
+
+	errMessage := fmt.Sprint("Domain could not be resolved in time. Try again later")
+	customErr := wrappedErr.New(fasthttp.StatusRequestTimeout, methodName, errMessage)
+	log.Println(customErr)
+
+	return customErr
+
+}
@@ -0,0 +1,267 @@
+package ssllabs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(serverURL string) *Client {
+
+	client := NewClient()
+	client.BaseURL = serverURL
+	client.PollInterval = time.Millisecond
+	client.Timeout = time.Second
+	client.TooManyRequestsBackoff = time.Millisecond
+
+	return client
+
+}
+
+func TestAnalyzeBacksOffOnTooManyRequests(t *testing.T) {
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Set("X-Max-Assessments", "3")
+		w.Header().Set("X-Current-Assessments", "0")
+
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			fmt.Fprint(w, `{"status":"IN_PROGRESS"}`)
+		default:
+			fmt.Fprint(w, `{"status":"READY","endpoints":[{"ipAddress":"1.1.1.1","grade":"A"}]}`)
+		}
+
+	}))
+
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	response, customErr := client.Analyze("test.com", Options{})
+	if customErr != nil {
+		t.Fatalf("didn't expect an error: %s", customErr)
+	}
+
+	if response.Status != "READY" || len(response.EndPoints) != 1 {
+		t.Errorf("got %+v, want a READY response with one endpoint", response)
+	}
+
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+
+	if client.maxAssessments != 3 {
+		t.Errorf("got max assessments %d, want 3", client.maxAssessments)
+	}
+
+}
+
+func TestAnalyzeReturnsEndpointDetails(t *testing.T) {
+
+	var paths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		paths = append(paths, r.URL.Path)
+
+		if r.URL.Query().Get("all") != "done" {
+			http.Error(w, "missing all=done", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, `{"host":"test.com","status":"READY","endpoints":[
+			{"ipAddress":"1.1.1.1","statusMessage":"Ready","grade":"A","details":{"forwardSecrecy":4,"heartbleed":false}},
+			{"ipAddress":"2.2.2.2","statusMessage":"Unable to connect to the server"}
+		],"certs":[{"id":"leaf","subject":"CN=test.com"}]}`)
+
+	}))
+
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	response, customErr := client.Analyze("test.com", Options{})
+	if customErr != nil {
+		t.Fatalf("didn't expect an error: %s", customErr)
+	}
+
+	if details := response.EndPoints[0].Details; details == nil || details.ForwardSecrecy != 4 {
+		t.Errorf("got %+v, want the details of the ready endpoint", details)
+	}
+
+	if len(response.Certs) != 1 {
+		t.Errorf("got %+v, want the certificates of the host", response.Certs)
+	}
+
+	if len(paths) != 1 || paths[0] != "/analyze" {
+		t.Errorf("got requests to %v, want a single analyze call", paths)
+	}
+
+}
+
+func TestGetEndpointData(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Path != "/getEndpointData" || r.URL.Query().Get("host") != "test.com" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprintf(w, `{"ipAddress":"%s","details":{"forwardSecrecy":4,"heartbleed":false}}`, r.URL.Query().Get("s"))
+
+	}))
+
+	defer server.Close()
+
+	endPoint, customErr := newTestClient(server.URL).GetEndpointData("test.com", "1.1.1.1")
+	if customErr != nil {
+		t.Fatalf("didn't expect an error: %s", customErr)
+	}
+
+	if endPoint.IPAddress != "1.1.1.1" || endPoint.Details == nil || endPoint.Details.ForwardSecrecy != 4 {
+		t.Errorf("got %+v, want the details of the endpoint", endPoint)
+	}
+
+}
+
+func TestAnalyzeQueuesAssessmentsAtCapacity(t *testing.T) {
+
+	var running, maxRunning int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		w.Header().Set("X-Max-Assessments", "2")
+		fmt.Fprint(w, `{"status":"READY"}`)
+
+	}))
+
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, customErr := client.Analyze(fmt.Sprintf("test%d.com", i), Options{StartNew: true})
+			if customErr != nil {
+				t.Errorf("didn't expect an error: %s", customErr)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if maxRunning > 2 {
+		t.Errorf("got %d concurrent assessments, want at most 2", maxRunning)
+	}
+
+}
+
+func TestAnalyzeReturnsCachedReportsWithoutSlot(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Max-Assessments", "1")
+		fmt.Fprint(w, `{"status":"READY"}`)
+	}))
+
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.runningAssessments = 1
+
+	response, customErr := client.Analyze("test.com", Options{})
+	if customErr != nil {
+		t.Fatalf("didn't expect an error while every slot is taken: %s", customErr)
+	}
+
+	if response.Status != "READY" || client.runningAssessments != 1 {
+		t.Errorf("got %+v and %d running assessments, want the cached report without a slot", response, client.runningAssessments)
+	}
+
+}
+
+func TestAnalyzeDiscoversLimitsBeforeStarting(t *testing.T) {
+
+	var paths []string
+	var mutex sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		mutex.Lock()
+		paths = append(paths, r.URL.Path)
+		mutex.Unlock()
+
+		if r.URL.Path == "/info" {
+			fmt.Fprint(w, `{"maxAssessments":4,"currentAssessments":1}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"status":"READY"}`)
+
+	}))
+
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	_, customErr := client.Analyze("test.com", Options{StartNew: true})
+	if customErr != nil {
+		t.Fatalf("didn't expect an error: %s", customErr)
+	}
+
+	if client.maxAssessments != 4 || len(paths) != 2 || paths[0] != "/info" {
+		t.Errorf("got max assessments %d after %v, want 4 read from /info first", client.maxAssessments, paths)
+	}
+
+}
+
+func TestAnalyzeArgs(t *testing.T) {
+
+	client := NewClient()
+
+	var tests = []struct {
+		options  Options
+		startNew bool
+		want     string
+	}{
+		{options: Options{}, want: "host=test.com&all=done"},
+		{options: Options{StartNew: true}, startNew: true, want: "host=test.com&all=done&startNew=on"},
+		{options: Options{StartNew: true}, want: "host=test.com&all=done"},
+		{options: Options{FromCache: true, MaxAge: 12}, want: "host=test.com&all=done&fromCache=on&maxAge=12"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			got := string(client.analyzeArgs("test.com", test.options, test.startNew).QueryString())
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+
+}
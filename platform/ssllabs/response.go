@@ -2,12 +2,350 @@ package ssllabs
 
 // Response represents the response from SSL Labs API
 type Response struct {
-	Status    string     `json:"status"`
-	EndPoints []EndPoint `json:"endpoints"`
+	Host            string     `json:"host"`
+	Port            int        `json:"port"`
+	Protocol        string     `json:"protocol"`
+	IsPublic        bool       `json:"isPublic"`
+	Status          string     `json:"status"`
+	StatusMessage   string     `json:"statusMessage"`
+	StartTime       int64      `json:"startTime"`
+	TestTime        int64      `json:"testTime"`
+	EngineVersion   string     `json:"engineVersion"`
+	CriteriaVersion string     `json:"criteriaVersion"`
+	CacheExpiryTime int64      `json:"cacheExpiryTime"`
+	CertHostnames   []string   `json:"certHostnames"`
+	EndPoints       []EndPoint `json:"endpoints"`
+	Certs           []Cert     `json:"certs"`
 }
 
 // EndPoint represents info for a given server endpoint
 type EndPoint struct {
-	IPAddress string `json:"ipAddress"`
-	Grade     string `json:"grade"`
+	IPAddress            string           `json:"ipAddress"`
+	ServerName           string           `json:"serverName"`
+	StatusMessage        string           `json:"statusMessage"`
+	StatusDetails        string           `json:"statusDetails"`
+	StatusDetailsMessage string           `json:"statusDetailsMessage"`
+	Grade                string           `json:"grade"`
+	GradeTrustIgnored    string           `json:"gradeTrustIgnored"`
+	FutureGrade          string           `json:"futureGrade"`
+	HasWarnings          bool             `json:"hasWarnings"`
+	IsExceptional        bool             `json:"isExceptional"`
+	Progress             int              `json:"progress"`
+	Duration             int64            `json:"duration"`
+	ETA                  int64            `json:"eta"`
+	Delegation           int              `json:"delegation"`
+	Details              *EndPointDetails `json:"details,omitempty"`
+}
+
+// EndPointDetails represents the full assessment of a given server endpoint
+type EndPointDetails struct {
+	HostStartTime                  int64             `json:"hostStartTime"`
+	CertChains                     []CertChain       `json:"certChains"`
+	Protocols                      []Protocol        `json:"protocols"`
+	Suites                         []ProtocolSuites  `json:"suites"`
+	NoSniSuites                    *ProtocolSuites   `json:"noSniSuites,omitempty"`
+	NamedGroups                    *NamedGroups      `json:"namedGroups,omitempty"`
+	ServerSignature                string            `json:"serverSignature"`
+	PrefixDelegation               bool              `json:"prefixDelegation"`
+	NonPrefixDelegation            bool              `json:"nonPrefixDelegation"`
+	VulnBeast                      bool              `json:"vulnBeast"`
+	RenegSupport                   int               `json:"renegSupport"`
+	SessionResumption              int               `json:"sessionResumption"`
+	CompressionMethods             int               `json:"compressionMethods"`
+	SupportsNpn                    bool              `json:"supportsNpn"`
+	NpnProtocols                   string            `json:"npnProtocols"`
+	SupportsAlpn                   bool              `json:"supportsAlpn"`
+	AlpnProtocols                  string            `json:"alpnProtocols"`
+	SessionTickets                 int               `json:"sessionTickets"`
+	OcspStapling                   bool              `json:"ocspStapling"`
+	StaplingRevocationStatus       int               `json:"staplingRevocationStatus"`
+	StaplingRevocationErrorMessage string            `json:"staplingRevocationErrorMessage"`
+	SniRequired                    bool              `json:"sniRequired"`
+	HTTPStatusCode                 int               `json:"httpStatusCode"`
+	HTTPForwarding                 string            `json:"httpForwarding"`
+	SupportsRc4                    bool              `json:"supportsRc4"`
+	Rc4WithModern                  bool              `json:"rc4WithModern"`
+	Rc4Only                        bool              `json:"rc4Only"`
+	ForwardSecrecy                 int               `json:"forwardSecrecy"`
+	SupportsAead                   bool              `json:"supportsAead"`
+	SupportsCBC                    bool              `json:"supportsCBC"`
+	ProtocolIntolerance            int               `json:"protocolIntolerance"`
+	MiscIntolerance                int               `json:"miscIntolerance"`
+	Sims                           *SimDetails       `json:"sims,omitempty"`
+	Heartbleed                     bool              `json:"heartbleed"`
+	Heartbeat                      bool              `json:"heartbeat"`
+	OpenSslCcs                     int               `json:"openSslCcs"`
+	OpenSSLLuckyMinus20            int               `json:"openSSLLuckyMinus20"`
+	Ticketbleed                    int               `json:"ticketbleed"`
+	Bleichenbacher                 int               `json:"bleichenbacher"`
+	ZombiePoodle                   int               `json:"zombiePoodle"`
+	GoldenDoodle                   int               `json:"goldenDoodle"`
+	ZeroLengthPaddingOracle        int               `json:"zeroLengthPaddingOracle"`
+	SleepingPoodle                 int               `json:"sleepingPoodle"`
+	Poodle                         bool              `json:"poodle"`
+	PoodleTLS                      int               `json:"poodleTls"`
+	FallbackScsv                   bool              `json:"fallbackScsv"`
+	Freak                          bool              `json:"freak"`
+	HasSct                         int               `json:"hasSct"`
+	DhPrimes                       []string          `json:"dhPrimes"`
+	DhUsesKnownPrimes              int               `json:"dhUsesKnownPrimes"`
+	DhYsReuse                      bool              `json:"dhYsReuse"`
+	EcdhParameterReuse             bool              `json:"ecdhParameterReuse"`
+	Logjam                         bool              `json:"logjam"`
+	ChaCha20Preference             bool              `json:"chaCha20Preference"`
+	HstsPolicy                     *HstsPolicy       `json:"hstsPolicy,omitempty"`
+	HstsPreloads                   []HstsPreload     `json:"hstsPreloads"`
+	HpkpPolicy                     *HpkpPolicy       `json:"hpkpPolicy,omitempty"`
+	HpkpRoPolicy                   *HpkpPolicy       `json:"hpkpRoPolicy,omitempty"`
+	StaticPkpPolicy                *StaticPkpPolicy  `json:"staticPkpPolicy,omitempty"`
+	HTTPTransactions               []HTTPTransaction `json:"httpTransactions"`
+	DrownHosts                     []DrownHost       `json:"drownHosts"`
+	DrownErrors                    bool              `json:"drownErrors"`
+	DrownVulnerable                bool              `json:"drownVulnerable"`
+	ImplementsTLS13MandatoryCS     bool              `json:"implementsTLS13MandatoryCS"`
+	ZeroRTTEnabled                 int               `json:"zeroRTTEnabled"`
+}
+
+// CertChain represents a certificate chain sent by the server
+type CertChain struct {
+	ID         string      `json:"id"`
+	CertIDs    []string    `json:"certIds"`
+	TrustPaths []TrustPath `json:"trustPaths"`
+	Issues     int         `json:"issues"`
+	NoSni      bool        `json:"noSni"`
+}
+
+// TrustPath represents a path from a certificate chain to a trusted root
+type TrustPath struct {
+	CertIDs       []string `json:"certIds"`
+	Trust         []Trust  `json:"trust"`
+	IsPinned      bool     `json:"isPinned"`
+	MatchedPins   int      `json:"matchedPins"`
+	UnMatchedPins int      `json:"unMatchedPins"`
+}
+
+// Trust represents the trust status of a path in a given root store
+type Trust struct {
+	RootStore         string `json:"rootStore"`
+	IsTrusted         bool   `json:"isTrusted"`
+	TrustErrorMessage string `json:"trustErrorMessage"`
+}
+
+// Protocol represents a protocol version supported by the server
+type Protocol struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	Version          string `json:"version"`
+	V2SuitesDisabled bool   `json:"v2SuitesDisabled"`
+	Q                *int   `json:"q,omitempty"`
+}
+
+// ProtocolSuites represents the cipher suites supported for a given protocol
+type ProtocolSuites struct {
+	Protocol           int     `json:"protocol"`
+	List               []Suite `json:"list"`
+	Preference         bool    `json:"preference"`
+	ChaCha20Preference bool    `json:"chaCha20Preference"`
+}
+
+// Suite represents a cipher suite supported by the server
+type Suite struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	CipherStrength int    `json:"cipherStrength"`
+	KxType         string `json:"kxType"`
+	KxStrength     int    `json:"kxStrength"`
+	DhP            int    `json:"dhP"`
+	DhG            int    `json:"dhG"`
+	DhYs           int    `json:"dhYs"`
+	NamedGroupBits int    `json:"namedGroupBits"`
+	NamedGroupID   int    `json:"namedGroupId"`
+	NamedGroupName string `json:"namedGroupName"`
+	Q              *int   `json:"q,omitempty"`
+}
+
+// NamedGroups represents the key exchange groups supported by the server
+type NamedGroups struct {
+	List       []NamedGroup `json:"list"`
+	Preference bool         `json:"preference"`
+}
+
+// NamedGroup represents a single key exchange group
+type NamedGroup struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Bits int    `json:"bits"`
+}
+
+// SimDetails represents the handshake simulation results
+type SimDetails struct {
+	Results []Simulation `json:"results"`
+}
+
+// Simulation represents the handshake simulated for a given client
+type Simulation struct {
+	Client         SimClient `json:"client"`
+	ErrorCode      int       `json:"errorCode"`
+	ErrorMessage   string    `json:"errorMessage"`
+	Attempts       int       `json:"attempts"`
+	CertChainID    string    `json:"certChainId"`
+	ProtocolID     int       `json:"protocolId"`
+	SuiteID        int       `json:"suiteId"`
+	SuiteName      string    `json:"suiteName"`
+	KxType         string    `json:"kxType"`
+	KxStrength     int       `json:"kxStrength"`
+	DhBits         int       `json:"dhBits"`
+	DhP            int       `json:"dhP"`
+	DhG            int       `json:"dhG"`
+	DhYs           int       `json:"dhYs"`
+	NamedGroupBits int       `json:"namedGroupBits"`
+	NamedGroupID   int       `json:"namedGroupId"`
+	NamedGroupName string    `json:"namedGroupName"`
+	AlertType      int       `json:"alertType"`
+	AlertCode      int       `json:"alertCode"`
+	KeyAlg         string    `json:"keyAlg"`
+	KeySize        int       `json:"keySize"`
+	SigAlg         string    `json:"sigAlg"`
+}
+
+// SimClient represents the client used in a handshake simulation
+type SimClient struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Platform    string `json:"platform"`
+	Version     string `json:"version"`
+	IsReference bool   `json:"isReference"`
+}
+
+// HstsPolicy represents the Strict-Transport-Security policy of the server
+type HstsPolicy struct {
+	LongMaxAge        int64             `json:"LONG_MAX_AGE"`
+	Header            string            `json:"header"`
+	Status            string            `json:"status"`
+	Error             string            `json:"error"`
+	MaxAge            int64             `json:"maxAge"`
+	IncludeSubDomains bool              `json:"includeSubDomains"`
+	Preload           bool              `json:"preload"`
+	Directives        map[string]string `json:"directives"`
+}
+
+// HstsPreload represents the status of the host in a given HSTS preload list
+type HstsPreload struct {
+	Source     string `json:"source"`
+	Hostname   string `json:"hostname"`
+	Status     string `json:"status"`
+	Error      string `json:"error"`
+	SourceTime int64  `json:"sourceTime"`
+}
+
+// HpkpPolicy represents the Public-Key-Pins policy of the server
+type HpkpPolicy struct {
+	Header            string      `json:"header"`
+	Status            string      `json:"status"`
+	Error             string      `json:"error"`
+	MaxAge            int64       `json:"maxAge"`
+	IncludeSubDomains bool        `json:"includeSubDomains"`
+	ReportURI         string      `json:"reportUri"`
+	Pins              []Pin       `json:"pins"`
+	MatchedPins       []Pin       `json:"matchedPins"`
+	Directives        []Directive `json:"directives"`
+}
+
+// StaticPkpPolicy represents the pinning policy preloaded in browsers
+type StaticPkpPolicy struct {
+	Status               string `json:"status"`
+	Error                string `json:"error"`
+	IncludeSubDomains    bool   `json:"includeSubDomains"`
+	ReportURI            string `json:"reportUri"`
+	Pins                 []Pin  `json:"pins"`
+	MatchedPins          []Pin  `json:"matchedPins"`
+	ForbiddenPins        []Pin  `json:"forbiddenPins"`
+	MatchedForbiddenPins []Pin  `json:"matchedForbiddenPins"`
+}
+
+// Pin represents a single public key pin
+type Pin struct {
+	HashFunction string `json:"hashFunction"`
+	Value        string `json:"value"`
+}
+
+// Directive represents a raw directive of a policy header
+type Directive struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HTTPTransaction represents a request made to the server during the assessment
+type HTTPTransaction struct {
+	RequestURL         string   `json:"requestUrl"`
+	StatusCode         int      `json:"statusCode"`
+	RequestLine        string   `json:"requestLine"`
+	RequestHeaders     []string `json:"requestHeaders"`
+	ResponseLine       string   `json:"responseLine"`
+	ResponseHeadersRaw []string `json:"responseHeadersRaw"`
+	ResponseHeaders    []Header `json:"responseHeaders"`
+	FragileServer      bool     `json:"fragileServer"`
+}
+
+// Header represents a single HTTP header
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DrownHost represents a server sharing the RSA key of the endpoint
+type DrownHost struct {
+	IP      string `json:"ip"`
+	Export  bool   `json:"export"`
+	Port    int    `json:"port"`
+	Special bool   `json:"special"`
+	SSLv2   bool   `json:"sslv2"`
+	Status  string `json:"status"`
+}
+
+// Cert represents a certificate sent by any of the endpoints
+type Cert struct {
+	ID                     string     `json:"id"`
+	Subject                string     `json:"subject"`
+	SerialNumber           string     `json:"serialNumber"`
+	CommonNames            []string   `json:"commonNames"`
+	AltNames               []string   `json:"altNames"`
+	NotBefore              int64      `json:"notBefore"`
+	NotAfter               int64      `json:"notAfter"`
+	IssuerSubject          string     `json:"issuerSubject"`
+	SigAlg                 string     `json:"sigAlg"`
+	RevocationInfo         int        `json:"revocationInfo"`
+	CrlURIs                []string   `json:"crlURIs"`
+	OcspURIs               []string   `json:"ocspURIs"`
+	RevocationStatus       int        `json:"revocationStatus"`
+	CrlRevocationStatus    int        `json:"crlRevocationStatus"`
+	OcspRevocationStatus   int        `json:"ocspRevocationStatus"`
+	DNSCaa                 bool       `json:"dnsCaa"`
+	CaaPolicy              *CaaPolicy `json:"caaPolicy,omitempty"`
+	MustStaple             bool       `json:"mustStaple"`
+	Sgc                    int        `json:"sgc"`
+	ValidationType         string     `json:"validationType"`
+	Issues                 int        `json:"issues"`
+	Sct                    bool       `json:"sct"`
+	Sha1Hash               string     `json:"sha1Hash"`
+	Sha256Hash             string     `json:"sha256Hash"`
+	PinSha256              string     `json:"pinSha256"`
+	KeyAlg                 string     `json:"keyAlg"`
+	KeySize                int        `json:"keySize"`
+	KeyStrength            int        `json:"keyStrength"`
+	KeyKnownDebianInsecure bool       `json:"keyKnownDebianInsecure"`
+	Raw                    string     `json:"raw"`
+}
+
+// CaaPolicy represents the CAA policy found by SSL Labs for the certificate
+type CaaPolicy struct {
+	PolicyHostname string      `json:"policyHostname"`
+	CaaRecords     []CaaRecord `json:"caaRecords"`
+}
+
+// CaaRecord represents a single CAA record
+type CaaRecord struct {
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+	Flags int    `json:"flags"`
 }
@@ -0,0 +1,274 @@
+package subdomains
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+
+	"domain-info-api/platform/dnsrecords"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Sources a subdomain can be discovered through
+const (
+	CertificateSource = "certificate"
+	DNSSource         = "dns"
+	WordlistSource    = "wordlist"
+)
+
+// Subdomain represents a name found under a domain along with how it was found and what it resolves to
+type Subdomain struct {
+	Name      string   `json:"name"`
+	Sources   []string `json:"sources"`
+	Addresses []string `json:"addresses"`
+}
+
+// Resolver represents what the discovery needs to query DNS records
+type Resolver interface {
+	Query(name string, recordType dnsmessage.Type) ([]dnsrecords.Record, error)
+}
+
+// Discoverer represents a client enumerating the subdomains of a domain
+type Discoverer struct {
+	Resolver    Resolver
+	Wordlist    []string
+	Concurrency int
+}
+
+// NewDiscoverer returns a Discoverer trying the given labels with the given resolver
+func NewDiscoverer(resolver Resolver, wordlist []string) *Discoverer {
+
+	return &Discoverer{
+		Resolver:    resolver,
+		Wordlist:    wordlist,
+		Concurrency: 10,
+	}
+
+}
+
+// Discover enumerates the subdomains of the given domain with the configuration found in the environment
+func Discover(domain string, certificateNames []string, inventory dnsrecords.Inventory) ([]Subdomain, error) {
+
+	wordlist, err := WordlistFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDiscoverer(dnsrecords.NewResolver(dnsrecords.ConfigFromEnv()), wordlist).Discover(domain, certificateNames, inventory), nil
+
+}
+
+// Discover returns the subdomains found among the names of the certificates, the hosts the DNS
+// records point to and the labels of the wordlist, keeping only the ones that resolve. When the
+// domain has a wildcard record, names only guessed from the wordlist that resolve to the same
+// addresses as a random label are dropped
+func (d *Discoverer) Discover(domain string, certificateNames []string, inventory dnsrecords.Inventory) []Subdomain {
+
+	domain = normalize(domain)
+	candidates := make(map[string][]string)
+
+	add := func(name, source string) {
+
+		name = normalize(strings.TrimPrefix(strings.TrimSpace(name), "*."))
+
+		if !isSubdomain(name, domain) {
+			return
+		}
+
+		for _, existing := range candidates[name] {
+			if existing == source {
+				return
+			}
+		}
+
+		candidates[name] = append(candidates[name], source)
+
+	}
+
+	for _, name := range certificateNames {
+		add(name, CertificateSource)
+	}
+
+	for _, record := range inventory.Records {
+		add(recordTarget(record), DNSSource)
+	}
+
+	for _, word := range d.Wordlist {
+		add(word+"."+domain, WordlistSource)
+	}
+
+	var names []string
+
+	for name := range candidates {
+		names = append(names, name)
+	}
+
+	random := randomLabel() + "." + domain
+	wildcard := d.resolve([]string{random})[random]
+	resolved := d.resolve(names)
+
+	var found []Subdomain
+
+	for _, name := range names {
+
+		addresses := resolved[name]
+		if len(addresses) == 0 {
+			continue
+		}
+
+		sources := candidates[name]
+
+		if len(sources) == 1 && sources[0] == WordlistSource && sameAddresses(addresses, wildcard) {
+			continue
+		}
+
+		sort.Strings(sources)
+
+		found = append(found, Subdomain{Name: name, Sources: sources, Addresses: addresses})
+
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Name < found[j].Name
+	})
+
+	return found
+
+}
+
+// resolve returns the sorted IPv4 and IPv6 addresses of each name that has any, querying
+// up to Concurrency names at a time
+func (d *Discoverer) resolve(names []string) map[string][]string {
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+
+	concurrency := d.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+	resolved := make(map[string][]string)
+
+	for _, name := range names {
+
+		wait.Add(1)
+		slots <- struct{}{}
+
+		go func(name string) {
+
+			defer wait.Done()
+			defer func() { <-slots }()
+
+			var addresses []string
+
+			for _, recordType := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+
+				records, err := d.Resolver.Query(name, recordType)
+				if err != nil {
+					continue
+				}
+
+				for _, record := range records {
+					if record.Type == dnsrecords.A || record.Type == dnsrecords.AAAA {
+						addresses = append(addresses, record.Value)
+					}
+				}
+
+			}
+
+			if len(addresses) == 0 {
+				return
+			}
+
+			sort.Strings(addresses)
+
+			mutex.Lock()
+			resolved[name] = addresses
+			mutex.Unlock()
+
+		}(name)
+
+	}
+
+	wait.Wait()
+
+	return resolved
+
+}
+
+// recordTarget returns the host a record points to, such as the exchange of an MX record
+func recordTarget(record dnsrecords.Record) string {
+
+	fields := strings.Fields(record.Value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch record.Type {
+	case dnsrecords.CNAME, dnsrecords.NS, dnsrecords.MX, dnsrecords.SRV:
+		return fields[len(fields)-1]
+	}
+
+	return ""
+
+}
+
+// isSubdomain reports whether a name is a host name strictly under the domain
+func isSubdomain(name, domain string) bool {
+
+	if name == domain || !strings.HasSuffix(name, "."+domain) {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "_") {
+			return false
+		}
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+
+	}
+
+	return true
+
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+func randomLabel() string {
+
+	var random [8]byte
+
+	rand.Read(random[:])
+
+	return "wildcard-" + hex.EncodeToString(random[:])
+
+}
+
+func sameAddresses(a, b []string) bool {
+
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+
+}
@@ -0,0 +1,79 @@
+package subdomains
+
+import (
+	"reflect"
+	"testing"
+
+	"domain-info-api/platform/dnsrecords"
+	"domain-info-api/platform/dnsrecords/dnstest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDiscover(t *testing.T) {
+
+	resolver := dnstest.Resolver{dnsmessage.TypeA: {
+		"www.example.com":       {"192.0.2.1"},
+		"mail.example.com":      {"192.0.2.2"},
+		"api.example.com":       {"192.0.2.4", "192.0.2.3"},
+		"shop.example.com":      {"192.0.2.5"},
+		"ns1.provider.net":      {"198.51.100.1"},
+		"unresolved.example.io": {"192.0.2.9"},
+	}}
+
+	inventory := dnsrecords.Inventory{
+		Records: []dnsrecords.Record{
+			{Type: dnsrecords.MX, Name: "example.com.", Value: "10 mail.example.com."},
+			{Type: dnsrecords.NS, Name: "example.com.", Value: "ns1.provider.net."},
+			{Type: dnsrecords.SRV, Name: "_sip._tcp.example.com.", Value: "10 5 5060 sip.example.com."},
+			{Type: dnsrecords.TXT, Name: "example.com.", Value: "www.example.com"},
+		},
+	}
+
+	certificateNames := []string{"example.com", "*.example.com", "WWW.example.com", "shop.example.com", "example.org"}
+
+	got := NewDiscoverer(resolver, []string{"www", "api", "dev"}).Discover("example.com", certificateNames, inventory)
+
+	want := []Subdomain{
+		{Name: "api.example.com", Sources: []string{WordlistSource}, Addresses: []string{"192.0.2.3", "192.0.2.4"}},
+		{Name: "mail.example.com", Sources: []string{DNSSource}, Addresses: []string{"192.0.2.2"}},
+		{Name: "shop.example.com", Sources: []string{CertificateSource}, Addresses: []string{"192.0.2.5"}},
+		{Name: "www.example.com", Sources: []string{CertificateSource, WordlistSource}, Addresses: []string{"192.0.2.1"}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+}
+
+func TestDiscoverWithWildcard(t *testing.T) {
+
+	resolver := dnstest.Resolver{dnsmessage.TypeA: {
+		"*.example.com":   {"192.0.2.100"},
+		"www.example.com": {"192.0.2.1"},
+	}}
+
+	got := NewDiscoverer(resolver, []string{"www", "api", "dev"}).Discover("example.com", []string{"dev.example.com"}, dnsrecords.Inventory{})
+
+	var names []string
+
+	for _, subdomain := range got {
+		names = append(names, subdomain.Name)
+	}
+
+	if want := []string{"dev.example.com", "www.example.com"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+
+}
+
+func TestParseWordlist(t *testing.T) {
+
+	got := parseWordlist("# common names\nwww\n\n API \nwww\n")
+
+	if want := []string{"www", "api"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+}
@@ -0,0 +1,150 @@
+package subdomains
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// bundledWordlist holds the labels tried when SUBDOMAIN_WORDLIST is not set, one per line,
+// covering the names most often given to hosts under a domain
+const bundledWordlist = `
+www
+www1
+www2
+api
+app
+apps
+admin
+portal
+dashboard
+console
+auth
+login
+sso
+id
+account
+accounts
+my
+mail
+email
+webmail
+smtp
+imap
+pop
+mx
+autodiscover
+autoconfig
+ns1
+ns2
+dns
+vpn
+remote
+gateway
+proxy
+cdn
+static
+assets
+media
+img
+images
+files
+download
+downloads
+upload
+blog
+news
+shop
+store
+pay
+payments
+billing
+checkout
+support
+help
+docs
+status
+dev
+develop
+staging
+stage
+test
+testing
+qa
+uat
+demo
+beta
+sandbox
+preview
+internal
+intranet
+git
+gitlab
+jenkins
+ci
+jira
+wiki
+confluence
+grafana
+kibana
+monitor
+m
+mobile
+web
+secure
+crm
+erp
+hr
+careers
+jobs
+partners
+community
+forum
+events
+ftp
+sftp
+db
+search
+`
+
+// WordlistFromEnv returns the labels listed in the file set by SUBDOMAIN_WORDLIST, one per
+// line, or the bundled ones when it is not set
+func WordlistFromEnv() ([]string, error) {
+
+	path := os.Getenv("SUBDOMAIN_WORDLIST")
+	if path == "" {
+		return parseWordlist(bundledWordlist), nil
+	}
+
+	wordlist, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWordlist(string(wordlist)), nil
+
+}
+
+// parseWordlist returns the labels of a wordlist, skipping blank lines and comments
+func parseWordlist(text string) []string {
+
+	var words []string
+
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(text, "\n") {
+
+		word := strings.ToLower(strings.TrimSpace(line))
+
+		if word == "" || strings.HasPrefix(word, "#") || seen[word] {
+			continue
+		}
+
+		seen[word] = true
+		words = append(words, word)
+
+	}
+
+	return words
+
+}
@@ -0,0 +1,30 @@
+package webscraping
+
+import (
+	"bufio"
+	"io"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// sniffLength is how much of the page is inspected looking for a byte order mark or a
+// <meta charset> declaration, as done by browsers
+const sniffLength = 1024
+
+// decodeBody returns the page transcoded to UTF-8 along with the name of its original
+// encoding, taken from the byte order mark, the Content-Type header or the <meta> tags
+// of the page, in that order, and sniffed from the content otherwise
+func decodeBody(body io.Reader, contentType string) (io.Reader, string) {
+
+	reader := bufio.NewReaderSize(body, sniffLength)
+
+	start, _ := reader.Peek(sniffLength)
+
+	encoding, name, _ := charset.DetermineEncoding(start, contentType)
+
+	// BOMOverride strips the byte order mark, which the UTF-8 decoder would otherwise keep
+	return transform.NewReader(reader, unicode.BOMOverride(encoding.NewDecoder())), name
+
+}
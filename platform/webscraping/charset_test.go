@@ -0,0 +1,89 @@
+package webscraping
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestDecodeBody(t *testing.T) {
+
+	encode := func(t *testing.T, enc encoding.Encoding, text string) []byte {
+		t.Helper()
+
+		encoded, err := enc.NewEncoder().Bytes([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return encoded
+	}
+
+	cases := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		wantCharset string
+	}{
+		{
+			name:        "Shift_JIS from the Content-Type header",
+			body:        encode(t, japanese.ShiftJIS, "<title>日本語のタイトル</title>"),
+			contentType: "text/html; charset=Shift_JIS",
+			want:        "<title>日本語のタイトル</title>",
+			wantCharset: "shift_jis",
+		},
+		{
+			name:        "Windows-1251 from a meta charset tag",
+			body:        encode(t, charmap.Windows1251, `<meta charset="windows-1251"><title>Привет</title>`),
+			contentType: "text/html",
+			want:        `<meta charset="windows-1251"><title>Привет</title>`,
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "ISO-8859-1 from a meta http-equiv tag",
+			body:        encode(t, charmap.ISO8859_1, `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><title>Café</title>`),
+			want:        `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><title>Café</title>`,
+			wantCharset: "windows-1252",
+		},
+		{
+			name:        "byte order mark wins over the header",
+			body:        append([]byte("\xef\xbb\xbf"), []byte("<title>Ünïcödé</title>")...),
+			contentType: "text/html; charset=iso-8859-1",
+			want:        "<title>Ünïcödé</title>",
+			wantCharset: "utf-8",
+		},
+		{
+			name:        "undeclared UTF-8",
+			body:        []byte("<title>Ünïcödé</title>"),
+			want:        "<title>Ünïcödé</title>",
+			wantCharset: "utf-8",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			reader, charset := decodeBody(bytes.NewReader(testCase.body), testCase.contentType)
+
+			got, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+
+			if charset != testCase.wantCharset {
+				t.Errorf("got charset %q, want %q", charset, testCase.wantCharset)
+			}
+
+		})
+	}
+
+}
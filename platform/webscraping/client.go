@@ -0,0 +1,228 @@
+package webscraping
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"domain-info-api/platform/fingerprint"
+)
+
+// DefaultUserAgent identifies the service on the websites it scrapes when SCRAPER_USER_AGENT is not set
+const DefaultUserAgent = "domain-info-api/1.0 (+https://github.com/aledeltoro/domain-info-api)"
+
+const maxRedirects = 10
+
+// Config represents how websites are requested while scraping them
+type Config struct {
+	Timeout     time.Duration
+	MaxBodySize int64
+	UserAgent   string
+}
+
+// Scraper represents a client able to scrape the website of a domain. Technologies are
+// only detected when an Engine is set
+type Scraper struct {
+	Config    Config
+	TLSConfig *tls.Config
+	Engine    *fingerprint.Engine
+
+	client         *http.Client
+	documentClient *http.Client
+}
+
+// Redirect represents a single redirect response followed to reach the website. Downgrade is
+// set when it sends the visitor from HTTPS to plain HTTP
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+	Downgrade  bool   `json:"downgrade,omitempty"`
+}
+
+// RedirectChain represents every redirect followed from the first request to the final document.
+// Downgraded is set when any of them went from HTTPS to plain HTTP
+type RedirectChain struct {
+	Hops            []Redirect `json:"hops"`
+	FinalURL        string     `json:"final_url"`
+	FinalStatusCode int        `json:"final_status_code"`
+	Downgraded      bool       `json:"downgraded"`
+}
+
+// DefaultConfig returns the configuration used when no environment variable is set
+func DefaultConfig() Config {
+
+	return Config{
+		Timeout:     10 * time.Second,
+		MaxBodySize: 5 << 20,
+		UserAgent:   DefaultUserAgent,
+	}
+
+}
+
+// ConfigFromEnv returns the default configuration overridden by the SCRAPER_TIMEOUT,
+// SCRAPER_MAX_BODY_SIZE and SCRAPER_USER_AGENT variables
+func ConfigFromEnv() Config {
+
+	config := DefaultConfig()
+
+	if timeout, err := time.ParseDuration(os.Getenv("SCRAPER_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+
+	if size, err := strconv.ParseInt(os.Getenv("SCRAPER_MAX_BODY_SIZE"), 10, 64); err == nil && size > 0 {
+		config.MaxBodySize = size
+	}
+
+	if userAgent := os.Getenv("SCRAPER_USER_AGENT"); userAgent != "" {
+		config.UserAgent = userAgent
+	}
+
+	return config
+
+}
+
+// NewScraper returns a Scraper with the given configuration
+func NewScraper(config Config) *Scraper {
+	return &Scraper{Config: config}
+}
+
+// httpClient returns the client used for the page itself, which does not follow redirects so
+// that every hop can be recorded, and the client used for the assets it references
+func (s *Scraper) httpClient(followRedirects bool) *http.Client {
+
+	if s.client == nil {
+
+		transport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout: s.Config.Timeout,
+			}).DialContext,
+			TLSClientConfig:       s.TLSConfig,
+			TLSHandshakeTimeout:   s.Config.Timeout,
+			ResponseHeaderTimeout: s.Config.Timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		}
+
+		s.client = &http.Client{Transport: transport, Timeout: s.Config.Timeout}
+
+		s.documentClient = &http.Client{
+			Transport: transport,
+			Timeout:   s.Config.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+	}
+
+	if followRedirects {
+		return s.client
+	}
+
+	return s.documentClient
+
+}
+
+// get performs a GET request identified with the configured User-Agent
+func (s *Scraper) get(target string, followRedirects bool) (*http.Response, error) {
+	return s.getTraced(target, followRedirects, nil)
+}
+
+// getTraced performs a GET request like get, reporting the connection it used to the given
+// trace when it is not nil
+func (s *Scraper) getTraced(target string, followRedirects bool, trace *httptrace.ClientTrace) (*http.Response, error) {
+
+	request, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if trace != nil {
+		request = request.WithContext(httptrace.WithClientTrace(request.Context(), trace))
+	}
+
+	request.Header.Set("User-Agent", s.Config.UserAgent)
+
+	return s.httpClient(followRedirects).Do(request)
+
+}
+
+// getDocument requests the given URL following redirects by hand, recording each of them, and
+// returns the address of the server the final response came from
+func (s *Scraper) getDocument(target string) (*http.Response, RedirectChain, string, error) {
+
+	chain := RedirectChain{Hops: []Redirect{}}
+
+	current, err := url.Parse(target)
+	if err != nil {
+		return nil, chain, "", err
+	}
+
+	var address string
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			address, _, _ = net.SplitHostPort(info.Conn.RemoteAddr().String())
+		},
+	}
+
+	for {
+
+		response, err := s.getTraced(current.String(), false, trace)
+		if err != nil {
+			return nil, chain, "", err
+		}
+
+		location := response.Header.Get("Location")
+
+		if !isRedirect(response.StatusCode) || location == "" {
+			chain.FinalURL = current.String()
+			chain.FinalStatusCode = response.StatusCode
+			return response, chain, address, nil
+		}
+
+		response.Body.Close()
+
+		redirect := Redirect{URL: current.String(), StatusCode: response.StatusCode, Location: location}
+
+		next, err := url.Parse(location)
+		if err == nil {
+			next = current.ResolveReference(next)
+			redirect.Downgrade = current.Scheme == "https" && next.Scheme == "http"
+			chain.Downgraded = chain.Downgraded || redirect.Downgrade
+		}
+
+		chain.Hops = append(chain.Hops, redirect)
+
+		if len(chain.Hops) > maxRedirects {
+			return nil, chain, "", fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		if err != nil {
+			return nil, chain, "", err
+		}
+
+		current = next
+
+	}
+
+}
+
+func isRedirect(statusCode int) bool {
+
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+
+}
@@ -0,0 +1,135 @@
+package webscraping
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFetchWebsiteInfoFollowsRedirects(t *testing.T) {
+
+	var userAgent string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/home", http.StatusMovedPermanently)
+		case "/home":
+			http.Redirect(w, r, "/home/", http.StatusFound)
+		case "/home/":
+			userAgent = r.Header.Get("User-Agent")
+			fmt.Fprint(w, "<html><head><title>Home</title></head></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	scraper := NewScraper(DefaultConfig())
+	scraper.Config.UserAgent = "test-agent"
+	scraper.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	siteInfo, customErr := scraper.FetchWebsiteInfo(domain)
+	if customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	want := RedirectChain{
+		Hops: []Redirect{
+			{URL: server.URL, StatusCode: http.StatusMovedPermanently, Location: "/home"},
+			{URL: server.URL + "/home", StatusCode: http.StatusFound, Location: "/home/"},
+		},
+		FinalURL:        server.URL + "/home/",
+		FinalStatusCode: http.StatusOK,
+	}
+
+	if !reflect.DeepEqual(siteInfo.Redirects, want) {
+		t.Errorf("got %+v, want %+v", siteInfo.Redirects, want)
+	}
+
+	if siteInfo.Title != "Home" {
+		t.Errorf("got title %q, want Home", siteInfo.Title)
+	}
+
+	if userAgent != "test-agent" {
+		t.Errorf("got User-Agent %q, want test-agent", userAgent)
+	}
+
+	if siteInfo.Address != "127.0.0.1" {
+		t.Errorf("got address %q, want the one of the test server", siteInfo.Address)
+	}
+
+}
+
+func TestFetchWebsiteInfoFlagsDowngrades(t *testing.T) {
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Plain</title></head></html>")
+	}))
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	scraper := NewScraper(DefaultConfig())
+	scraper.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	siteInfo, customErr := scraper.FetchWebsiteInfo(strings.TrimPrefix(secure.URL, "https://"))
+	if customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	want := RedirectChain{
+		Hops:            []Redirect{{URL: secure.URL, StatusCode: http.StatusFound, Location: plain.URL + "/", Downgrade: true}},
+		FinalURL:        plain.URL + "/",
+		FinalStatusCode: http.StatusOK,
+		Downgraded:      true,
+	}
+
+	if !reflect.DeepEqual(siteInfo.Redirects, want) {
+		t.Errorf("got %+v, want %+v", siteInfo.Redirects, want)
+	}
+
+}
+
+func TestFetchWebsiteInfoFallsBackToHTTP(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Plain</title></head></html>")
+	}))
+	defer server.Close()
+
+	domain := strings.TrimPrefix(server.URL, "http://")
+
+	siteInfo, customErr := NewScraper(DefaultConfig()).FetchWebsiteInfo(domain)
+	if customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	if siteInfo.Redirects.FinalURL != server.URL {
+		t.Errorf("got final URL %q, want %q", siteInfo.Redirects.FinalURL, server.URL)
+	}
+
+}
+
+func TestFetchWebsiteInfoStopsRedirectLoops(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	_, customErr := NewScraper(DefaultConfig()).FetchWebsiteInfo(strings.TrimPrefix(server.URL, "http://"))
+	if customErr == nil {
+		t.Error("wanted an error, but didn't get one")
+	}
+
+}
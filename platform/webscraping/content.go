@@ -0,0 +1,184 @@
+package webscraping
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// shingleSize is the number of consecutive words hashed together as a feature of the simhash
+const shingleSize = 3
+
+// Content represents the fingerprint of the visible text of a page. TextHash only matches
+// for identical text, while SimHash values of similar texts differ in few bits
+type Content struct {
+	TextHash  string `json:"text_hash"`
+	SimHash   string `json:"simhash"`
+	WordCount int    `json:"word_count"`
+}
+
+// blockElements holds the elements rendered apart from the text around them, whose text
+// is separated from its surroundings so that adjacent blocks do not merge into one word
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"details": true, "dialog": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+	"main": true, "nav": true, "ol": true, "option": true, "p": true, "pre": true, "section": true,
+	"summary": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// hiddenElements holds the elements whose text is never displayed
+var hiddenElements = map[string]bool{"script": true, "style": true, "noscript": true, "template": true}
+
+// fetchContent fingerprints the text of the document body, leaving out scripts and styles
+func (w *WebsiteInfo) fetchContent(document *goquery.Document) {
+
+	w.Content = NewContent(visibleText(document.Find("body").Nodes))
+
+}
+
+// visibleText returns the text of the given nodes the way it is displayed, with block
+// elements separated by whitespace and hidden elements left out
+func visibleText(nodes []*html.Node) string {
+
+	var text strings.Builder
+
+	var walk func(node *html.Node)
+
+	walk = func(node *html.Node) {
+
+		if node.Type == html.TextNode {
+			text.WriteString(node.Data)
+			return
+		}
+
+		if node.Type == html.ElementNode && hiddenElements[node.Data] {
+			return
+		}
+
+		block := node.Type == html.ElementNode && blockElements[node.Data]
+
+		if block {
+			text.WriteByte(' ')
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+
+		if block {
+			text.WriteByte(' ')
+		}
+
+	}
+
+	for _, node := range nodes {
+		walk(node)
+	}
+
+	return text.String()
+
+}
+
+// NewContent returns the fingerprint of the given text once normalized, which lowercases
+// it, collapses whitespace and masks digits so that counters and dates are not seen as changes
+func NewContent(text string) Content {
+
+	words := normalizeText(text)
+
+	normalized := strings.Join(words, " ")
+	textHash := sha256.Sum256([]byte(normalized))
+
+	return Content{
+		TextHash:  hex.EncodeToString(textHash[:]),
+		SimHash:   fmt.Sprintf("%016x", simHash(words)),
+		WordCount: len(words),
+	}
+
+}
+
+// Similarity returns how similar the texts behind two fingerprints are, from 0 to 1
+func Similarity(a, b Content) float64 {
+
+	if a.TextHash == b.TextHash {
+		return 1
+	}
+
+	first, err := strconv.ParseUint(a.SimHash, 16, 64)
+	if err != nil {
+		return 0
+	}
+
+	second, err := strconv.ParseUint(b.SimHash, 16, 64)
+	if err != nil {
+		return 0
+	}
+
+	return 1 - float64(bits.OnesCount64(first^second))/64
+
+}
+
+func normalizeText(text string) []string {
+
+	masked := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return '0'
+		}
+		return unicode.ToLower(r)
+	}, text)
+
+	return strings.Fields(masked)
+
+}
+
+// simHash returns the 64 bits simhash of the shingles of the given words
+func simHash(words []string) uint64 {
+
+	var weights [64]int
+
+	features := len(words) - shingleSize + 1
+	if features < 1 && len(words) > 0 {
+		features = 1
+	}
+
+	for i := 0; i < features; i++ {
+
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+
+		hasher := fnv.New64a()
+		hasher.Write([]byte(strings.Join(words[i:end], " ")))
+		featureHash := hasher.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if featureHash&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+
+	}
+
+	var fingerprint uint64
+
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+
+	return fingerprint
+
+}
@@ -0,0 +1,94 @@
+package webscraping
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testArticle = `The quick brown fox jumps over the lazy dog while the farmer watches from the porch.
+Every morning the fox returns to the field looking for something to eat, and every morning
+the dog pretends not to notice. The farmer has grown fond of both animals over the years
+and tells the story to anyone who visits the farm during the harvest season.`
+
+func TestFetchContentIgnoresMarkupAndScripts(t *testing.T) {
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(`<html>
+<head><title>Ignored</title><style>body { color: red }</style></head>
+<body>
+	<h1>Hello   World</h1>
+	<script>var rendered = Date.now();</script>
+	<p>Visited 1234 times</p>
+</body>
+</html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var siteInfo WebsiteInfo
+
+	siteInfo.fetchContent(document)
+
+	want := NewContent("hello world visited 0000 times")
+
+	if siteInfo.Content != want {
+		t.Errorf("got %+v, want %+v", siteInfo.Content, want)
+	}
+
+	if siteInfo.Content.WordCount != 5 {
+		t.Errorf("got %d words, want 5", siteInfo.Content.WordCount)
+	}
+
+}
+
+func TestFetchContentSeparatesBlocks(t *testing.T) {
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>` +
+		`<div>Hello</div><div>World</div><ul><li>One</li><li>Two</li></ul>line<br>break <b>bo</b>ld` +
+		`</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var siteInfo WebsiteInfo
+
+	siteInfo.fetchContent(document)
+
+	want := NewContent("hello world one two line break bold")
+
+	if siteInfo.Content != want {
+		t.Errorf("got %+v, want %+v", siteInfo.Content, want)
+	}
+
+}
+
+func TestSimilarity(t *testing.T) {
+
+	original := NewContent(testArticle)
+
+	tests := []struct {
+		name string
+		text string
+		min  float64
+		max  float64
+	}{
+		{"same text", testArticle, 1, 1},
+		{"whitespace and case", strings.ToUpper(strings.Replace(testArticle, " ", "\n ", -1)), 1, 1},
+		{"small edit", strings.Replace(testArticle, "harvest season", "summer holidays", 1), 0.8, 0.99},
+		{"defaced", "Hacked by someone. Your security is a joke, patch your servers.", 0, 0.75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := Similarity(original, NewContent(tt.text))
+
+			if got < tt.min || got > tt.max {
+				t.Errorf("got similarity %.2f, want between %.2f and %.2f", got, tt.min, tt.max)
+			}
+
+		})
+	}
+
+}
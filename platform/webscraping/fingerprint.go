@@ -0,0 +1,80 @@
+package webscraping
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"domain-info-api/platform/fingerprint"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	defaultEngine     *fingerprint.Engine
+	defaultEngineOnce sync.Once
+)
+
+// loadDefaultEngine returns the fingerprinting engine configured through the environment,
+// loading it only once
+func loadDefaultEngine() *fingerprint.Engine {
+
+	defaultEngineOnce.Do(func() {
+
+		engine, err := fingerprint.DefaultEngine()
+		if err != nil {
+			log.Printf("loadDefaultEngine: fingerprinting disabled: %s", err.Error())
+			return
+		}
+
+		defaultEngine = engine
+
+	})
+
+	return defaultEngine
+
+}
+
+// fetchTechnologies detects the technologies used by the website from the response headers
+// and cookies along with the meta tags, scripts and markup of the document. Technologies is
+// left nil when there is no engine, so that it is not mistaken for a website using none
+func (w *WebsiteInfo) fetchTechnologies(document *goquery.Document, engine *fingerprint.Engine) {
+
+	w.Technologies = nil
+
+	if engine == nil {
+		return
+	}
+
+	page := fingerprint.Page{
+		Header:  w.Headers,
+		Cookies: make(map[string]string),
+		Meta:    make(map[string]string),
+	}
+
+	for _, cookie := range (&http.Response{Header: w.Headers}).Cookies() {
+		page.Cookies[cookie.Name] = cookie.Value
+	}
+
+	document.Find("meta[name]").Each(func(index int, element *goquery.Selection) {
+
+		name, _ := element.Attr("name")
+		content, _ := element.Attr("content")
+
+		page.Meta[strings.ToLower(name)] = content
+
+	})
+
+	document.Find("script[src]").Each(func(index int, element *goquery.Selection) {
+
+		src, _ := element.Attr("src")
+		page.ScriptSrc = append(page.ScriptSrc, src)
+
+	})
+
+	page.HTML, _ = document.Html()
+
+	w.Technologies = engine.Detect(page)
+
+}
@@ -0,0 +1,68 @@
+package webscraping
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"domain-info-api/platform/fingerprint"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFetchTechnologies(t *testing.T) {
+
+	engine, err := fingerprint.NewEngine([]byte(`{
+		"Nginx": {"cats": ["Web servers"], "headers": {"Server": "nginx(?:/([\\d.]+))?\\;version:\\1"}},
+		"PHP": {"cats": ["Programming languages"], "cookies": {"PHPSESSID": ""}},
+		"WordPress": {"cats": ["CMS"], "meta": {"generator": "^WordPress ?([\\d.]+)?\\;version:\\1"}},
+		"jQuery": {"cats": ["JavaScript libraries"], "scriptSrc": ["jquery-([\\d.]+)\\.min\\.js\\;version:\\1"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><head>
+		<meta name="Generator" content="WordPress 5.4">
+		<script src="/js/jquery-3.5.1.min.js"></script>
+	</head></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	siteInfo := WebsiteInfo{Headers: http.Header{
+		"Server":     []string{"nginx/1.18.0"},
+		"Set-Cookie": []string{"PHPSESSID=abc; Path=/"},
+	}}
+
+	siteInfo.fetchTechnologies(document, engine)
+
+	want := map[string]string{"Nginx": "1.18.0", "PHP": "", "WordPress": "5.4", "jQuery": "3.5.1"}
+
+	if len(siteInfo.Technologies) != len(want) {
+		t.Fatalf("got %+v, want %v", siteInfo.Technologies, want)
+	}
+
+	for _, technology := range siteInfo.Technologies {
+		if version, exists := want[technology.Name]; !exists || version != technology.Version {
+			t.Errorf("got %s %q, want %v", technology.Name, technology.Version, want)
+		}
+	}
+
+}
+
+func TestFetchTechnologiesWithoutEngine(t *testing.T) {
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(`<html></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var siteInfo WebsiteInfo
+	siteInfo.fetchTechnologies(document, nil)
+
+	if siteInfo.Technologies != nil {
+		t.Errorf("got %+v, want nil when fingerprinting is disabled", siteInfo.Technologies)
+	}
+
+}
@@ -0,0 +1,86 @@
+package webscraping
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFetchLogo(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/favicon.ico" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/x-icon")
+		fmt.Fprint(w, "icon")
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "relative href resolved against the document URL",
+			page: `<link rel="icon" href="static/icon.png">`,
+			want: server.URL + "/blog/static/icon.png",
+		},
+		{
+			name: "protocol relative href",
+			page: `<link rel="shortcut icon" href="//cdn.example.com/fav.ico">`,
+			want: "http://cdn.example.com/fav.ico",
+		},
+		{
+			name: "base href respected",
+			page: `<base href="https://assets.example.com/v2/"><link rel="icon" href="icon.png">`,
+			want: "https://assets.example.com/v2/icon.png",
+		},
+		{
+			name: "largest declared size wins",
+			page: `<link rel="icon" href="/16.png" sizes="16x16"><link rel="apple-touch-icon" href="/180.png" sizes="180x180"><link rel="icon" href="/32.png" sizes="32x32">`,
+			want: server.URL + "/180.png",
+		},
+		{
+			name: "fallback to favicon.ico",
+			page: `<title>No icons</title>`,
+			want: server.URL + "/favicon.ico",
+		},
+		{
+			name: "fallback ignores base href",
+			page: `<base href="https://assets.example.com/v2/"><title>No icons</title>`,
+			want: server.URL + "/favicon.ico",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			document, err := goquery.NewDocumentFromReader(strings.NewReader("<html><head>" + testCase.page + "</head></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			document.Url, _ = url.Parse(server.URL + "/blog/post")
+
+			scraper := NewScraper(DefaultConfig())
+
+			var siteInfo WebsiteInfo
+			siteInfo.fetchMetadata(document, scraper)
+			siteInfo.fetchLogo(document, scraper)
+
+			if siteInfo.Logo != testCase.want {
+				t.Errorf("got %q, want %q", siteInfo.Logo, testCase.want)
+			}
+
+		})
+	}
+
+}
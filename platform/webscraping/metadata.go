@@ -0,0 +1,299 @@
+package webscraping
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const maxManifestSize = 1 << 20
+
+// Metadata represents the descriptive data declared by a website besides its title
+type Metadata struct {
+	Description  string            `json:"description"`
+	Canonical    string            `json:"canonical"`
+	Language     string            `json:"language"`
+	Charset      string            `json:"charset"`
+	Generator    string            `json:"generator"`
+	OpenGraph    map[string]string `json:"open_graph"`
+	TwitterCard  map[string]string `json:"twitter_card"`
+	Icons        []Icon            `json:"icons"`
+	Organization *Organization     `json:"organization,omitempty"`
+}
+
+// Icon represents an icon declared either by a link tag or by the web app manifest
+type Icon struct {
+	Href   string `json:"href"`
+	Rel    string `json:"rel"`
+	Sizes  string `json:"sizes,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Source string `json:"source"`
+}
+
+// Organization represents the organization described by the JSON-LD data of a website
+type Organization struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Logo   string   `json:"logo"`
+	SameAs []string `json:"same_as"`
+}
+
+// manifest represents the parts of a web app manifest we care about
+type manifest struct {
+	Icons []struct {
+		Src   string `json:"src"`
+		Sizes string `json:"sizes"`
+		Type  string `json:"type"`
+	} `json:"icons"`
+}
+
+var iconRels = map[string]bool{
+	"icon":                         true,
+	"shortcut icon":                true,
+	"apple-touch-icon":             true,
+	"apple-touch-icon-precomposed": true,
+}
+
+func (w *WebsiteInfo) fetchMetadata(document *goquery.Document, s *Scraper) {
+
+	metadata := Metadata{
+		OpenGraph:   make(map[string]string),
+		TwitterCard: make(map[string]string),
+		Icons:       []Icon{},
+	}
+
+	metadata.Language, _ = document.Find("html").First().Attr("lang")
+
+	base := documentBase(document)
+
+	if canonical, exists := document.Find(`link[rel="canonical"]`).First().Attr("href"); exists {
+		metadata.Canonical = resolveURL(base, canonical)
+	}
+
+	document.Find("meta").Each(func(index int, element *goquery.Selection) {
+
+		content, exists := element.Attr("content")
+		if !exists {
+			return
+		}
+
+		name, _ := element.Attr("name")
+		property, _ := element.Attr("property")
+
+		name = strings.ToLower(name)
+		property = strings.ToLower(property)
+
+		switch {
+		case name == "description":
+			metadata.Description = content
+		case name == "generator":
+			metadata.Generator = content
+		case strings.HasPrefix(property, "og:"):
+			metadata.OpenGraph[strings.TrimPrefix(property, "og:")] = content
+		case strings.HasPrefix(name, "twitter:"):
+			metadata.TwitterCard[strings.TrimPrefix(name, "twitter:")] = content
+		case strings.HasPrefix(property, "twitter:"):
+			metadata.TwitterCard[strings.TrimPrefix(property, "twitter:")] = content
+		}
+
+	})
+
+	document.Find("link").Each(func(index int, element *goquery.Selection) {
+
+		rel, _ := element.Attr("rel")
+		rel = strings.ToLower(strings.TrimSpace(rel))
+
+		href, exists := element.Attr("href")
+		if !exists || !iconRels[rel] {
+			return
+		}
+
+		sizes, _ := element.Attr("sizes")
+		iconType, _ := element.Attr("type")
+
+		metadata.Icons = append(metadata.Icons, Icon{Href: resolveURL(base, href), Rel: rel, Sizes: sizes, Type: iconType, Source: "link"})
+
+	})
+
+	if manifestHref, exists := document.Find(`link[rel="manifest"]`).First().Attr("href"); exists {
+		metadata.Icons = append(metadata.Icons, s.fetchManifestIcons(base, manifestHref)...)
+	}
+
+	document.Find(`script[type="application/ld+json"]`).EachWithBreak(func(index int, element *goquery.Selection) bool {
+		metadata.Organization = findOrganization(element.Text())
+		return metadata.Organization == nil
+	})
+
+	w.Metadata = metadata
+
+}
+
+// fetchManifestIcons returns the icons declared by the web app manifest found at the given href
+func (s *Scraper) fetchManifestIcons(base *url.URL, href string) []Icon {
+
+	var icons []Icon
+
+	manifestURL, err := url.Parse(resolveURL(base, href))
+	if err != nil || !manifestURL.IsAbs() {
+		return icons
+	}
+
+	response, err := s.get(manifestURL.String(), true)
+	if err != nil {
+		return icons
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return icons
+	}
+
+	var manifestObject manifest
+
+	err = json.NewDecoder(io.LimitReader(response.Body, maxManifestSize)).Decode(&manifestObject)
+	if err != nil {
+		return icons
+	}
+
+	for _, icon := range manifestObject.Icons {
+
+		iconURL, err := url.Parse(icon.Src)
+		if err != nil {
+			continue
+		}
+
+		icons = append(icons, Icon{
+			Href:   manifestURL.ResolveReference(iconURL).String(),
+			Rel:    "manifest",
+			Sizes:  icon.Sizes,
+			Type:   icon.Type,
+			Source: "manifest",
+		})
+
+	}
+
+	return icons
+
+}
+
+// size returns the largest area declared by the sizes attribute of the icon. Scalable icons
+// declared with "any" are preferred over every bitmap, while undeclared sizes count as zero
+func (i Icon) size() int {
+
+	var largest int
+
+	for _, size := range strings.Fields(strings.ToLower(i.Sizes)) {
+
+		if size == "any" {
+			return math.MaxInt32
+		}
+
+		var width, height int
+
+		if _, err := fmt.Sscanf(size, "%dx%d", &width, &height); err != nil {
+			continue
+		}
+
+		if width*height > largest {
+			largest = width * height
+		}
+
+	}
+
+	return largest
+
+}
+
+// findOrganization returns the first organization described in a JSON-LD script, looking
+// into arrays and @graph containers
+func findOrganization(script string) *Organization {
+
+	var data interface{}
+
+	if err := json.Unmarshal([]byte(script), &data); err != nil {
+		return nil
+	}
+
+	return searchOrganization(data)
+
+}
+
+func searchOrganization(data interface{}) *Organization {
+
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if organization := searchOrganization(item); organization != nil {
+				return organization
+			}
+		}
+	case map[string]interface{}:
+		if isOrganizationType(value["@type"]) {
+			return &Organization{
+				Name:   jsonString(value["name"]),
+				URL:    jsonString(value["url"]),
+				Logo:   jsonString(value["logo"]),
+				SameAs: jsonStrings(value["sameAs"]),
+			}
+		}
+		if graph, exists := value["@graph"]; exists {
+			return searchOrganization(graph)
+		}
+	}
+
+	return nil
+
+}
+
+func isOrganizationType(value interface{}) bool {
+
+	for _, schemaType := range jsonStrings(value) {
+		if strings.HasSuffix(schemaType, "Organization") || schemaType == "Corporation" {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// jsonString returns a JSON-LD value as a string, reading the "url" of nested objects such as ImageObject
+func jsonString(value interface{}) string {
+
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case map[string]interface{}:
+		return jsonString(typed["url"])
+	}
+
+	return ""
+
+}
+
+// jsonStrings returns a JSON-LD value that can be either a single string or a list of them
+func jsonStrings(value interface{}) []string {
+
+	var values []string
+
+	switch typed := value.(type) {
+	case string:
+		values = append(values, typed)
+	case []interface{}:
+		for _, item := range typed {
+			if text := jsonString(item); text != "" {
+				values = append(values, text)
+			}
+		}
+	}
+
+	return values
+
+}
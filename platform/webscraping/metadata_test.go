@@ -0,0 +1,126 @@
+package webscraping
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFetchMetadata(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manifest.json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"icons": [{"src": "icons/192.png", "sizes": "192x192", "type": "image/png"}]}`)
+	}))
+	defer server.Close()
+
+	page := `<html lang="en">
+	<head>
+		<title>Example</title>
+		<meta name="description" content="An example website">
+		<meta name="generator" content="WordPress 5.4">
+		<meta property="og:title" content="Example OG">
+		<meta property="og:image" content="https://example.com/og.png">
+		<meta name="twitter:card" content="summary">
+		<link rel="canonical" href="https://example.com/">
+		<link rel="icon" href="/favicon.png" sizes="32x32" type="image/png">
+		<link rel="apple-touch-icon" href="/apple.png" sizes="180x180">
+		<link rel="manifest" href="/manifest.json">
+		<script type="application/ld+json">
+		{"@context": "https://schema.org", "@graph": [
+			{"@type": "WebSite", "name": "Example"},
+			{"@type": "Organization", "name": "Example Inc", "url": "https://example.com",
+			 "logo": {"@type": "ImageObject", "url": "https://example.com/logo.png"},
+			 "sameAs": ["https://twitter.com/example", "https://github.com/example"]}
+		]}
+		</script>
+	</head>
+	</html>`
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document.Url, _ = url.Parse(server.URL + "/")
+
+	var siteInfo WebsiteInfo
+	siteInfo.fetchMetadata(document, NewScraper(DefaultConfig()))
+
+	want := Metadata{
+		Description: "An example website",
+		Canonical:   "https://example.com/",
+		Language:    "en",
+		Generator:   "WordPress 5.4",
+		OpenGraph:   map[string]string{"title": "Example OG", "image": "https://example.com/og.png"},
+		TwitterCard: map[string]string{"card": "summary"},
+		Icons: []Icon{
+			{Href: server.URL + "/favicon.png", Rel: "icon", Sizes: "32x32", Type: "image/png", Source: "link"},
+			{Href: server.URL + "/apple.png", Rel: "apple-touch-icon", Sizes: "180x180", Source: "link"},
+			{Href: server.URL + "/icons/192.png", Rel: "manifest", Sizes: "192x192", Type: "image/png", Source: "manifest"},
+		},
+		Organization: &Organization{
+			Name:   "Example Inc",
+			URL:    "https://example.com",
+			Logo:   "https://example.com/logo.png",
+			SameAs: []string{"https://twitter.com/example", "https://github.com/example"},
+		},
+	}
+
+	if !reflect.DeepEqual(siteInfo.Metadata, want) {
+		t.Errorf("got %+v, want %+v", siteInfo.Metadata, want)
+	}
+
+}
+
+func TestFetchMetadataBoundsManifestRequest(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	page := `<html><head>
+		<link rel="icon" href="/favicon.png">
+		<link rel="manifest" href="/manifest.json">
+	</head></html>`
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document.Url, _ = url.Parse(server.URL + "/")
+
+	config := DefaultConfig()
+	config.Timeout = 100 * time.Millisecond
+
+	started := time.Now()
+
+	var siteInfo WebsiteInfo
+	siteInfo.fetchMetadata(document, NewScraper(config))
+
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("got metadata after %s, want the manifest request to time out", elapsed)
+	}
+
+	want := []Icon{{Href: server.URL + "/favicon.png", Rel: "icon", Source: "link"}}
+
+	if !reflect.DeepEqual(siteInfo.Metadata.Icons, want) {
+		t.Errorf("got %+v, want %+v", siteInfo.Metadata.Icons, want)
+	}
+
+}
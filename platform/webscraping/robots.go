@@ -0,0 +1,199 @@
+package webscraping
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxRobotsSize is the part of a robots.txt file crawlers are required to parse
+const maxRobotsSize = 500 << 10
+
+// Robots represents what the robots.txt file of a website declares for the service
+type Robots struct {
+	URL             string    `json:"url"`
+	Found           bool      `json:"found"`
+	StatusCode      int       `json:"status_code"`
+	HomepageAllowed bool      `json:"homepage_allowed"`
+	Sitemaps        []Sitemap `json:"sitemaps"`
+}
+
+// robotsRule represents an Allow or Disallow line of a robots.txt group
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsFile represents a parsed robots.txt file
+type robotsFile struct {
+	groups   map[string][]robotsRule
+	sitemaps []string
+}
+
+// fetchRobots requests the robots.txt file of the domain over HTTPS first and decides
+// whether the homepage can be scraped. Following RFC 9309, a missing file allows
+// everything while a server error disallows everything. When the website cannot be
+// reached at all, the homepage is left to fail on its own
+func (s *Scraper) fetchRobots(domain string) (Robots, *robotsFile) {
+
+	robots := Robots{Sitemaps: []Sitemap{}}
+
+	var response *http.Response
+	var err error
+
+	for _, scheme := range []string{"https://", "http://"} {
+
+		robots.URL = scheme + domain + "/robots.txt"
+
+		response, err = s.get(robots.URL, true)
+		if err == nil {
+			break
+		}
+
+	}
+
+	if err != nil {
+		robots.HomepageAllowed = true
+		return robots, nil
+	}
+
+	defer response.Body.Close()
+
+	robots.StatusCode = response.StatusCode
+
+	switch {
+	case response.StatusCode >= http.StatusInternalServerError:
+		return robots, nil
+	case response.StatusCode >= http.StatusBadRequest:
+		robots.HomepageAllowed = true
+		return robots, &robotsFile{}
+	}
+
+	file := parseRobots(io.LimitReader(response.Body, maxRobotsSize))
+
+	robots.Found = true
+	robots.HomepageAllowed = file.allowed(productToken(s.Config.UserAgent), "/")
+
+	return robots, file
+
+}
+
+// parseRobots returns the groups of rules by lowercase user agent along with the declared sitemaps
+func parseRobots(body io.Reader) *robotsFile {
+
+	file := &robotsFile{groups: make(map[string][]robotsRule)}
+
+	var agents []string
+	var readingAgents bool
+
+	scanner := bufio.NewScanner(body)
+
+	for scanner.Scan() {
+
+		line := scanner.Text()
+
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		switch field {
+		case "user-agent":
+			if !readingAgents {
+				agents = nil
+			}
+			readingAgents = true
+			agents = append(agents, strings.ToLower(value))
+			if _, exists := file.groups[strings.ToLower(value)]; !exists {
+				file.groups[strings.ToLower(value)] = []robotsRule{}
+			}
+		case "allow", "disallow":
+			readingAgents = false
+			if value == "" {
+				continue
+			}
+			for _, agent := range agents {
+				file.groups[agent] = append(file.groups[agent], robotsRule{allow: field == "allow", pattern: value})
+			}
+		case "sitemap":
+			file.sitemaps = append(file.sitemaps, value)
+		default:
+			readingAgents = false
+		}
+
+	}
+
+	return file
+
+}
+
+// allowed reports whether the given path can be requested by the user agent, applying the
+// most specific matching rule and preferring Allow on ties
+func (f *robotsFile) allowed(agent, path string) bool {
+
+	rules, exists := f.groups[strings.ToLower(agent)]
+	if !exists {
+		rules, exists = f.groups["*"]
+	}
+
+	if !exists {
+		return true
+	}
+
+	allowed := true
+	longest := -1
+
+	for _, rule := range rules {
+
+		if !robotsPatternMatches(rule.pattern, path) {
+			continue
+		}
+
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			longest = len(rule.pattern)
+			allowed = rule.allow
+		}
+
+	}
+
+	return allowed
+
+}
+
+// robotsPatternMatches reports whether a path matches a rule using the * and $ wildcards
+func robotsPatternMatches(pattern, path string) bool {
+
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	expression := "^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	if anchored {
+		expression += "$"
+	}
+
+	matched, err := regexp.MatchString(expression, path)
+
+	return err == nil && matched
+
+}
+
+// productToken returns the name robots.txt groups use to address the given User-Agent
+func productToken(userAgent string) string {
+
+	token := strings.Fields(userAgent)
+	if len(token) == 0 {
+		return ""
+	}
+
+	return strings.SplitN(token[0], "/", 2)[0]
+
+}
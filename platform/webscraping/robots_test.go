@@ -0,0 +1,237 @@
+package webscraping
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRobots = `
+# comments are ignored
+User-agent: *
+Disallow: /private
+Allow: /private/open$
+
+User-agent: test-agent
+User-agent: other-agent
+Disallow: /
+Allow: /public/*.html
+
+Sitemap: /sitemap_index.xml
+`
+
+func TestRobotsAllowed(t *testing.T) {
+
+	file := parseRobots(strings.NewReader(testRobots))
+
+	tests := []struct {
+		agent string
+		path  string
+		want  bool
+	}{
+		{"anyone", "/", true},
+		{"anyone", "/private/page", false},
+		{"anyone", "/private/open", true},
+		{"anyone", "/private/open/more", false},
+		{"test-agent", "/", false},
+		{"Test-Agent", "/about", false},
+		{"other-agent", "/public/index.html", true},
+		{"other-agent", "/public/index.php", false},
+	}
+
+	for _, tt := range tests {
+		if got := file.allowed(tt.agent, tt.path); got != tt.want {
+			t.Errorf("allowed(%q, %q) = %v, want %v", tt.agent, tt.path, got, tt.want)
+		}
+	}
+
+	if len(file.sitemaps) != 1 || file.sitemaps[0] != "/sitemap_index.xml" {
+		t.Errorf("got sitemaps %v, want [/sitemap_index.xml]", file.sitemaps)
+	}
+
+}
+
+func TestProductToken(t *testing.T) {
+
+	if got := productToken(DefaultUserAgent); got != "domain-info-api" {
+		t.Errorf("got %q, want domain-info-api", got)
+	}
+
+}
+
+func TestFetchWebsiteInfoHonorsRobots(t *testing.T) {
+
+	var homepageRequested bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: test-agent\nDisallow: /\n")
+		default:
+			homepageRequested = true
+			fmt.Fprint(w, "<html><head><title>Home</title></head></html>")
+		}
+	}))
+	defer server.Close()
+
+	scraper := NewScraper(DefaultConfig())
+	scraper.Config.UserAgent = "test-agent/2.0"
+
+	siteInfo, customErr := scraper.FetchWebsiteInfo(strings.TrimPrefix(server.URL, "http://"))
+	if customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	if homepageRequested || siteInfo.Title != "" {
+		t.Errorf("homepage was scraped although robots.txt disallows it")
+	}
+
+	if !siteInfo.Robots.Found || siteInfo.Robots.HomepageAllowed {
+		t.Errorf("got %+v, want a found robots.txt disallowing the homepage", siteInfo.Robots)
+	}
+
+}
+
+func TestFetchWebsiteInfoReadsSitemaps(t *testing.T) {
+
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+	fmt.Fprint(writer, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/c</loc><lastmod>2020-03-01T10:00:00+02:00</lastmod></url>
+</urlset>`)
+	writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nAllow: /\n\nSitemap: /sitemap_index.xml\n")
+		case "/sitemap_index.xml":
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>/pages.xml</loc><lastmod>2019-12-31</lastmod></sitemap>
+	<sitemap><loc>/posts.xml.gz</loc></sitemap>
+</sitemapindex>`)
+		case "/pages.xml":
+			fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/a</loc><lastmod>2020-01-15</lastmod></url>
+	<url><loc>https://example.com/b</loc></url>
+</urlset>`)
+		case "/posts.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(compressed.Bytes())
+		default:
+			fmt.Fprint(w, "<html><head><title>Home</title></head></html>")
+		}
+	}))
+	defer server.Close()
+
+	siteInfo, customErr := NewScraper(DefaultConfig()).FetchWebsiteInfo(strings.TrimPrefix(server.URL, "http://"))
+	if customErr != nil {
+		t.Fatalf("got an error, but didn't want one: %v", customErr)
+	}
+
+	sitemaps := siteInfo.Robots.Sitemaps
+	if len(sitemaps) != 1 {
+		t.Fatalf("got %d sitemaps, want 1", len(sitemaps))
+	}
+
+	index := sitemaps[0]
+
+	if !index.Index || len(index.Sitemaps) != 2 {
+		t.Fatalf("got %+v, want an index listing 2 sitemaps", index)
+	}
+
+	if index.Sitemaps[1].URL != server.URL+"/posts.xml.gz" || index.Sitemaps[1].Error != "" {
+		t.Errorf("got %+v, want the compressed sitemap to be read", index.Sitemaps[1])
+	}
+
+	if index.URLCount != 3 {
+		t.Errorf("got %d URLs, want 3", index.URLCount)
+	}
+
+	want := time.Date(2020, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	if index.LastModified == nil || !index.LastModified.Equal(want) {
+		t.Errorf("got last modified %v, want %v", index.LastModified, want)
+	}
+
+}
+
+func TestFetchSitemapsFallsBackToDefaultLocation(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprint(w, `<urlset><url><loc>https://example.com/</loc></url></urlset>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	scraper := NewScraper(DefaultConfig())
+
+	robots, file := scraper.fetchRobots(strings.TrimPrefix(server.URL, "http://"))
+
+	if robots.Found || !robots.HomepageAllowed {
+		t.Errorf("got %+v, want a missing robots.txt allowing everything", robots)
+	}
+
+	sitemaps := scraper.fetchSitemaps(robots, file)
+
+	if len(sitemaps) != 1 || sitemaps[0].URLCount != 1 {
+		t.Errorf("got %+v, want /sitemap.xml with 1 URL", sitemaps)
+	}
+
+}
+
+func TestFetchSitemapsBoundsRequests(t *testing.T) {
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nAllow: /\n\nSitemap: /sitemap_index.xml\n")
+		case "/sitemap_index.xml":
+			requests++
+			fmt.Fprint(w, "<sitemapindex>")
+			for i := 0; i < maxIndexedSitemaps; i++ {
+				fmt.Fprintf(w, "<sitemap><loc>/sitemap-%d.xml</loc></sitemap>", i)
+			}
+			fmt.Fprint(w, "</sitemapindex>")
+		default:
+			requests++
+			fmt.Fprintf(w, "<urlset><url><loc>https://example.com%s</loc></url>%s</urlset>", r.URL.Path, strings.Repeat(" ", 4096))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.MaxBodySize = 2048
+
+	scraper := NewScraper(config)
+
+	robots, file := scraper.fetchRobots(strings.TrimPrefix(server.URL, "http://"))
+	sitemaps := scraper.fetchSitemaps(robots, file)
+
+	if requests != maxSitemapRequests {
+		t.Errorf("got %d sitemap requests, want %d", requests, maxSitemapRequests)
+	}
+
+	if len(sitemaps) != 1 || len(sitemaps[0].Sitemaps) != maxSitemapRequests-1 {
+		t.Fatalf("got %+v, want an index listing %d sitemaps", sitemaps, maxSitemapRequests-1)
+	}
+
+	if child := sitemaps[0].Sitemaps[0]; child.Error == "" {
+		t.Errorf("got %+v, want the sitemap larger than the configured body size to be cut short", child)
+	}
+
+}
@@ -2,62 +2,116 @@ package webscraping
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 
 	wrappedErr "domain-info-api/platform/errorhandling"
+	"domain-info-api/platform/fingerprint"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 // WebsiteInfo represents the scraped data from a given domain
 type WebsiteInfo struct {
-	Title string
-	Logo  string
+	Title        string
+	Logo         string
+	Metadata     Metadata
+	Redirects    RedirectChain
+	Headers      http.Header
+	Address      string
+	Technologies []fingerprint.Technology
+	Robots       Robots
+	Content      Content
 }
 
-// FetchWebsiteInfo returns a new instance of WebsiteInfo w
+// page represents what is known about the response the document was parsed from
+type page struct {
+	redirects RedirectChain
+	charset   string
+	header    http.Header
+	address   string
+}
+
+// FetchWebsiteInfo returns a new instance of WebsiteInfo with the configuration found in the environment
 func FetchWebsiteInfo(domain string) (WebsiteInfo, *wrappedErr.Error) {
+	scraper := NewScraper(ConfigFromEnv())
+	scraper.Engine = loadDefaultEngine()
+
+	return scraper.FetchWebsiteInfo(domain)
+}
+
+// FetchWebsiteInfo returns a new instance of WebsiteInfo for the given domain. The homepage
+// is only scraped when robots.txt allows it, otherwise just Robots is filled
+func (s *Scraper) FetchWebsiteInfo(domain string) (WebsiteInfo, *wrappedErr.Error) {
 
 	var siteInfo WebsiteInfo
 
-	document, customErr := scrapeDocument(domain)
+	robots, rules := s.fetchRobots(domain)
+	robots.Sitemaps = s.fetchSitemaps(robots, rules)
+
+	siteInfo.Robots = robots
+
+	if !robots.HomepageAllowed {
+		log.Printf("FetchWebsiteInfo: robots.txt of %s does not allow scraping the homepage", domain)
+		return siteInfo, nil
+	}
+
+	document, scraped, customErr := s.scrapeDocument(domain)
 	if customErr != nil {
 		return WebsiteInfo{}, customErr
 	}
 
+	siteInfo.Redirects = scraped.redirects
+	siteInfo.Headers = scraped.header
+	siteInfo.Address = scraped.address
+
 	siteInfo.fetchTitle(document)
-	siteInfo.fetchLogo(document)
+	siteInfo.fetchMetadata(document, s)
+	siteInfo.Metadata.Charset = scraped.charset
+	siteInfo.fetchLogo(document, s)
+	siteInfo.fetchTechnologies(document, s.Engine)
+	siteInfo.fetchContent(document)
 
 	return siteInfo, nil
 
 }
 
-func scrapeDocument(domain string) (*goquery.Document, *wrappedErr.Error) {
+// scrapeDocument requests the website over HTTPS first, falling back to HTTP when the
+// HTTPS request cannot be completed at all, and parses it once transcoded to UTF-8
+func (s *Scraper) scrapeDocument(domain string) (*goquery.Document, page, *wrappedErr.Error) {
 
 	var customErr *wrappedErr.Error
 
-	protocol := "http://"
-
-	response, err := http.Get(protocol + domain)
+	response, redirects, address, err := s.getDocument("https://" + domain)
+	if err != nil {
+		log.Printf("scrapeDocument: HTTPS request to %s failed, falling back to HTTP: %s", domain, err.Error())
+		response, redirects, address, err = s.getDocument("http://" + domain)
+	}
 	if err != nil {
 		errMessage := fmt.Sprintf("Error: %s", err.Error())
 		customErr = wrappedErr.New(http.StatusInternalServerError, "scrapeDocument", errMessage)
 		log.Println(customErr)
-		return &goquery.Document{}, customErr
+		return &goquery.Document{}, page{redirects: redirects}, customErr
 	}
 
 	defer response.Body.Close()
 
-	document, err := goquery.NewDocumentFromReader(response.Body)
+	body, encoding := decodeBody(io.LimitReader(response.Body, s.Config.MaxBodySize), response.Header.Get("Content-Type"))
+
+	document, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
 		errMessage := fmt.Sprintf("Error: %s", err.Error())
 		customErr = wrappedErr.New(http.StatusInternalServerError, "scrapeDocument", errMessage)
 		log.Println(customErr)
-		return &goquery.Document{}, customErr
+		return &goquery.Document{}, page{redirects: redirects}, customErr
 	}
 
-	return document, nil
+	document.Url = response.Request.URL
+
+	return document, page{redirects: redirects, charset: encoding, header: response.Header, address: address}, nil
 
 }
 
@@ -68,26 +122,92 @@ func (w *WebsiteInfo) fetchTitle(document *goquery.Document) {
 
 }
 
-func (w *WebsiteInfo) fetchLogo(document *goquery.Document) {
+// fetchLogo picks the largest declared icon as the logo, resolved against the document URL,
+// falling back to the /favicon.ico of the host that served the page when it declares no icon
+// at all, since browsers ignore <base href> for that request
+func (w *WebsiteInfo) fetchLogo(document *goquery.Document, s *Scraper) {
 
-	document.Find("link").EachWithBreak(func(index int, element *goquery.Selection) bool {
+	var best *Icon
 
-		rel, _ := element.Attr("rel")
+	for i := range w.Metadata.Icons {
 
-		var iconExists bool = rel == "shortcut icon" || rel == "icon"
+		icon := &w.Metadata.Icons[i]
 
-		if iconExists {
+		if best == nil || icon.size() > best.size() {
+			best = icon
+		}
 
-			logo, exists := element.Attr("href")
-			if exists {
-				w.Logo = logo
-				return false
-			}
+	}
 
-		}
+	if best != nil {
+		w.Logo = best.Href
+		return
+	}
+
+	base := document.Url
+	if base == nil || !base.IsAbs() {
+		return
+	}
+
+	favicon := base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()
+
+	if s.faviconExists(favicon) {
+		w.Logo = favicon
+	}
+
+}
+
+// documentBase returns the URL relative references of the document are resolved against,
+// which is the final URL of the response unless overridden by a <base href> tag
+func documentBase(document *goquery.Document) *url.URL {
+
+	base := document.Url
+
+	href, exists := document.Find("base[href]").First().Attr("href")
+	if !exists {
+		return base
+	}
+
+	baseHref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return base
+	}
+
+	if base == nil {
+		return baseHref
+	}
+
+	return base.ResolveReference(baseHref)
+
+}
+
+// resolveURL returns the given reference resolved against the base URL, or the reference
+// untouched when it cannot be resolved
+func resolveURL(base *url.URL, href string) string {
+
+	href = strings.TrimSpace(href)
+
+	reference, err := url.Parse(href)
+	if err != nil || base == nil {
+		return href
+	}
+
+	return base.ResolveReference(reference).String()
+
+}
+
+// faviconExists reports whether the given URL serves something other than an error or HTML page
+func (s *Scraper) faviconExists(favicon string) bool {
+
+	response, err := s.get(favicon, true)
+	if err != nil {
+		return false
+	}
+
+	defer response.Body.Close()
 
-		return true
+	contentType := response.Header.Get("Content-Type")
 
-	})
+	return response.StatusCode == http.StatusOK && !strings.HasPrefix(contentType, "text/html")
 
 }
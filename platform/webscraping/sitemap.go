@@ -0,0 +1,264 @@
+package webscraping
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Limits of the sitemaps requested for a single domain. Indexes are only followed one level
+// deep, and no more than maxSitemapRequests sitemaps are requested overall, each of them read
+// up to the configured body size once uncompressed
+const (
+	maxSitemaps        = 5
+	maxIndexedSitemaps = 20
+	maxSitemapRequests = 10
+)
+
+// sitemapBudget bounds the requests made and the time spent fetching the sitemaps of a domain
+type sitemapBudget struct {
+	requests int
+	deadline time.Time
+}
+
+// Sitemap represents a sitemap declared by a website. URLCount and LastModified of an
+// index add up the sitemaps it lists that could be fetched
+type Sitemap struct {
+	URL          string     `json:"url"`
+	Index        bool       `json:"index"`
+	URLCount     int        `json:"url_count"`
+	LastModified *time.Time `json:"last_modified"`
+	Sitemaps     []Sitemap  `json:"sitemaps,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// lastModifiedLayouts holds the W3C datetime formats allowed in a <lastmod> tag
+var lastModifiedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// fetchSitemaps requests the sitemaps declared by robots.txt, or /sitemap.xml when it
+// declares none, as long as robots.txt allows it. No new sitemap is requested once the
+// configured timeout has elapsed or maxSitemapRequests have been made
+func (s *Scraper) fetchSitemaps(robots Robots, file *robotsFile) []Sitemap {
+
+	sitemaps := []Sitemap{}
+
+	if file == nil {
+		return sitemaps
+	}
+
+	declared := file.sitemaps
+
+	if len(declared) == 0 && file.allowed(productToken(s.Config.UserAgent), "/sitemap.xml") {
+		declared = []string{strings.TrimSuffix(robots.URL, "/robots.txt") + "/sitemap.xml"}
+	}
+
+	budget := &sitemapBudget{requests: maxSitemapRequests, deadline: time.Now().Add(s.Config.Timeout)}
+
+	for i, location := range declared {
+
+		if i == maxSitemaps || !budget.take() {
+			break
+		}
+
+		sitemap, children := s.fetchSitemap(resolveSitemapURL(robots.URL, location))
+
+		if sitemap.Index {
+
+			for j, child := range children {
+
+				if j == maxIndexedSitemaps || !budget.take() {
+					break
+				}
+
+				childSitemap, _ := s.fetchSitemap(child)
+
+				sitemap.URLCount += childSitemap.URLCount
+				sitemap.LastModified = latest(sitemap.LastModified, childSitemap.LastModified)
+				sitemap.Sitemaps = append(sitemap.Sitemaps, childSitemap)
+
+			}
+
+		}
+
+		// /sitemap.xml is only a guess, so it is left out when it cannot be fetched
+		if len(file.sitemaps) == 0 && sitemap.Error != "" {
+			continue
+		}
+
+		sitemaps = append(sitemaps, sitemap)
+
+	}
+
+	return sitemaps
+
+}
+
+// take tells whether another sitemap may be requested, counting it against the budget
+func (b *sitemapBudget) take() bool {
+
+	if b.requests == 0 || time.Now().After(b.deadline) {
+		return false
+	}
+
+	b.requests--
+
+	return true
+
+}
+
+// fetchSitemap requests a single sitemap, returning the sitemaps it lists when it is an index
+func (s *Scraper) fetchSitemap(location string) (Sitemap, []string) {
+
+	sitemap := Sitemap{URL: location}
+
+	response, err := s.get(location, true)
+	if err != nil {
+		sitemap.Error = err.Error()
+		return sitemap, nil
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		sitemap.Error = fmt.Sprintf("unexpected status code %d", response.StatusCode)
+		return sitemap, nil
+	}
+
+	body, err := decompressSitemap(response.Body)
+	if err != nil {
+		sitemap.Error = err.Error()
+		return sitemap, nil
+	}
+
+	children, err := parseSitemap(io.LimitReader(body, s.Config.MaxBodySize), &sitemap)
+	if err != nil {
+		sitemap.Error = err.Error()
+	}
+
+	return sitemap, children
+
+}
+
+// decompressSitemap returns the body of a sitemap served gzipped, as sitemap.xml.gz files
+// usually are, or the body untouched otherwise
+func decompressSitemap(body io.Reader) (io.Reader, error) {
+
+	reader := bufio.NewReader(body)
+
+	magic, _ := reader.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return reader, nil
+	}
+
+	return gzip.NewReader(reader)
+
+}
+
+// parseSitemap counts the URLs of a <urlset> or collects the locations of a <sitemapindex>,
+// keeping the latest <lastmod> found in either
+func parseSitemap(body io.Reader, sitemap *Sitemap) ([]string, error) {
+
+	var children []string
+	var root bool
+
+	decoder := xml.NewDecoder(body)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	for {
+
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return children, err
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch element.Name.Local {
+		case "urlset":
+			root = true
+		case "sitemapindex":
+			root = true
+			sitemap.Index = true
+		case "url":
+			sitemap.URLCount++
+		case "loc":
+			var location string
+			if err := decoder.DecodeElement(&location, &element); err != nil {
+				return children, err
+			}
+			if sitemap.Index {
+				children = append(children, resolveSitemapURL(sitemap.URL, location))
+			}
+		case "lastmod":
+			var value string
+			if err := decoder.DecodeElement(&value, &element); err != nil {
+				return children, err
+			}
+			sitemap.LastModified = latest(sitemap.LastModified, parseLastModified(value))
+		}
+
+	}
+
+	if !root {
+		return children, fmt.Errorf("not a sitemap")
+	}
+
+	return children, nil
+
+}
+
+func resolveSitemapURL(base, location string) string {
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return strings.TrimSpace(location)
+	}
+
+	return resolveURL(baseURL, location)
+
+}
+
+func parseLastModified(value string) *time.Time {
+
+	value = strings.TrimSpace(value)
+
+	for _, layout := range lastModifiedLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			parsed = parsed.UTC()
+			return &parsed
+		}
+	}
+
+	return nil
+
+}
+
+func latest(current, candidate *time.Time) *time.Time {
+
+	if current == nil || (candidate != nil && candidate.After(*current)) {
+		return candidate
+	}
+
+	return current
+
+}